@@ -0,0 +1,29 @@
+package blockchain
+
+import (
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ChainClient 与节点交互所需的方法集合，*ethclient.Client 与模拟链的 simulated.Client 都满足
+type ChainClient interface {
+	ethereum.BlockNumberReader
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.GasPricer1559
+	ethereum.FeeHistoryReader
+	ethereum.LogFilterer
+	ethereum.PendingStateReader
+	ethereum.PendingContractCaller
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+	ethereum.ChainIDReader
+}
+
+// Dial 按 RPC 地址建立连接，测试中可替换为模拟链
+var Dial = func(rpcURL string) (ChainClient, error) {
+	return ethclient.Dial(rpcURL)
+}
@@ -2,249 +2,221 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"log"
-	"math/big"
+	"errors"
+	"fmt"
 	"scos/config"
-	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// BlockchainClient 各链客户端的注册表，链可以在运行时添加、停用或移除
 type BlockchainClient struct {
-	clients    map[string]*cli
-	privateKey *ecdsa.PrivateKey
+	mu       sync.RWMutex
+	clients  map[string]*cli
+	signers  map[string]Signer // 按名称共享的签名器，运行时添加的链复用同名实例
+	decimals sync.Map          // 代币精度不会变化，按 链/地址 缓存
 }
 
 type cli struct {
-	client       *ethclient.Client
+	client       ChainClient
+	pool         *rpcPool
 	VaultAddress common.Address
+	info         config.ChainInfo
+	tm           *txManager // 运营账户，调用金库合约
+	scosTM       *txManager // SCOS owner，调用 mint；与运营账户使用同一签名器时与 tm 相同
+	disabled     atomic.Bool
+
+	mcMu      sync.Mutex // 保护 Multicall3 检测结果
+	mcChecked bool
+	mcFound   bool
 }
 
-func NewBlockchainClient(chains map[string]config.ChainInfo, privateKeyHex string) (*BlockchainClient, error) {
-	clients := make(map[string]*cli)
+// NewBlockchainClient signers 以 ChainInfo.Signer 名称为键，默认签名器的键为 config.DefaultSigner。
+// RPC 连接失败的链以不可用状态启动，由 RunHealthChecks 在后台重连
+func NewBlockchainClient(chains map[string]config.ChainInfo, signers map[string]Signer) (*BlockchainClient, error) {
+	bc := &BlockchainClient{
+		clients: make(map[string]*cli),
+		signers: make(map[string]Signer),
+	}
+	for name, signer := range signers {
+		bc.signers[name] = signer
+	}
 
 	for chainName, chain := range chains {
-		client, err := ethclient.Dial(chain.RPC)
-		if err != nil {
-			return nil, err
+		signer := signers[signerName(chain)]
+		if signer == nil {
+			return nil, fmt.Errorf("chain %s: signer %q not provided", chainName, signerName(chain))
 		}
-		clients[chainName] = &cli{
-			client:       client,
-			VaultAddress: common.HexToAddress(chain.VaultAddress),
+		scosSigner := signers[scosSignerName(chain)]
+		if scosSigner == nil {
+			return nil, fmt.Errorf("chain %s: scos signer %q not provided", chainName, scosSignerName(chain))
 		}
+		bc.clients[chainName] = newCli(chainName, chain, signer, scosSigner)
 	}
-
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
-	if err != nil {
-		return nil, err
-	}
-
-	return &BlockchainClient{
-		clients:    clients,
-		privateKey: privateKey,
-	}, nil
-}
-
-func (bc *BlockchainClient) GetClient(chain string) *ethclient.Client {
-	info := bc.clients[chain]
-	if info == nil {
-		log.Panicf("chain %s not found", chain)
-	}
-	return info.client
+	return bc, nil
 }
 
-func (bc *BlockchainClient) GetVaultAddr(chain string) common.Address {
-	return bc.clients[chain].VaultAddress
+func newCli(name string, chain config.ChainInfo, signer, scosSigner Signer) *cli {
+	pool := newRPCPool(name, chain.Endpoints())
+	c := &cli{
+		client:       pool,
+		pool:         pool,
+		VaultAddress: common.HexToAddress(chain.VaultAddress),
+		info:         chain,
+		tm:           newTxManager(name, pool, signer, chain.Fees),
+	}
+	// 同一账户的交易必须经由同一个 txManager 分配 nonce
+	c.scosTM = c.tm
+	if scosSignerName(chain) != signerName(chain) {
+		c.scosTM = newTxManager(name, pool, scosSigner, chain.Fees)
+	}
+	return c
 }
 
-func (bc *BlockchainClient) auth(chain string) (*bind.TransactOpts, error) {
-	chainID, err := bc.GetClient(chain).ChainID(context.Background())
-	if err != nil {
-		return nil, err
+// txManagers 该链所有发送交易的账户，去重
+func (c *cli) txManagers() []*txManager {
+	if c.scosTM == c.tm {
+		return []*txManager{c.tm}
 	}
-	return bind.NewKeyedTransactorWithChainID(bc.privateKey, chainID)
-
+	return []*txManager{c.tm, c.scosTM}
 }
 
-func (bc *BlockchainClient) StakeStock(chain, tokenAddr string, amount *big.Int, scosAmount *big.Int) (string, error) {
-	client := bc.GetClient(chain)
-
-	contractABI := `[{"inputs":[{"internalType":"address","name":"token","type":"address"},{"internalType":"uint256","name":"amount","type":"uint256"},{"internalType":"uint256","name":"scosAmount","type":"uint256"}],"name":"stakeStock","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
-
-	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
-	if err != nil {
-		return "", err
+// confirmations 交易视为最终确认所需的区块数
+func (c *cli) confirmations() uint64 {
+	if c.info.Confirmations > 0 {
+		return c.info.Confirmations
 	}
+	return 1
+}
 
-	data, err := parsedABI.Pack("stakeStock", common.HexToAddress(tokenAddr), amount, scosAmount)
-	if err != nil {
-		return "", err
-	}
-
-	auth, err := bc.auth(chain)
-	if err != nil {
-		return "", err
-	}
-
-	nonce, err := client.PendingNonceAt(context.Background(), auth.From)
-	if err != nil {
-		return "", err
-	}
-
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return "", err
-	}
-
-	msg := ethereum.CallMsg{
-		From: auth.From,
-		To:   &common.Address{},
-		Data: data,
-	}
-	gasLimit, err := client.EstimateGas(context.Background(), msg)
-	if err != nil {
-		gasLimit = 300000 // fallback
-	}
-
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = gasLimit
-	auth.GasPrice = gasPrice
-
-	boundContract := bind.NewBoundContract(bc.GetVaultAddr(chain), parsedABI, client, client, client)
-	tx, err := boundContract.Transact(auth, "stakeStock", common.HexToAddress(tokenAddr), amount, scosAmount)
-	if err != nil {
-		return "", err
+// NewSigners 为所有链引用到的签名器各创建一个实例，多条链引用同一名称时共享
+func NewSigners(cfg *config.Config) (map[string]Signer, error) {
+	signers := make(map[string]Signer)
+	for chainName, chain := range cfg.Chains {
+		for _, name := range []string{signerName(chain), scosSignerName(chain)} {
+			if _, ok := signers[name]; ok {
+				continue
+			}
+			sc, ok := cfg.SignerConfig(name)
+			if !ok {
+				return nil, fmt.Errorf("chain %s: signer %q is not defined", chainName, name)
+			}
+			signer, err := NewSigner(sc)
+			if err != nil {
+				return nil, fmt.Errorf("signer %s: %w", name, err)
+			}
+			signers[name] = signer
+		}
 	}
-
-	return tx.Hash().Hex(), nil
+	return signers, nil
 }
 
-func (bc *BlockchainClient) UnstakeStock(chain, tokenAddr string) (string, error) {
-	client := bc.GetClient(chain)
-
-	contractABI := `[{"inputs":[{"internalType":"address","name":"token","type":"address"}],"name":"unstakeStock","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
-
-	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
-	if err != nil {
-		return "", err
+func signerName(chain config.ChainInfo) string {
+	if chain.Signer == "" {
+		return config.DefaultSigner
 	}
+	return chain.Signer
+}
 
-	data, err := parsedABI.Pack("unstakeStock", common.HexToAddress(tokenAddr))
-	if err != nil {
-		return "", err
+// scosSignerName SCOS owner 的签名器名称，未配置 scos_signer 时与运营账户相同
+func scosSignerName(chain config.ChainInfo) string {
+	if chain.SCOSSigner == "" {
+		return signerName(chain)
 	}
+	return chain.SCOSSigner
+}
 
-	auth, err := bc.auth(chain)
-	if err != nil {
-		return "", err
-	}
+// RunHealthChecks 定期探测所有链的 RPC 节点，切换到健康节点并重连不可用的节点
+func (bc *BlockchainClient) RunHealthChecks(ctx context.Context, cfg config.HealthConfig) {
+	ticker := time.NewTicker(min(cfg.Interval, cfg.ReconnectMin))
+	defer ticker.Stop()
 
-	nonce, err := client.PendingNonceAt(context.Background(), auth.From)
-	if err != nil {
-		return "", err
-	}
+	for {
+		bc.ProbeRPCs(ctx, cfg)
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return "", err
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
+}
 
-	msg := ethereum.CallMsg{
-		From: auth.From,
-		To:   &common.Address{},
-		Data: data,
-	}
-	gasLimit, err := client.EstimateGas(context.Background(), msg)
-	if err != nil {
-		gasLimit = 300000 // fallback
+// ProbeRPCs 对所有链中到期的节点执行一轮探测，已停用的链不探测
+func (bc *BlockchainClient) ProbeRPCs(ctx context.Context, cfg config.HealthConfig) {
+	var wg sync.WaitGroup
+	for _, c := range bc.all() {
+		if c.disabled.Load() {
+			continue
+		}
+		wg.Add(1)
+		go func(pool *rpcPool) {
+			defer wg.Done()
+			pool.probe(ctx, cfg)
+		}(c.pool)
 	}
+	wg.Wait()
+}
 
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = gasLimit
-	auth.GasPrice = gasPrice
-
-	boundContract := bind.NewBoundContract(bc.GetVaultAddr(chain), parsedABI, client, client, client)
-	tx, err := boundContract.Transact(auth, "unstakeStock", common.HexToAddress(tokenAddr))
+// SignerAddress 返回该链当前的运营账户地址
+func (bc *BlockchainClient) SignerAddress(chain string) (common.Address, error) {
+	c, err := bc.lookup(chain)
 	if err != nil {
-		return "", err
+		return common.Address{}, err
 	}
-
-	return tx.Hash().Hex(), nil
+	return c.tm.currentSigner().Address(), nil
 }
 
-func (bc *BlockchainClient) Liquidate(chain, userAddr, tokenAddr string) (string, error) {
-	client := bc.GetClient(chain)
-
-	contractABI := `{
-  "type": "function",
-  "name": "liquidate",
-  "inputs": [
-    {
-      "name": "user",
-      "type": "address"
-    },
-    {
-      "name": "token",
-      "type": "address"
-    }
-  ],
-  "outputs": [],
-  "stateMutability": "nonpayable"
-}`
-
-	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+// RotateSigner 轮换某条链的运营账户：先阻止新的发送，等待旧账户的在途交易全部上链，再切换到新签名器。
+// 未单独配置 scos_signer 时 SCOS 的 mint 也随之使用新账户，需先把 SCOS 的 owner 转给新账户
+func (bc *BlockchainClient) RotateSigner(ctx context.Context, chain string, next Signer) (common.Address, error) {
+	c, err := bc.lookup(chain)
 	if err != nil {
-		return "", err
+		return common.Address{}, err
 	}
 
-	data, err := parsedABI.Pack("liquidate", common.HexToAddress(userAddr), common.HexToAddress(tokenAddr))
-	if err != nil {
-		return "", err
+	old, err := c.tm.rotate(ctx, next)
+	if errors.Is(err, ErrRotationInProgress) {
+		return old, err
 	}
-
-	auth, err := bc.auth(chain)
 	if err != nil {
-		return "", err
+		return old, fmt.Errorf("drain pending transactions of %s: %w", old.Hex(), err)
 	}
+	return old, nil
+}
 
-	nonce, err := client.PendingNonceAt(context.Background(), auth.From)
-	if err != nil {
-		return "", err
+// ReplaceStuck 对所有链执行一轮卡住交易的加价替换
+func (bc *BlockchainClient) ReplaceStuck(ctx context.Context) []Replacement {
+	var replaced []Replacement
+	for _, c := range bc.all() {
+		if c.disabled.Load() || !c.pool.available() {
+			continue
+		}
+		for _, tm := range c.txManagers() {
+			replaced = append(replaced, tm.replaceStuck(ctx)...)
+		}
 	}
+	return replaced
+}
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+// CancelTx 以 0 金额自转账顶替一笔未上链的运营交易（含 SCOS 的 mint），返回取消交易的哈希
+func (bc *BlockchainClient) CancelTx(ctx context.Context, chain string, txHash string) (string, error) {
+	c, err := bc.lookup(chain)
 	if err != nil {
 		return "", err
 	}
-
-	msg := ethereum.CallMsg{
-		From: auth.From,
-		To:   &common.Address{},
-		Data: data,
-	}
-	gasLimit, err := client.EstimateGas(context.Background(), msg)
-	if err != nil {
-		gasLimit = 300000 // fallback
-	}
-
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = gasLimit
-	auth.GasPrice = gasPrice
-
-	boundContract := bind.NewBoundContract(bc.GetVaultAddr(chain), parsedABI, client, client, client)
-	tx, err := boundContract.Transact(auth, "liquidate", common.HexToAddress(userAddr), common.HexToAddress(tokenAddr))
-	if err != nil {
-		return "", err
+	for _, tm := range c.txManagers() {
+		tx, err := tm.cancel(ctx, common.HexToHash(txHash))
+		if errors.Is(err, ErrNotOperatorTx) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return tx.Hash().Hex(), nil
 	}
-
-	return tx.Hash().Hex(), nil
+	return "", ErrNotOperatorTx
 }
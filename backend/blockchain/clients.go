@@ -0,0 +1,78 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"scos/config"
+	"scos/contracts"
+	"scos/money"
+)
+
+// 各处理器与后台任务只依赖自己用到的链操作，*BlockchainClient 实现全部接口，测试可以只替换其中一部分
+
+// UserTxClient 构造并广播由用户钱包签名的交易
+type UserTxClient interface {
+	TokenDecimals(ctx context.Context, chain, tokenAddr string) (uint8, error)
+	BuildApproveTx(ctx context.Context, chain, from, tokenAddr string, amount *big.Int) (*UnsignedTx, error)
+	SubmitSignedTx(ctx context.Context, chain string, raw []byte) (*SubmittedTx, error)
+}
+
+// StockClient 股票价格与代币余额查询，更新价格时同步到各链金库
+type StockClient interface {
+	Chains() []string
+	SCOSAddress(chain string) (string, error)
+	TokenAddress(chain, symbol string) (string, bool)
+	TokenDecimals(ctx context.Context, chain, tokenAddr string) (uint8, error)
+	TokenBalance(ctx context.Context, chain, tokenAddr, owner string) (*big.Int, error)
+	SetTokenPrice(ctx context.Context, chain, tokenAddr string, price money.Decimal) (string, error)
+}
+
+// ChainReadClient 链状态、仓位、余额与签名 domain 的只读查询
+type ChainReadClient interface {
+	Status() []ChainStatus
+	CheckChain(chain string) error
+	ChainID(chain string) (*big.Int, error)
+	GetVaultAddr(chain string) (common.Address, error)
+	SignerAddress(chain string) (common.Address, error)
+	IsSupportedToken(ctx context.Context, chain, tokenAddr string) (bool, error)
+	GetStakeInfo(ctx context.Context, chain, user, tokenAddr string) (contracts.StockVaultStakeInfo, error)
+	TokenDecimals(ctx context.Context, chain, tokenAddr string) (uint8, error)
+	SCOSDecimals(ctx context.Context, chain string) (uint8, error)
+	TokenBalance(ctx context.Context, chain, tokenAddr, owner string) (*big.Int, error)
+	TokenAllowance(ctx context.Context, chain, tokenAddr, owner, spender string) (*big.Int, error)
+}
+
+// AdminClient 运行时管理链配置、签名器与运营交易
+type AdminClient interface {
+	HasChain(chain string) bool
+	AddChain(name string, info config.ChainInfo, signer, scosSigner Signer) error
+	RemoveChain(name string) error
+	SetChainEnabled(name string, enabled bool) error
+	Signer(name string) (Signer, bool)
+	SignerAddress(chain string) (common.Address, error)
+	RotateSigner(ctx context.Context, chain string, next Signer) (common.Address, error)
+	CancelTx(ctx context.Context, chain string, txHash string) (string, error)
+}
+
+// LiquidationClient 清算监控批量读取仓位并发送清算交易
+type LiquidationClient interface {
+	Available(chain string) bool
+	SignerAddress(chain string) (common.Address, error)
+	GetStakeInfos(ctx context.Context, chain string, keys []StakeKey) ([]Result[contracts.StockVaultStakeInfo], error)
+	Liquidate(ctx context.Context, chain, userAddr, tokenAddr string) (string, error)
+}
+
+// Client API 服务与清算监控用到的全部链操作
+type Client interface {
+	VaultClient
+	UserTxClient
+	StockClient
+	ChainReadClient
+	AdminClient
+	LiquidationClient
+}
+
+var _ Client = (*BlockchainClient)(nil)
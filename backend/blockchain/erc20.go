@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenBalance ERC20 balanceOf，返回最小单位
+func (bc *BlockchainClient) TokenBalance(ctx context.Context, chain, tokenAddr, owner string) (*big.Int, error) {
+	token, err := bc.StockToken(chain, tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := token.BalanceOf(&bind.CallOpts{Context: ctx}, common.HexToAddress(owner))
+	return balance, asRevert(err)
+}
+
+// TokenAllowance ERC20 allowance，返回最小单位
+func (bc *BlockchainClient) TokenAllowance(ctx context.Context, chain, tokenAddr, owner, spender string) (*big.Int, error) {
+	token, err := bc.StockToken(chain, tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	allowance, err := token.Allowance(&bind.CallOpts{Context: ctx}, common.HexToAddress(owner), common.HexToAddress(spender))
+	return allowance, asRevert(err)
+}
+
+// TokenDecimals ERC20 decimals
+func (bc *BlockchainClient) TokenDecimals(ctx context.Context, chain, tokenAddr string) (uint8, error) {
+	key := chain + "/" + strings.ToLower(tokenAddr)
+	if v, ok := bc.decimals.Load(key); ok {
+		return v.(uint8), nil
+	}
+
+	token, err := bc.StockToken(chain, tokenAddr)
+	if err != nil {
+		return 0, err
+	}
+	decimals, err := token.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, asRevert(err)
+	}
+	bc.decimals.Store(key, decimals)
+	return decimals, nil
+}
+
+// SCOSAddress 该链配置的 SCOS 合约地址，未配置时返回空
+func (bc *BlockchainClient) SCOSAddress(chain string) (string, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return "", err
+	}
+	return c.info.SCOSAddress, nil
+}
+
+// defaultSCOSDecimals 未配置 scos_address 时 SCOS 金额的精度，与 SCOS 合约一致
+const defaultSCOSDecimals = 6
+
+// SCOSDecimals 该链 SCOS 合约的 decimals，未配置 scos_address 时为 defaultSCOSDecimals
+func (bc *BlockchainClient) SCOSDecimals(ctx context.Context, chain string) (uint8, error) {
+	scos, err := bc.SCOSAddress(chain)
+	if err != nil {
+		return 0, err
+	}
+	if scos == "" {
+		return defaultSCOSDecimals, nil
+	}
+	return bc.TokenDecimals(ctx, chain, scos)
+}
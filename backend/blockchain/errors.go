@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	ErrUnknownChain       = errors.New("unknown chain")
+	ErrChainUnavailable   = errors.New("chain unavailable")
+	ErrChainExists        = errors.New("chain already registered")
+	ErrTxNotPending       = errors.New("transaction is not pending")
+	ErrNotOperatorTx      = errors.New("transaction was not sent by the chain operator")
+	ErrFeeCeiling         = errors.New("replacement fee would exceed the configured ceiling")
+	ErrUnsupportedTx      = errors.New("transaction is not a supported vault or token call")
+	ErrTxRejected         = errors.New("transaction rejected by node")
+	ErrSCOSNotConfigured  = errors.New("scos_address not configured")
+	ErrRotationInProgress = errors.New("signer rotation already in progress")
+)
+
+// RevertError 合约调用会 revert，Reason 为合约 require 给出的原因（如 "No active stake"）
+type RevertError struct {
+	Reason string
+	Data   []byte
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
+// asRevert 识别节点返回的 revert 错误并解码原因，其他错误原样返回
+func asRevert(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(s); decodeErr == nil {
+				reason, _ := abi.UnpackRevert(data)
+				return &RevertError{Reason: reason, Data: data}
+			}
+		}
+	}
+
+	// 部分节点不返回 data，只在错误消息里带上原因
+	msg := err.Error()
+	if i := strings.Index(msg, "execution reverted"); i >= 0 {
+		reason := strings.TrimPrefix(msg[i+len("execution reverted"):], ":")
+		return &RevertError{Reason: strings.TrimSpace(reason)}
+	}
+	return err
+}
@@ -0,0 +1,608 @@
+// Package fake 提供 blockchain.VaultClient 的内存实现，按 StockVault 合约的语义维护质押状态，
+// 用于不连接节点的 handler 测试
+package fake
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"scos/blockchain"
+	"scos/contracts"
+)
+
+// 模拟交易的 gas 与单价
+const (
+	SimulatedGas = 120000
+	GasPrice     = 1_000_000_000
+)
+
+// DefaultDecimals 未通过 SetTokenDecimals 设置时代币与 SCOS 的精度
+const DefaultDecimals = 6
+
+// DefaultCollateralRatio 未通过 SetCollateralRatio 设置时金库的抵押率（基点）
+const DefaultCollateralRatio = 15000
+
+var vaultABI, _ = contracts.StockVaultMetaData.GetAbi()
+
+// Tx 已“上链”的金库调用
+type Tx struct {
+	Hash       string
+	Chain      string
+	Method     string // stakeStock / unstakeStock / withdrawStock / repayDebt / liquidate
+	From       common.Address
+	User       common.Address // liquidate 的目标用户，其他调用与 From 相同
+	Token      common.Address
+	Amount     *big.Int
+	SCOSAmount *big.Int
+}
+
+type chain struct {
+	id        *big.Int
+	vault     common.Address
+	disabled  bool
+	supported map[common.Address]bool
+	decimals  map[common.Address]uint8
+	scos      uint8
+	stakes    map[common.Address]map[common.Address]contracts.StockVaultStakeInfo // user => token => stake
+
+	prices map[common.Address]*big.Int // 与合约 prices 相同，放大 1e18
+	ratio  int64                       // 抵押率，基点
+
+	balances   map[common.Address]*big.Int // SCOS 余额
+	allowances map[common.Address]*big.Int // 授权金库转走的 SCOS
+}
+
+// Vault 内存中的多链金库与 SCOS。运营交易以 operator 为 msg.sender，operator 同时是两个合约的 owner；
+// 用户交易先由 BuildXxxTx 构造，再通过 Execute 以交易的 From 为 msg.sender 执行
+type Vault struct {
+	mu       sync.Mutex
+	operator common.Address
+	chains   map[string]*chain
+	txs      []Tx
+	failNext error
+	now      func() time.Time
+}
+
+var _ blockchain.VaultClient = (*Vault)(nil)
+
+func NewVault(operator common.Address) *Vault {
+	return &Vault{operator: operator, chains: make(map[string]*chain), now: time.Now}
+}
+
+// AddChain 注册一条链及其金库地址
+func (v *Vault) AddChain(name string, chainID int64, vault common.Address) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.chains[name] = &chain{
+		id:         big.NewInt(chainID),
+		vault:      vault,
+		supported:  make(map[common.Address]bool),
+		decimals:   make(map[common.Address]uint8),
+		scos:       DefaultDecimals,
+		prices:     make(map[common.Address]*big.Int),
+		ratio:      DefaultCollateralRatio,
+		balances:   make(map[common.Address]*big.Int),
+		allowances: make(map[common.Address]*big.Int),
+		stakes:     make(map[common.Address]map[common.Address]contracts.StockVaultStakeInfo),
+	}
+}
+
+// SetChainEnabled 停用后该链的操作返回 ErrChainUnavailable
+func (v *Vault) SetChainEnabled(name string, enabled bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.disabled = !enabled
+	}
+}
+
+// AddSupportedToken 对应合约的 addSupportedToken
+func (v *Vault) AddSupportedToken(name string, token common.Address) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.supported[token] = true
+	}
+}
+
+// SetTokenDecimals 设置股票代币的精度
+func (v *Vault) SetTokenDecimals(name string, token common.Address, decimals uint8) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.decimals[token] = decimals
+	}
+}
+
+// SetPrice 对应合约的 setPrice，price 为每个代币最小单位对应的 SCOS 最小单位，放大 1e18
+func (v *Vault) SetPrice(name string, token common.Address, price *big.Int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.prices[token] = new(big.Int).Set(price)
+	}
+}
+
+// SetCollateralRatio 设置金库的抵押率（基点）
+func (v *Vault) SetCollateralRatio(name string, ratio int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.ratio = ratio
+	}
+}
+
+// SetStake 直接写入链上仓位，用于构造测试前置状态
+func (v *Vault) SetStake(name string, user, token common.Address, info contracts.StockVaultStakeInfo) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.setStake(user, token, info)
+	}
+}
+
+// SetSCOSDecimals 设置 SCOS 的精度
+func (v *Vault) SetSCOSDecimals(name string, decimals uint8) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.scos = decimals
+	}
+}
+
+// SetSCOSBalance 设置 owner 持有的 SCOS（最小单位）
+func (v *Vault) SetSCOSBalance(name string, owner common.Address, amount *big.Int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.balances[owner] = new(big.Int).Set(amount)
+	}
+}
+
+// SetSCOSAllowance 设置 owner 授权金库转走的 SCOS（最小单位）
+func (v *Vault) SetSCOSAllowance(name string, owner common.Address, amount *big.Int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c := v.chains[name]; c != nil {
+		c.allowances[owner] = new(big.Int).Set(amount)
+	}
+}
+
+// FailNext 下一次构造用户交易或清算返回 err，不修改状态
+func (v *Vault) FailNext(err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.failNext = err
+}
+
+// Txs 按发送顺序返回所有成功的调用
+func (v *Vault) Txs() []Tx {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]Tx(nil), v.txs...)
+}
+
+// Operator 发送交易的运营账户
+func (v *Vault) Operator() common.Address {
+	return v.operator
+}
+
+func (v *Vault) HasChain(name string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.chains[name] != nil
+}
+
+func (v *Vault) CheckChain(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, err := v.lookup(name)
+	return err
+}
+
+func (v *Vault) ChainID(name string) (*big.Int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(c.id), nil
+}
+
+func (v *Vault) GetVaultAddr(name string) (common.Address, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.lookup(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return c.vault, nil
+}
+
+func (v *Vault) IsSupportedToken(ctx context.Context, name, tokenAddr string) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, false)
+	if err != nil {
+		return false, err
+	}
+	return c.supported[common.HexToAddress(tokenAddr)], nil
+}
+
+func (v *Vault) TokenDecimals(ctx context.Context, name, tokenAddr string) (uint8, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, false)
+	if err != nil {
+		return 0, err
+	}
+	if d, ok := c.decimals[common.HexToAddress(tokenAddr)]; ok {
+		return d, nil
+	}
+	return DefaultDecimals, nil
+}
+
+func (v *Vault) SCOSDecimals(ctx context.Context, name string) (uint8, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, false)
+	if err != nil {
+		return 0, err
+	}
+	return c.scos, nil
+}
+
+func (v *Vault) GetStakeInfo(ctx context.Context, name, user, tokenAddr string) (contracts.StockVaultStakeInfo, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, false)
+	if err != nil {
+		return contracts.StockVaultStakeInfo{}, err
+	}
+	return c.stake(common.HexToAddress(user), common.HexToAddress(tokenAddr)), nil
+}
+
+func (v *Vault) BuildStakeTx(ctx context.Context, name, from, tokenAddr string, amount, scosAmount *big.Int) (*blockchain.UnsignedTx, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, true)
+	if err != nil {
+		return nil, err
+	}
+	user, token := common.HexToAddress(from), common.HexToAddress(tokenAddr)
+	if err := c.checkStake(user, token, amount, scosAmount); err != nil {
+		return nil, err
+	}
+	return c.unsigned(user, "stakeStock", token, amount, scosAmount)
+}
+
+func (v *Vault) BuildUnstakeTx(ctx context.Context, name, from, tokenAddr string) (*blockchain.UnsignedTx, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, true)
+	if err != nil {
+		return nil, err
+	}
+	user, token := common.HexToAddress(from), common.HexToAddress(tokenAddr)
+	if err := c.checkUnstake(user, token); err != nil {
+		return nil, err
+	}
+	return c.unsigned(user, "unstakeStock", token)
+}
+
+func (v *Vault) BuildWithdrawTx(ctx context.Context, name, from, tokenAddr string, amount *big.Int) (*blockchain.UnsignedTx, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, true)
+	if err != nil {
+		return nil, err
+	}
+	user, token := common.HexToAddress(from), common.HexToAddress(tokenAddr)
+	if _, err := c.checkWithdraw(user, token, amount); err != nil {
+		return nil, err
+	}
+	return c.unsigned(user, "withdrawStock", token, amount)
+}
+
+func (v *Vault) BuildRepayTx(ctx context.Context, name, from, tokenAddr string, scosAmount *big.Int) (*blockchain.UnsignedTx, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, true)
+	if err != nil {
+		return nil, err
+	}
+	user, token := common.HexToAddress(from), common.HexToAddress(tokenAddr)
+	if _, err := c.checkRepay(user, token, scosAmount); err != nil {
+		return nil, err
+	}
+	return c.unsigned(user, "repayDebt", token, scosAmount)
+}
+
+// Execute 以 tx.From 为 msg.sender 执行 BuildXxxTx 构造的金库调用，相当于用户签名后交易上链
+func (v *Vault) Execute(name string, tx *blockchain.UnsignedTx) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.lookup(name)
+	if err != nil {
+		return "", err
+	}
+	if tx.To != c.vault || len(tx.Data) < 4 {
+		return "", fmt.Errorf("fake: transaction is not a vault call")
+	}
+	method, err := vaultABI.MethodById(tx.Data[:4])
+	if err != nil {
+		return "", err
+	}
+	args, err := method.Inputs.Unpack(tx.Data[4:])
+	if err != nil {
+		return "", err
+	}
+	user, token := tx.From, args[0].(common.Address)
+
+	switch method.Name {
+	case "stakeStock":
+		amount, scosAmount := args[1].(*big.Int), args[2].(*big.Int)
+		if err := c.checkStake(user, token, amount, scosAmount); err != nil {
+			return "", err
+		}
+		c.setStake(user, token, contracts.StockVaultStakeInfo{
+			Amount:       new(big.Int).Set(amount),
+			BorrowedSCOS: new(big.Int).Set(scosAmount),
+			Timestamp:    big.NewInt(v.now().Unix()),
+			Active:       true,
+		})
+		return v.record(Tx{Chain: name, Method: method.Name, From: user, User: user, Token: token, Amount: amount, SCOSAmount: scosAmount}), nil
+	case "unstakeStock":
+		if err := c.checkUnstake(user, token); err != nil {
+			return "", err
+		}
+		debt := c.stake(user, token).BorrowedSCOS
+		c.burnSCOS(user, debt)
+		amount, err := c.close(user, token)
+		if err != nil {
+			return "", err
+		}
+		return v.record(Tx{Chain: name, Method: method.Name, From: user, User: user, Token: token, Amount: amount, SCOSAmount: debt}), nil
+	case "withdrawStock":
+		amount := args[1].(*big.Int)
+		s, err := c.checkWithdraw(user, token, amount)
+		if err != nil {
+			return "", err
+		}
+		s.Amount = new(big.Int).Sub(s.Amount, amount)
+		c.setStake(user, token, s)
+		return v.record(Tx{Chain: name, Method: method.Name, From: user, User: user, Token: token, Amount: amount}), nil
+	case "repayDebt":
+		scosAmount := args[1].(*big.Int)
+		s, err := c.checkRepay(user, token, scosAmount)
+		if err != nil {
+			return "", err
+		}
+		c.burnSCOS(user, scosAmount)
+		s.BorrowedSCOS = new(big.Int).Sub(s.BorrowedSCOS, scosAmount)
+		c.setStake(user, token, s)
+		return v.record(Tx{Chain: name, Method: method.Name, From: user, User: user, Token: token, SCOSAmount: scosAmount}), nil
+	}
+	return "", fmt.Errorf("fake: unsupported vault method %s", method.Name)
+}
+
+func (v *Vault) Liquidate(ctx context.Context, name, userAddr, tokenAddr string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, true)
+	if err != nil {
+		return "", err
+	}
+	user, token := common.HexToAddress(userAddr), common.HexToAddress(tokenAddr)
+	amount, err := c.close(user, token)
+	if err != nil {
+		return "", err
+	}
+	return v.record(Tx{Chain: name, Method: "liquidate", User: user, Token: token, Amount: amount}), nil
+}
+
+func (v *Vault) SCOSBalance(ctx context.Context, name, owner string) (*big.Int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.balance(common.HexToAddress(owner)), nil
+}
+
+func (v *Vault) SCOSAllowance(ctx context.Context, name, owner string) (*big.Int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, err := v.begin(ctx, name, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.allowance(common.HexToAddress(owner)), nil
+}
+
+func (v *Vault) lookup(name string) (*chain, error) {
+	c := v.chains[name]
+	if c == nil {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrUnknownChain, name)
+	}
+	if c.disabled {
+		return nil, fmt.Errorf("%w: %s is disabled", blockchain.ErrChainUnavailable, name)
+	}
+	return c, nil
+}
+
+// begin 依次检查 ctx、链状态与 FailNext 注入的错误，调用方需持有锁
+func (v *Vault) begin(ctx context.Context, name string, write bool) (*chain, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c, err := v.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if write && v.failNext != nil {
+		err, v.failNext = v.failNext, nil
+		return nil, err
+	}
+	return c, nil
+}
+
+func (v *Vault) record(tx Tx) string {
+	if tx.From == (common.Address{}) {
+		tx.From = v.operator
+	}
+	tx.Hash = crypto.Keccak256Hash([]byte(fmt.Sprintf("%s/%d", strings.ToLower(tx.Chain), len(v.txs)))).Hex()
+	v.txs = append(v.txs, tx)
+	return tx.Hash
+}
+
+// unsigned 与 BlockchainClient.BuildXxxTx 相同的字段，费用按 legacy 交易给出
+func (c *chain) unsigned(from common.Address, method string, args ...interface{}) (*blockchain.UnsignedTx, error) {
+	data, err := vaultABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &blockchain.UnsignedTx{
+		ChainID:  new(big.Int).Set(c.id),
+		From:     from,
+		To:       c.vault,
+		Data:     data,
+		Value:    new(big.Int),
+		Gas:      SimulatedGas,
+		GasPrice: big.NewInt(GasPrice),
+	}, nil
+}
+
+// checkStake 与 stakeStock 的 require 相同
+func (c *chain) checkStake(user, token common.Address, amount, scosAmount *big.Int) error {
+	switch {
+	case !c.supported[token]:
+		return revert("Token not supported")
+	case amount == nil || amount.Sign() <= 0:
+		return revert("Amount must be greater than 0")
+	case c.stake(user, token).Active:
+		return revert("Already staked")
+	case scosAmount.Cmp(c.maxBorrow(token, amount)) > 0:
+		return revert("Exceeds collateral ratio")
+	}
+	return nil
+}
+
+// checkUnstake 与 unstakeStock 相同：仓位活跃，且用户已授权并持有足够的 SCOS 偿还剩余债务
+func (c *chain) checkUnstake(user, token common.Address) error {
+	s := c.stake(user, token)
+	switch {
+	case !s.Active:
+		return revert("No active stake")
+	case c.allowance(user).Cmp(s.BorrowedSCOS) < 0:
+		return revert("ERC20: insufficient allowance")
+	case c.balance(user).Cmp(s.BorrowedSCOS) < 0:
+		return revert("ERC20: burn amount exceeds balance")
+	}
+	return nil
+}
+
+// maxBorrow 与合约相同：amount x price x 10000 / (1e18 x collateralRatio)
+func (c *chain) maxBorrow(token common.Address, amount *big.Int) *big.Int {
+	price := c.prices[token]
+	if price == nil {
+		return new(big.Int)
+	}
+	out := new(big.Int).Mul(amount, price)
+	out.Mul(out, big.NewInt(10000))
+	return out.Div(out, new(big.Int).Mul(big.NewInt(1e18), big.NewInt(c.ratio)))
+}
+
+func (c *chain) allowance(owner common.Address) *big.Int {
+	if a := c.allowances[owner]; a != nil {
+		return a
+	}
+	return new(big.Int)
+}
+
+// burnSCOS 金库以 burnFrom 销毁 owner 偿还的 SCOS，调用方已检查授权与余额
+func (c *chain) burnSCOS(owner common.Address, amount *big.Int) {
+	c.allowances[owner] = new(big.Int).Sub(c.allowance(owner), amount)
+	c.balances[owner] = new(big.Int).Sub(c.balance(owner), amount)
+}
+
+func (c *chain) balance(owner common.Address) *big.Int {
+	if b := c.balances[owner]; b != nil {
+		return b
+	}
+	return new(big.Int)
+}
+
+// checkWithdraw 与 withdrawStock 的 require 相同，返回当前仓位
+func (c *chain) checkWithdraw(user, token common.Address, amount *big.Int) (contracts.StockVaultStakeInfo, error) {
+	s := c.stake(user, token)
+	switch {
+	case !s.Active:
+		return s, revert("No active stake")
+	case amount.Sign() <= 0:
+		return s, revert("Amount must be greater than 0")
+	case amount.Cmp(s.Amount) >= 0:
+		return s, revert("Use unstakeStock to withdraw everything")
+	case s.BorrowedSCOS.Cmp(c.maxBorrow(token, new(big.Int).Sub(s.Amount, amount))) > 0:
+		return s, revert("Exceeds collateral ratio")
+	}
+	return s, nil
+}
+
+// checkRepay 与 repayDebt 相同：仓位活跃、数量不超过债务，且用户已授权并持有足够的 SCOS，返回当前仓位
+func (c *chain) checkRepay(user, token common.Address, scosAmount *big.Int) (contracts.StockVaultStakeInfo, error) {
+	s := c.stake(user, token)
+	switch {
+	case !s.Active:
+		return s, revert("No active stake")
+	case scosAmount.Sign() <= 0:
+		return s, revert("Amount must be greater than 0")
+	case scosAmount.Cmp(s.BorrowedSCOS) > 0:
+		return s, revert("Repay exceeds debt")
+	case c.allowance(user).Cmp(scosAmount) < 0:
+		return s, revert("ERC20: insufficient allowance")
+	case c.balance(user).Cmp(scosAmount) < 0:
+		return s, revert("ERC20: burn amount exceeds balance")
+	}
+	return s, nil
+}
+
+// stake 未质押过时与合约一样返回零值
+func (c *chain) stake(user, token common.Address) contracts.StockVaultStakeInfo {
+	if s, ok := c.stakes[user][token]; ok {
+		return s
+	}
+	return contracts.StockVaultStakeInfo{Amount: new(big.Int), BorrowedSCOS: new(big.Int), Timestamp: new(big.Int)}
+}
+
+func (c *chain) setStake(user, token common.Address, s contracts.StockVaultStakeInfo) {
+	if c.stakes[user] == nil {
+		c.stakes[user] = make(map[common.Address]contracts.StockVaultStakeInfo)
+	}
+	c.stakes[user][token] = s
+}
+
+// close unstakeStock 与 liquidate 共用：清空仓位并返回原质押数量
+func (c *chain) close(user, token common.Address) (*big.Int, error) {
+	s := c.stake(user, token)
+	if !s.Active {
+		return nil, revert("No active stake")
+	}
+	c.setStake(user, token, contracts.StockVaultStakeInfo{
+		Amount: new(big.Int), BorrowedSCOS: new(big.Int), Timestamp: s.Timestamp,
+	})
+	return s.Amount, nil
+}
+
+func revert(reason string) error {
+	return &blockchain.RevertError{Reason: reason}
+}
@@ -0,0 +1,99 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"scos/config"
+)
+
+var errNoLondon = errors.New("chain does not support EIP-1559 dynamic fees")
+
+// feeQuote 一笔交易的费用参数，dynamic 为 true 时使用 tipCap/feeCap，否则使用 gasPrice
+type feeQuote struct {
+	dynamic  bool
+	gasPrice *big.Int
+	tipCap   *big.Int
+	feeCap   *big.Int
+}
+
+// suggestFees 按链配置计算费用。auto 模式下最新区块带 baseFee 即视为已启用 London
+func suggestFees(ctx context.Context, client ChainClient, cfg config.FeeConfig) (*feeQuote, error) {
+	var baseFee *big.Int
+	if cfg.Mode != "legacy" {
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		baseFee = head.BaseFee
+		if baseFee == nil && cfg.Mode == "dynamic" {
+			return nil, errNoLondon
+		}
+	}
+
+	if baseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		gasPrice = capWei(scaleWei(gasPrice, cfg.Multiplier), cfg.MaxFeeGwei)
+		return &feeQuote{gasPrice: gasPrice}, nil
+	}
+
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tip = capWei(scaleWei(tip, cfg.Multiplier), cfg.MaxTipGwei)
+
+	// 预留两倍 baseFee，连续几个满块后交易仍可打包
+	feeCap := scaleWei(new(big.Int).Mul(baseFee, big.NewInt(2)), cfg.Multiplier)
+	feeCap = capWei(feeCap.Add(feeCap, tip), cfg.MaxFeeGwei)
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	return &feeQuote{dynamic: true, tipCap: tip, feeCap: feeCap}, nil
+}
+
+// bumpWei 按百分比上浮，向上取整
+func bumpWei(v *big.Int, percent uint64) *big.Int {
+	out := new(big.Int).Mul(v, new(big.Int).SetUint64(100+percent))
+	out.Add(out, big.NewInt(99))
+	return out.Div(out, big.NewInt(100))
+}
+
+func maxWei(a, b *big.Int) *big.Int {
+	if b == nil || (a != nil && a.Cmp(b) >= 0) {
+		return a
+	}
+	return b
+}
+
+func scaleWei(v *big.Int, multiplier float64) *big.Int {
+	if multiplier == 0 || multiplier == 1 {
+		return v
+	}
+	f := new(big.Float).Mul(new(big.Float).SetInt(v), big.NewFloat(multiplier))
+	out, _ := f.Int(nil)
+	return out
+}
+
+func capWei(v *big.Int, capGwei float64) *big.Int {
+	if capGwei == 0 {
+		return v
+	}
+	limit := gweiToWei(capGwei)
+	if v.Cmp(limit) > 0 {
+		return limit
+	}
+	return v
+}
+
+func gweiToWei(gwei float64) *big.Int {
+	f := new(big.Float).Mul(big.NewFloat(gwei), big.NewFloat(params.GWei))
+	out, _ := f.Int(nil)
+	return out
+}
@@ -0,0 +1,650 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+
+	"scos/config"
+	"scos/contracts"
+	"scos/models"
+	"scos/money"
+)
+
+// Indexer 从检查点开始跟踪各链 StockVault 的事件日志，把链上状态写回 StakeRecord；
+// 用户自己的质押上链并达到确认数后，按事件中的借出数量给质押人铸造 SCOS
+type Indexer struct {
+	db     *gorm.DB
+	bc     *BlockchainClient
+	cfg    config.IndexerConfig
+	events *contracts.StockVaultFilterer // 只用于解码日志，不绑定地址
+}
+
+func NewIndexer(db *gorm.DB, bc *BlockchainClient, cfg config.IndexerConfig) *Indexer {
+	events, err := contracts.NewStockVaultFilterer(common.Address{}, nil)
+	if err != nil {
+		log.Panicf("bind vault events: %v", err)
+	}
+	return &Indexer{db: db, bc: bc, cfg: cfg, events: events}
+}
+
+func (ix *Indexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(ix.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		ix.SyncAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll 对每条链索引到最新区块
+func (ix *Indexer) SyncAll(ctx context.Context) {
+	for _, chain := range ix.bc.Chains() {
+		if !ix.bc.Available(chain) {
+			continue
+		}
+		if err := ix.Sync(ctx, chain); err != nil {
+			log.Printf("indexer %s: %v", chain, err)
+		}
+	}
+}
+
+// Sync 索引单条链，从检查点下一个区块开始分批拉取日志，只处理达到确认数的区块。
+// 发现链重组时先回滚到共同祖先区块，再重新索引
+func (ix *Indexer) Sync(ctx context.Context, chain string) error {
+	c, err := ix.bc.lookup(chain)
+	if err != nil {
+		return err
+	}
+	client := c.client
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ix.checkReorg(ctx, client, chain, head); err != nil {
+		return err
+	}
+
+	depth := c.confirmations()
+	if head+1 < depth {
+		return nil
+	}
+	safe := head + 1 - depth
+
+	from, err := ix.nextBlock(chain, c.info.StartBlock)
+	if err != nil {
+		return err
+	}
+	for from <= safe {
+		to := min(from+ix.cfg.BatchSize-1, safe)
+		logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{c.VaultAddress},
+			Topics: [][]common.Hash{{
+				vaultABI.Events["StockStaked"].ID,
+				vaultABI.Events["StockUnstaked"].ID,
+				vaultABI.Events["StockWithdrawn"].ID,
+				vaultABI.Events["DebtRepaid"].ID,
+				vaultABI.Events["Liquidated"].ID,
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		headers, err := processedHeaders(ctx, client, to, logs)
+		if err != nil {
+			return err
+		}
+
+		err = ix.db.Transaction(func(db *gorm.DB) error {
+			for _, l := range logs {
+				if err := ix.apply(ctx, db, chain, c.VaultAddress, l); err != nil {
+					return fmt.Errorf("log %s#%d: %w", l.TxHash.Hex(), l.Index, err)
+				}
+			}
+			for _, h := range headers {
+				err := db.Save(&models.IndexedBlock{
+					Chain:      chain,
+					Number:     h.Number.Uint64(),
+					Hash:       h.Hash().Hex(),
+					ParentHash: h.ParentHash.Hex(),
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return db.Save(&models.IndexCheckpoint{Chain: chain, Block: to, UpdatedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return err
+		}
+		from = to + 1
+	}
+	return ix.sendMints(ctx, chain)
+}
+
+// processedHeaders 取批次末尾区块与含事件区块的区块头，事件区块按日志里的哈希取，与日志保持一致
+func processedHeaders(ctx context.Context, client ChainClient, to uint64, logs []types.Log) ([]*types.Header, error) {
+	last, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return nil, err
+	}
+	headers := []*types.Header{last}
+	seen := map[common.Hash]bool{last.Hash(): true}
+	for _, l := range logs {
+		if seen[l.BlockHash] {
+			continue
+		}
+		seen[l.BlockHash] = true
+		h, err := client.HeaderByHash(ctx, l.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, h)
+	}
+	return headers, nil
+}
+
+// checkReorg 最后处理的区块之后的下一个区块，其 parentHash 必须等于记录的哈希；
+// 链头尚未超过该区块时直接比较该高度的区块哈希
+func (ix *Indexer) checkReorg(ctx context.Context, client ChainClient, chain string, head uint64) error {
+	var last models.IndexedBlock
+	err := ix.db.Where("chain = ?", chain).Order("number desc").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var canonical string
+	if last.Number < head {
+		next, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(last.Number+1))
+		if err != nil {
+			return err
+		}
+		canonical = next.ParentHash.Hex()
+	} else {
+		h, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(last.Number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return err
+		}
+		if h != nil {
+			canonical = h.Hash().Hex()
+		}
+	}
+	if canonical == last.Hash {
+		return nil
+	}
+
+	ancestor, found, err := ix.commonAncestor(ctx, client, chain)
+	if err != nil {
+		return err
+	}
+	log.Printf("indexer %s: reorg detected at block %d, rolling back to %d", chain, last.Number, ancestor)
+	return ix.rollback(chain, ancestor, found)
+}
+
+// commonAncestor 从高到低查找哈希仍在主链上的已处理区块
+func (ix *Indexer) commonAncestor(ctx context.Context, client ChainClient, chain string) (uint64, bool, error) {
+	var blocks []models.IndexedBlock
+	if err := ix.db.Where("chain = ?", chain).Order("number desc").Find(&blocks).Error; err != nil {
+		return 0, false, err
+	}
+	for _, b := range blocks {
+		h, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(b.Number))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if h.Hash().Hex() == b.Hash {
+			return b.Number, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// rollback 撤销祖先区块之后的所有索引结果：按倒序恢复 StakeRecord，删除补录的交易，
+// 本服务发出且已确认的交易重新置为 pending 交由回执监听器复核。found 为 false 时全部重建
+func (ix *Indexer) rollback(chain string, ancestor uint64, found bool) error {
+	above := func(db *gorm.DB) *gorm.DB {
+		if found {
+			return db.Where("chain = ? AND block_number > ?", chain, ancestor)
+		}
+		return db.Where("chain = ?", chain)
+	}
+
+	return ix.db.Transaction(func(db *gorm.DB) error {
+		var changes []models.StakeChange
+		if err := above(db).Order("id desc").Find(&changes).Error; err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if err := restoreStake(db, c); err != nil {
+				return err
+			}
+		}
+		if err := above(db).Delete(&models.StakeChange{}).Error; err != nil {
+			return err
+		}
+		if err := above(db).Delete(&models.VaultEvent{}).Error; err != nil {
+			return err
+		}
+		// 尚未发送的 mint 随质押一起撤销，已发送的按下面的规则交由回执监听器复核
+		if err := above(db).Where("type = ? AND status = ?", "mint", "queued").Delete(&models.Transaction{}).Error; err != nil {
+			return err
+		}
+		if err := above(db).Where("source = ?", "indexer").Delete(&models.Transaction{}).Error; err != nil {
+			return err
+		}
+		err := above(db.Model(&models.Transaction{})).
+			Where("source <> ? AND status IN ?", "indexer", []string{"confirmed", "failed"}).
+			Updates(map[string]interface{}{
+				"status":              "pending",
+				"block_number":        0,
+				"gas_used":            0,
+				"effective_gas_price": "",
+				"revert_reason":       "",
+				"updated_at":          time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		blocks := db.Where("chain = ?", chain)
+		if found {
+			blocks = blocks.Where("number > ?", ancestor)
+		}
+		if err := blocks.Delete(&models.IndexedBlock{}).Error; err != nil {
+			return err
+		}
+		if !found {
+			return db.Where("chain = ?", chain).Delete(&models.IndexCheckpoint{}).Error
+		}
+		return db.Save(&models.IndexCheckpoint{Chain: chain, Block: ancestor, UpdatedAt: time.Now()}).Error
+	})
+}
+
+func restoreStake(db *gorm.DB, c models.StakeChange) error {
+	if c.Before == "" {
+		return db.Delete(&models.StakeRecord{}, c.StakeID).Error
+	}
+	var stake models.StakeRecord
+	if err := json.Unmarshal([]byte(c.Before), &stake); err != nil {
+		return err
+	}
+	return db.Save(&stake).Error
+}
+
+func (ix *Indexer) nextBlock(chain string, startBlock uint64) (uint64, error) {
+	var cp models.IndexCheckpoint
+	err := ix.db.Where("chain = ?", chain).First(&cp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return startBlock, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cp.Block + 1, nil
+}
+
+// apply 解码一条日志，写入 VaultEvent 并更新对应的 StakeRecord 与 Transaction
+func (ix *Indexer) apply(ctx context.Context, db *gorm.DB, chain string, vault common.Address, l types.Log) error {
+	ev := models.VaultEvent{
+		Chain:       chain,
+		TxHash:      l.TxHash.Hex(),
+		LogIndex:    l.Index,
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash.Hex(),
+		CreatedAt:   time.Now(),
+	}
+	if err := ix.decode(l, &ev); err != nil {
+		return err
+	}
+
+	// 同一条日志只处理一次
+	var count int64
+	err := db.Model(&models.VaultEvent{}).
+		Where("chain = ? AND tx_hash = ? AND log_index = ?", ev.Chain, ev.TxHash, ev.LogIndex).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+	if err := db.Create(&ev).Error; err != nil {
+		return err
+	}
+
+	// 金额按链上精度换算，读取失败时整批重试
+	var units stakeUnits
+	switch ev.Event {
+	case "StockStaked", "StockWithdrawn", "DebtRepaid":
+		if units.token, err = ix.bc.TokenDecimals(ctx, chain, ev.TokenAddress); err != nil {
+			return fmt.Errorf("token decimals: %w", err)
+		}
+		if units.scos, err = ix.bc.SCOSDecimals(ctx, chain); err != nil {
+			return fmt.Errorf("SCOS decimals: %w", err)
+		}
+	}
+	symbol := ""
+	if ev.Event == "StockStaked" {
+		symbol = ix.bc.TokenSymbol(chain, ev.TokenAddress)
+	}
+	if err := applyToStake(db, vault, ev, units, symbol); err != nil {
+		return err
+	}
+	if err := recordIndexedTx(db, ev); err != nil {
+		return err
+	}
+	if ev.Event == "StockStaked" {
+		return queueMint(db, ev)
+	}
+	return nil
+}
+
+// decode 用合约绑定解析日志，填入事件名、用户、代币与金额
+func (ix *Indexer) decode(l types.Log, ev *models.VaultEvent) error {
+	if len(l.Topics) == 0 {
+		return errors.New("log has no topics")
+	}
+	switch l.Topics[0] {
+	case vaultABI.Events["StockStaked"].ID:
+		e, err := ix.events.ParseStockStaked(l)
+		if err != nil {
+			return err
+		}
+		ev.Event, ev.UserAddress, ev.TokenAddress = "StockStaked", e.User.Hex(), e.Token.Hex()
+		ev.Amount, ev.BorrowedSCOS = e.Amount.String(), e.BorrowedSCOS.String()
+	case vaultABI.Events["StockUnstaked"].ID:
+		e, err := ix.events.ParseStockUnstaked(l)
+		if err != nil {
+			return err
+		}
+		ev.Event, ev.UserAddress, ev.TokenAddress = "StockUnstaked", e.User.Hex(), e.Token.Hex()
+		ev.Amount = e.Amount.String()
+	case vaultABI.Events["StockWithdrawn"].ID:
+		e, err := ix.events.ParseStockWithdrawn(l)
+		if err != nil {
+			return err
+		}
+		ev.Event, ev.UserAddress, ev.TokenAddress = "StockWithdrawn", e.User.Hex(), e.Token.Hex()
+		ev.Amount, ev.Remaining = e.Amount.String(), e.Remaining.String()
+	case vaultABI.Events["DebtRepaid"].ID:
+		e, err := ix.events.ParseDebtRepaid(l)
+		if err != nil {
+			return err
+		}
+		ev.Event, ev.UserAddress, ev.TokenAddress = "DebtRepaid", e.User.Hex(), e.Token.Hex()
+		ev.BorrowedSCOS, ev.Remaining = e.ScosAmount.String(), e.RemainingDebt.String()
+	case vaultABI.Events["Liquidated"].ID:
+		e, err := ix.events.ParseLiquidated(l)
+		if err != nil {
+			return err
+		}
+		ev.Event, ev.UserAddress, ev.TokenAddress = "Liquidated", e.User.Hex(), e.Token.Hex()
+		ev.Amount = e.Amount.String()
+	default:
+		return fmt.Errorf("unknown event %s", l.Topics[0].Hex())
+	}
+	return nil
+}
+
+// stakeUnits 事件中质押数量与 SCOS 金额的精度
+type stakeUnits struct {
+	token uint8
+	scos  uint8
+}
+
+// applyToStake 按事件更新质押记录。链上同一用户同一代币同时只有一笔活跃质押；
+// symbol 为 tokens 配置中代币对应的股票代码，质押价格取该股票当前的价格
+func applyToStake(db *gorm.DB, vault common.Address, ev models.VaultEvent, units stakeUnits, symbol string) error {
+	var stake models.StakeRecord
+	now := time.Now()
+
+	switch ev.Event {
+	case "StockStaked":
+		// 优先匹配通过 API 发起的质押，其次是同一质押人未结束的记录
+		err := db.Where("chain = ? AND tx_hash = ?", ev.Chain, ev.TxHash).First(&stake).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = activeStake(db, ev).First(&stake).Error
+		}
+		before := ""
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			stake = models.StakeRecord{
+				UserAddress:     ev.UserAddress,
+				TokenAddress:    ev.TokenAddress,
+				Chain:           ev.Chain,
+				ContractAddress: vault.Hex(),
+				CreatedAt:       now,
+			}
+		} else if err != nil {
+			return err
+		} else if before, err = snapshot(stake); err != nil {
+			return err
+		}
+		stake.Staker = ev.UserAddress
+		if symbol != "" {
+			stake.StockSymbol = symbol
+		}
+		var price models.TokenPrice
+		if stake.StockSymbol != "" && db.Where("symbol = ?", stake.StockSymbol).First(&price).Error == nil {
+			stake.StakePrice = price.Price
+		}
+		stake.Amount = money.FromUnits(rawUnits(ev.Amount), units.token)
+		stake.SCOSBorrowed = money.FromUnits(rawUnits(ev.BorrowedSCOS), units.scos)
+		stake.Status = "active"
+		stake.TxHash = ev.TxHash
+		stake.BlockNumber = ev.BlockNumber
+		stake.UpdatedAt = now
+		return saveStake(db, ev, &stake, before)
+
+	case "StockWithdrawn", "DebtRepaid":
+		// 事件带有剩余数量，直接覆盖，与 API 已写入的结果一致
+		err := activeStake(db, ev).Order("id desc").First(&stake).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		before, err := snapshot(stake)
+		if err != nil {
+			return err
+		}
+		if ev.Event == "StockWithdrawn" {
+			stake.Amount = money.FromUnits(rawUnits(ev.Remaining), units.token)
+		} else {
+			stake.SCOSBorrowed = money.FromUnits(rawUnits(ev.Remaining), units.scos)
+		}
+		stake.UpdatedAt = now
+		return saveStake(db, ev, &stake, before)
+
+	case "StockUnstaked", "Liquidated":
+		err := activeStake(db, ev).Order("id desc").First(&stake).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		before, err := snapshot(stake)
+		if err != nil {
+			return err
+		}
+		stake.Status = "redeemed"
+		if ev.Event == "Liquidated" {
+			stake.Status = "liquidated"
+		}
+		stake.UpdatedAt = now
+		return saveStake(db, ev, &stake, before)
+	}
+	return nil
+}
+
+// saveStake 保存质押记录并记下修改前的状态，供重组回滚使用
+func saveStake(db *gorm.DB, ev models.VaultEvent, stake *models.StakeRecord, before string) error {
+	if err := db.Save(stake).Error; err != nil {
+		return err
+	}
+	return db.Create(&models.StakeChange{
+		Chain:       ev.Chain,
+		BlockNumber: ev.BlockNumber,
+		StakeID:     stake.ID,
+		Before:      before,
+	}).Error
+}
+
+func snapshot(stake models.StakeRecord) (string, error) {
+	b, err := json.Marshal(stake)
+	return string(b), err
+}
+
+func activeStake(db *gorm.DB, ev models.VaultEvent) *gorm.DB {
+	return db.Where("chain = ? AND LOWER(staker) = ? AND LOWER(token_address) = ? AND status = ?",
+		ev.Chain, strings.ToLower(ev.UserAddress), strings.ToLower(ev.TokenAddress), "active")
+}
+
+// recordIndexedTx 直接调用合约（未经本服务）的交易补录到 Transaction
+func recordIndexedTx(db *gorm.DB, ev models.VaultEvent) error {
+	var count int64
+	if err := db.Model(&models.Transaction{}).Where("chain = ? AND tx_hash = ?", ev.Chain, ev.TxHash).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	txType := map[string]string{
+		"StockStaked":    "stake",
+		"StockUnstaked":  "unstake",
+		"StockWithdrawn": "withdraw",
+		"DebtRepaid":     "repay",
+		"Liquidated":     "liquidate",
+	}[ev.Event]
+	return db.Create(&models.Transaction{
+		UserAddress: ev.UserAddress,
+		Type:        txType,
+		TxHash:      ev.TxHash,
+		Chain:       ev.Chain,
+		Status:      "confirmed",
+		BlockNumber: ev.BlockNumber,
+		Source:      "indexer",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}).Error
+}
+
+// queueMint 为质押排队一笔 mint，与事件在同一事务中写入；stake 与 mint 两笔交易互相关联。
+// 每个 StockStaked 事件只铸造一次：重组后重新索引时，已有未失败的 mint 只改为关联新的质押记录
+func queueMint(db *gorm.DB, ev models.VaultEvent) error {
+	if borrowed := rawUnits(ev.BorrowedSCOS); borrowed == nil || borrowed.Sign() <= 0 {
+		return nil
+	}
+	var stake models.StakeRecord
+	if err := db.Where("chain = ? AND tx_hash = ?", ev.Chain, ev.TxHash).First(&stake).Error; err != nil {
+		return err
+	}
+	var stakeTx models.Transaction
+	if err := db.Where("chain = ? AND tx_hash = ?", ev.Chain, ev.TxHash).First(&stakeTx).Error; err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s:%d", ev.TxHash, ev.LogIndex)
+	var mint models.Transaction
+	err := db.Where("chain = ? AND type = ? AND stake_event = ? AND status <> ?", ev.Chain, "mint", key, "failed").First(&mint).Error
+	switch {
+	case err == nil:
+		mint.StakeID, mint.LinkedTxID, mint.UpdatedAt = stake.ID, stakeTx.ID, time.Now()
+		if err := db.Save(&mint).Error; err != nil {
+			return err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		mint = models.Transaction{
+			UserAddress: ev.UserAddress,
+			Type:        "mint",
+			Chain:       ev.Chain,
+			Status:      "queued",
+			BlockNumber: ev.BlockNumber,
+			StakeID:     stake.ID,
+			LinkedTxID:  stakeTx.ID,
+			StakeEvent:  key,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if err := db.Create(&mint).Error; err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+	return db.Model(&stakeTx).Updates(map[string]interface{}{"stake_id": stake.ID, "linked_tx_id": mint.ID}).Error
+}
+
+// sendMints 发送该链排队中的 mint，数量取对应 StockStaked 事件的 borrowedSCOS。
+// revert 的 mint 标记为 failed 等待人工处理，其他错误留在队列中，下一轮同步时重试
+func (ix *Indexer) sendMints(ctx context.Context, chain string) error {
+	var queued []models.Transaction
+	if err := ix.db.Where("chain = ? AND type = ? AND status = ?", chain, "mint", "queued").Order("id").Find(&queued).Error; err != nil {
+		return err
+	}
+	for _, mint := range queued {
+		var stakeTx models.Transaction
+		if err := ix.db.First(&stakeTx, mint.LinkedTxID).Error; err != nil {
+			return err
+		}
+		var ev models.VaultEvent
+		err := ix.db.Where("chain = ? AND tx_hash = ? AND event = ?", chain, stakeTx.TxHash, "StockStaked").First(&ev).Error
+		if err != nil {
+			return err
+		}
+
+		hash, err := ix.bc.MintSCOS(ctx, chain, mint.UserAddress, rawUnits(ev.BorrowedSCOS))
+		var revert *RevertError
+		switch {
+		case errors.As(err, &revert):
+			log.Printf("indexer %s: mint for stake %d reverted: %v", chain, mint.StakeID, err)
+			mint.Status = "failed"
+			mint.RevertReason = err.Error()
+		case err != nil:
+			return fmt.Errorf("mint SCOS for stake %d: %w", mint.StakeID, err)
+		default:
+			mint.TxHash = hash
+			mint.Status = "pending"
+		}
+		// 发送后不再随所属区块回滚，重组时由回执监听器复核或由 queueMint 重新关联
+		mint.BlockNumber = 0
+		mint.UpdatedAt = time.Now()
+		if err := ix.db.Save(&mint).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rawUnits 解析事件中十进制字符串形式的最小单位，无法解析时返回 nil
+func rawUnits(raw string) *big.Int {
+	v, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return nil
+	}
+	return v
+}
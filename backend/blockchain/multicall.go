@@ -0,0 +1,225 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"scos/contracts"
+)
+
+const (
+	multicallBatchSize  = 200 // 单次 aggregate3 打包的调用数
+	fallbackParallelism = 8   // 没有 Multicall3 时并发的单独调用数
+)
+
+// Multicall3 在绝大多数 EVM 链上部署在同一地址
+var multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+var (
+	multicallABI = mustABI(contracts.Multicall3MetaData)
+	tokenABI     = mustABI(contracts.StockTokenMetaData)
+)
+
+// Call 一次只读合约调用
+type Call struct {
+	Target common.Address
+	Data   []byte
+}
+
+// CallResult 单个调用的返回数据；Err 只表示该调用自身失败（如 revert），不影响同批其他调用
+type CallResult struct {
+	Data []byte
+	Err  error
+}
+
+// Result 批量读取中单项的解码结果
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// StakeKey 金库 userStakes 的键，Staker 为链上 msg.sender
+type StakeKey struct {
+	Staker common.Address
+	Token  common.Address
+}
+
+// BalanceKey ERC20 balanceOf 查询
+type BalanceKey struct {
+	Token common.Address
+	Owner common.Address
+}
+
+// BatchCall 批量执行只读调用，结果与 calls 一一对应。
+// 链上有 Multicall3 时每 multicallBatchSize 个调用合并为一次 eth_call，否则退回并发的单独调用。
+// 节点不可达等整批失败的情况返回 error
+func (bc *BlockchainClient) BatchCall(ctx context.Context, chain string, calls []Call) ([]CallResult, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CallResult, len(calls))
+	if len(calls) == 0 {
+		return results, nil
+	}
+
+	mc, ok := c.multicall(ctx)
+	if !ok {
+		return results, parallelCalls(ctx, c.client, calls, results)
+	}
+	for start := 0; start < len(calls); start += multicallBatchSize {
+		end := min(start+multicallBatchSize, len(calls))
+		if err := aggregate(ctx, c.client, mc, calls[start:end], results[start:end]); err != nil {
+			return nil, fmt.Errorf("multicall on %s: %w", chain, err)
+		}
+	}
+	return results, nil
+}
+
+// GetStakeInfos 批量读取金库 getStakeInfo
+func (bc *BlockchainClient) GetStakeInfos(ctx context.Context, chain string, keys []StakeKey) ([]Result[contracts.StockVaultStakeInfo], error) {
+	vault, err := bc.GetVaultAddr(chain)
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]Call, len(keys))
+	for i, k := range keys {
+		data, err := vaultABI.Pack("getStakeInfo", k.Staker, k.Token)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = Call{Target: vault, Data: data}
+	}
+	return batchRead[contracts.StockVaultStakeInfo](ctx, bc, chain, vaultABI, "getStakeInfo", calls)
+}
+
+// TokenBalances 批量读取 ERC20 balanceOf，返回最小单位
+func (bc *BlockchainClient) TokenBalances(ctx context.Context, chain string, keys []BalanceKey) ([]Result[*big.Int], error) {
+	calls := make([]Call, len(keys))
+	for i, k := range keys {
+		data, err := tokenABI.Pack("balanceOf", k.Owner)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = Call{Target: k.Token, Data: data}
+	}
+	return batchRead[*big.Int](ctx, bc, chain, tokenABI, "balanceOf", calls)
+}
+
+// batchRead 执行同一方法的批量调用并把单个返回值解码为 T
+func batchRead[T any](ctx context.Context, bc *BlockchainClient, chain string, parsed *abi.ABI, method string, calls []Call) ([]Result[T], error) {
+	results, err := bc.BatchCall(ctx, chain, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Result[T], len(results))
+	for i, r := range results {
+		if r.Err != nil {
+			out[i].Err = r.Err
+			continue
+		}
+		values, err := parsed.Unpack(method, r.Data)
+		if err != nil {
+			out[i].Err = fmt.Errorf("decode %s: %w", method, err)
+			continue
+		}
+		out[i].Value = *abi.ConvertType(values[0], new(T)).(*T)
+	}
+	return out, nil
+}
+
+// multicall 返回该链的 Multicall3 地址：配置了 multicall_address 时直接使用，
+// 否则检查标准地址上是否部署了合约。检测结果缓存，检测失败时下次重试
+func (c *cli) multicall(ctx context.Context) (common.Address, bool) {
+	if c.info.MulticallAddress != "" {
+		return common.HexToAddress(c.info.MulticallAddress), true
+	}
+
+	c.mcMu.Lock()
+	defer c.mcMu.Unlock()
+	if !c.mcChecked {
+		code, err := c.client.CodeAt(ctx, multicall3Address, nil)
+		if err != nil {
+			return common.Address{}, false
+		}
+		c.mcChecked, c.mcFound = true, len(code) > 0
+	}
+	return multicall3Address, c.mcFound
+}
+
+// aggregate 用 aggregate3 执行一批调用，单个调用失败不影响其他调用
+func aggregate(ctx context.Context, client ChainClient, mc common.Address, calls []Call, out []CallResult) error {
+	args := make([]contracts.Multicall3Call3, len(calls))
+	for i, call := range calls {
+		args[i] = contracts.Multicall3Call3{Target: call.Target, AllowFailure: true, CallData: call.Data}
+	}
+	data, err := multicallABI.Pack("aggregate3", args)
+	if err != nil {
+		return err
+	}
+
+	raw, err := client.CallContract(ctx, ethereum.CallMsg{To: &mc, Data: data}, nil)
+	if err != nil {
+		return asRevert(err)
+	}
+	values, err := multicallABI.Unpack("aggregate3", raw)
+	if err != nil {
+		return fmt.Errorf("decode aggregate3: %w", err)
+	}
+	results := *abi.ConvertType(values[0], new([]contracts.Multicall3Result)).(*[]contracts.Multicall3Result)
+	if len(results) != len(calls) {
+		return fmt.Errorf("aggregate3 returned %d results for %d calls", len(results), len(calls))
+	}
+
+	for i, r := range results {
+		if r.Success {
+			out[i].Data = r.ReturnData
+			continue
+		}
+		reason, _ := abi.UnpackRevert(r.ReturnData)
+		out[i].Err = &RevertError{Reason: reason, Data: r.ReturnData}
+	}
+	return nil
+}
+
+// parallelCalls 并发执行单独的 eth_call，revert 记录在对应结果中，其他错误使整批失败
+func parallelCalls(ctx context.Context, client ChainClient, calls []Call, out []CallResult) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, fallbackParallelism)
+	for i := range calls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			target := calls[i].Target
+			data, err := client.CallContract(ctx, ethereum.CallMsg{To: &target, Data: calls[i].Data}, nil)
+			err = asRevert(err)
+
+			var revert *RevertError
+			if err == nil || errors.As(err, &revert) {
+				out[i] = CallResult{Data: data, Err: err}
+				return
+			}
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
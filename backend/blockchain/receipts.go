@@ -0,0 +1,154 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+
+	"scos/config"
+	"scos/models"
+)
+
+// ReceiptWatcher 轮询 pending 状态交易的回执，达到确认数后更新为 confirmed / failed，
+// 节点长时间查不到的交易标记为 dropped
+type ReceiptWatcher struct {
+	db  *gorm.DB
+	bc  *BlockchainClient
+	cfg config.WatcherConfig
+}
+
+func NewReceiptWatcher(db *gorm.DB, bc *BlockchainClient, cfg config.WatcherConfig) *ReceiptWatcher {
+	return &ReceiptWatcher{db: db, bc: bc, cfg: cfg}
+}
+
+func (w *ReceiptWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.ApplyReplacements(w.bc.ReplaceStuck(ctx))
+			w.Poll(ctx)
+		}
+	}
+}
+
+// ApplyReplacements 交易被加价替换后，把记录指向新的哈希，保留最初的哈希便于查询
+func (w *ReceiptWatcher) ApplyReplacements(replaced []Replacement) {
+	for _, r := range replaced {
+		var tx models.Transaction
+		if err := w.db.Where("chain = ? AND tx_hash = ?", r.Chain, r.OldHash.Hex()).First(&tx).Error; err != nil {
+			continue
+		}
+		if tx.OriginalTxHash == "" {
+			tx.OriginalTxHash = tx.TxHash
+		}
+		tx.TxHash = r.NewHash.Hex()
+		tx.UpdatedAt = time.Now()
+		w.db.Save(&tx)
+	}
+}
+
+// Poll 处理一轮所有 pending 交易
+func (w *ReceiptWatcher) Poll(ctx context.Context) {
+	var txs []models.Transaction
+	if err := w.db.Where("status = ? AND tx_hash <> ''", "pending").Find(&txs).Error; err != nil {
+		log.Printf("receipt watcher: load pending transactions: %v", err)
+		return
+	}
+
+	heads := make(map[string]uint64)
+	for i := range txs {
+		tx := &txs[i]
+		if !w.bc.Available(tx.Chain) {
+			continue
+		}
+		if err := w.check(ctx, tx, heads); err != nil {
+			log.Printf("receipt watcher: %s %s: %v", tx.Chain, tx.TxHash, err)
+		}
+	}
+}
+
+func (w *ReceiptWatcher) check(ctx context.Context, tx *models.Transaction, heads map[string]uint64) error {
+	client, err := w.bc.GetClient(tx.Chain)
+	if err != nil {
+		return err
+	}
+	hash := common.HexToHash(tx.TxHash)
+
+	receipt, err := client.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		_, _, err := client.TransactionByHash(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) && time.Since(tx.CreatedAt) > w.cfg.DropTimeout {
+			tx.Status = "dropped"
+			tx.UpdatedAt = time.Now()
+			return w.db.Save(tx).Error
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	head, ok := heads[tx.Chain]
+	if !ok {
+		if head, err = client.BlockNumber(ctx); err != nil {
+			return err
+		}
+		heads[tx.Chain] = head
+	}
+	block := receipt.BlockNumber.Uint64()
+	if head+1 < block+w.bc.Confirmations(tx.Chain) {
+		return nil
+	}
+
+	tx.BlockNumber = block
+	tx.GasUsed = receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		tx.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		tx.Status = "confirmed"
+	} else {
+		tx.Status = "failed"
+		tx.RevertReason = revertReason(ctx, client, hash, receipt.BlockNumber)
+	}
+	tx.UpdatedAt = time.Now()
+	return w.db.Save(tx).Error
+}
+
+// revertReason 在交易所在区块的父状态上重放调用，取回 revert 原因
+func revertReason(ctx context.Context, client ChainClient, hash common.Hash, block *big.Int) string {
+	tx, _, err := client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return ""
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return ""
+	}
+
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+	_, err = client.CallContract(ctx, msg, new(big.Int).Sub(block, big.NewInt(1)))
+	var revert *RevertError
+	if errors.As(asRevert(err), &revert) {
+		return revert.Reason
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"scos/config"
+)
+
+// lookup 查找可用于操作的链：不存在返回 ErrUnknownChain，已停用返回 ErrChainUnavailable
+func (bc *BlockchainClient) lookup(chain string) (*cli, error) {
+	c, err := bc.get(chain)
+	if err != nil {
+		return nil, err
+	}
+	if c.disabled.Load() {
+		return nil, fmt.Errorf("%w: %s is disabled", ErrChainUnavailable, chain)
+	}
+	return c, nil
+}
+
+func (bc *BlockchainClient) get(chain string) (*cli, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	c := bc.clients[chain]
+	if c == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, chain)
+	}
+	return c, nil
+}
+
+func (bc *BlockchainClient) all() []*cli {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	out := make([]*cli, 0, len(bc.clients))
+	for _, c := range bc.clients {
+		out = append(out, c)
+	}
+	return out
+}
+
+// GetClient 返回该链的节点客户端，调用会路由到当前健康的 RPC 节点
+func (bc *BlockchainClient) GetClient(chain string) (ChainClient, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+	return c.client, nil
+}
+
+func (bc *BlockchainClient) GetVaultAddr(chain string) (common.Address, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return c.VaultAddress, nil
+}
+
+// ChainID 该链配置的 chain_id
+func (bc *BlockchainClient) ChainID(chain string) (*big.Int, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := new(big.Int).SetString(c.info.ChainID, 10)
+	if !ok {
+		return nil, fmt.Errorf("chain %s: invalid chain_id %q", chain, c.info.ChainID)
+	}
+	return id, nil
+}
+
+// CheckChain 链存在、未停用且有健康的 RPC 节点时返回 nil
+func (bc *BlockchainClient) CheckChain(chain string) error {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return err
+	}
+	if !c.pool.available() {
+		return fmt.Errorf("%w: %s has no healthy rpc", ErrChainUnavailable, chain)
+	}
+	return nil
+}
+
+// Chains 返回所有已注册的链名（含已停用的），按名称排序
+func (bc *BlockchainClient) Chains() []string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	names := make([]string, 0, len(bc.clients))
+	for name := range bc.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (bc *BlockchainClient) HasChain(chain string) bool {
+	_, err := bc.get(chain)
+	return err == nil
+}
+
+// Available 该链未停用且至少有一个健康的 RPC 节点
+func (bc *BlockchainClient) Available(chain string) bool {
+	return bc.CheckChain(chain) == nil
+}
+
+// Confirmations 返回该链交易视为最终确认所需的区块数，链不存在时按 1 处理
+func (bc *BlockchainClient) Confirmations(chain string) uint64 {
+	c, err := bc.get(chain)
+	if err != nil {
+		return 1
+	}
+	return c.confirmations()
+}
+
+// Status 返回各链当前使用的 RPC 节点及所有节点的探测结果
+func (bc *BlockchainClient) Status() []ChainStatus {
+	var out []ChainStatus
+	for _, name := range bc.Chains() {
+		c, err := bc.get(name)
+		if err != nil {
+			continue
+		}
+		st := c.pool.status()
+		st.Enabled = !c.disabled.Load()
+		st.Available = st.Available && st.Enabled
+		out = append(out, st)
+	}
+	return out
+}
+
+// Signer 返回已加载的同名签名器
+func (bc *BlockchainClient) Signer(name string) (Signer, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	s, ok := bc.signers[name]
+	return s, ok
+}
+
+// AddChain 运行时注册一条新链，signer、scosSigner 分别为 info.Signer、info.SCOSSigner 对应的签名器。
+// 只修改内存中的注册表，不写回配置文件
+func (bc *BlockchainClient) AddChain(name string, info config.ChainInfo, signer, scosSigner Signer) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if bc.clients[name] != nil {
+		return fmt.Errorf("%w: %s", ErrChainExists, name)
+	}
+	if _, ok := bc.signers[signerName(info)]; !ok {
+		bc.signers[signerName(info)] = signer
+	}
+	if _, ok := bc.signers[scosSignerName(info)]; !ok {
+		bc.signers[scosSignerName(info)] = scosSigner
+	}
+	bc.clients[name] = newCli(name, info, signer, scosSigner)
+	log.Printf("chain %s added", name)
+	return nil
+}
+
+// SetChainEnabled 停用的链保留在注册表中，所有操作返回 ErrChainUnavailable，后台任务跳过该链
+func (bc *BlockchainClient) SetChainEnabled(name string, enabled bool) error {
+	c, err := bc.get(name)
+	if err != nil {
+		return err
+	}
+	c.disabled.Store(!enabled)
+	log.Printf("chain %s enabled=%v", name, enabled)
+	return nil
+}
+
+// RemoveChain 从注册表移除一条链并关闭其 RPC 连接。数据库中的记录保留，重新添加后继续跟踪
+func (bc *BlockchainClient) RemoveChain(name string) error {
+	bc.mu.Lock()
+	c := bc.clients[name]
+	delete(bc.clients, name)
+	bc.mu.Unlock()
+	if c == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownChain, name)
+	}
+	c.pool.close()
+	bc.decimals.Range(func(k, _ interface{}) bool {
+		if strings.HasPrefix(k.(string), name+"/") {
+			bc.decimals.Delete(k)
+		}
+		return true
+	})
+	log.Printf("chain %s removed", name)
+	return nil
+}
@@ -0,0 +1,453 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"scos/config"
+)
+
+// rpcPool 一条链的多个 RPC 节点，所有读写都路由到当前选中的健康节点。
+// 健康探测按区块高度落后与响应延迟判断，调用出现连接类错误时立即切换。
+// 没有健康节点时整条链视为不可用，调用直接返回 ErrChainUnavailable，由后台探测按退避间隔重连
+type rpcPool struct {
+	chain string
+
+	mu        sync.RWMutex
+	endpoints []*endpoint
+	active    int
+	closed    bool // 链已移除，探测不再重新连接
+}
+
+type endpoint struct {
+	url    string
+	client ChainClient // 连接失败时为 nil，探测时重新连接
+
+	healthy   bool
+	block     uint64
+	lag       uint64
+	latency   time.Duration
+	lastErr   error
+	checkedAt time.Time
+	failures  int       // 连续失败次数，用于计算退避间隔
+	nextCheck time.Time // 下一次探测时间
+}
+
+// EndpointStatus 单个 RPC 节点的最近一次探测结果，URL 已隐去路径与参数中的密钥
+type EndpointStatus struct {
+	URL       string    `json:"url"`
+	Active    bool      `json:"active"`
+	Healthy   bool      `json:"healthy"`
+	Block     uint64    `json:"block"`
+	Lag       uint64    `json:"lag"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	NextCheck time.Time `json:"next_check"`
+}
+
+type ChainStatus struct {
+	Chain     string           `json:"chain"`
+	Enabled   bool             `json:"enabled"`
+	Available bool             `json:"available"`
+	ActiveRPC string           `json:"active_rpc"`
+	Endpoints []EndpointStatus `json:"endpoints"`
+}
+
+// newRPCPool 依次连接所有节点。连接失败的节点先标记为不健康，不影响服务启动
+func newRPCPool(chain string, urls []string) *rpcPool {
+	p := &rpcPool{chain: chain}
+	for _, u := range urls {
+		ep := &endpoint{url: u}
+		client, err := Dial(u)
+		if err != nil {
+			log.Printf("chain %s: dial %s: %v", chain, redactURL(u), err)
+			ep.lastErr = err
+		} else {
+			ep.client = client
+			ep.healthy = true
+		}
+		p.endpoints = append(p.endpoints, ep)
+	}
+	p.selectLocked()
+	return p
+}
+
+// close 关闭所有节点连接
+func (p *rpcPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, ep := range p.endpoints {
+		closeClient(ep.client)
+		ep.client = nil
+		ep.healthy = false
+	}
+}
+
+func closeClient(client ChainClient) {
+	if c, ok := client.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+func (p *rpcPool) available() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.endpoints[p.active].healthy
+}
+
+// current 返回当前节点及其客户端。客户端须在锁内读取：probe 会替换它，close 会将其置空
+func (p *rpcPool) current() (*endpoint, ChainClient, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ep := p.endpoints[p.active]
+	if !ep.healthy || ep.client == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrChainUnavailable, p.chain)
+	}
+	return ep, ep.client, nil
+}
+
+// observe 连接类错误（非节点返回的 JSON-RPC 错误）说明节点不可用，标记后切换到下一个健康节点，
+// 返回是否已切换
+func (p *rpcPool) observe(ep *endpoint, err error) bool {
+	if !isTransportError(err) {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep.healthy = false
+	ep.lastErr = err
+	ep.nextCheck = time.Time{}
+	p.selectLocked()
+	return p.endpoints[p.active] != ep
+}
+
+func isTransportError(err error) bool {
+	if err == nil || errors.Is(err, ethereum.NotFound) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// selectLocked 当前节点仍健康时保持不变，否则按配置顺序选第一个健康节点；都不健康时保持不变
+func (p *rpcPool) selectLocked() {
+	if p.endpoints[p.active].healthy {
+		return
+	}
+	for i, ep := range p.endpoints {
+		if ep.healthy {
+			log.Printf("chain %s: switching rpc %s -> %s", p.chain, redactURL(p.endpoints[p.active].url), redactURL(ep.url))
+			p.active = i
+			return
+		}
+	}
+}
+
+// probe 并发探测到期的节点：未连接的先重新连接，再查询区块高度，超过 max_latency 未返回视为失败。
+// 成功的节点按 interval 再次探测，失败的按 reconnect_min 起指数退避，最长 reconnect_max
+func (p *rpcPool) probe(ctx context.Context, cfg config.HealthConfig) {
+	type result struct {
+		client  ChainClient
+		block   uint64
+		latency time.Duration
+		err     error
+	}
+
+	// 客户端与节点列表一起在锁内取出，探测期间不持有锁
+	now := time.Now()
+	p.mu.RLock()
+	var due []*endpoint
+	var clients []ChainClient
+	for _, ep := range p.endpoints {
+		if !p.closed && !now.Before(ep.nextCheck) {
+			due = append(due, ep)
+			clients = append(clients, ep.client)
+		}
+	}
+	p.mu.RUnlock()
+	if len(due) == 0 {
+		return
+	}
+
+	results := make([]result, len(due))
+	var wg sync.WaitGroup
+	for i, ep := range due {
+		wg.Add(1)
+		go func(i int, url string, client ChainClient) {
+			defer wg.Done()
+			if client == nil {
+				c, err := Dial(url)
+				if err != nil {
+					results[i].err = err
+					return
+				}
+				client = c
+			}
+			cctx, cancel := context.WithTimeout(ctx, cfg.MaxLatency)
+			defer cancel()
+			start := time.Now()
+			block, err := client.BlockNumber(cctx)
+			results[i] = result{client: client, block: block, latency: time.Since(start), err: err}
+		}(i, ep.url, clients[i])
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		// 探测期间链被移除：丢弃新建立的连接
+		for i, r := range results {
+			if r.client != nil && r.client != clients[i] {
+				closeClient(r.client)
+			}
+		}
+		return
+	}
+	now = time.Now()
+	var highest uint64
+	for _, ep := range p.endpoints {
+		if ep.healthy && ep.block > highest {
+			highest = ep.block
+		}
+	}
+	for _, r := range results {
+		if r.err == nil && r.block > highest {
+			highest = r.block
+		}
+	}
+
+	for i, ep := range due {
+		r := results[i]
+		ep.checkedAt = now
+		ep.lastErr = r.err
+		if r.client != nil {
+			ep.client = r.client
+		}
+		if r.err != nil {
+			ep.healthy = false
+			ep.failures++
+			ep.nextCheck = now.Add(backoff(cfg, ep.failures))
+			continue
+		}
+		if ep.failures > 0 {
+			log.Printf("chain %s: rpc %s reachable again", p.chain, redactURL(ep.url))
+		}
+		ep.failures = 0
+		ep.nextCheck = now.Add(cfg.Interval)
+		ep.block = r.block
+		ep.latency = r.latency
+		ep.lag = highest - r.block
+		ep.healthy = ep.lag <= cfg.MaxBlockLag && r.latency <= cfg.MaxLatency
+	}
+	p.selectLocked()
+}
+
+func backoff(cfg config.HealthConfig, failures int) time.Duration {
+	d := cfg.ReconnectMin
+	for i := 1; i < failures && d < cfg.ReconnectMax; i++ {
+		d *= 2
+	}
+	return min(d, cfg.ReconnectMax)
+}
+
+func (p *rpcPool) status() ChainStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	st := ChainStatus{
+		Chain:     p.chain,
+		Available: p.endpoints[p.active].healthy,
+		ActiveRPC: redactURL(p.endpoints[p.active].url),
+	}
+	for i, ep := range p.endpoints {
+		es := EndpointStatus{
+			URL:       redactURL(ep.url),
+			Active:    i == p.active,
+			Healthy:   ep.healthy,
+			Block:     ep.block,
+			Lag:       ep.lag,
+			LatencyMs: ep.latency.Milliseconds(),
+			CheckedAt: ep.checkedAt,
+			NextCheck: ep.nextCheck,
+		}
+		if ep.lastErr != nil {
+			es.Error = ep.lastErr.Error()
+		}
+		st.Endpoints = append(st.Endpoints, es)
+	}
+	return st
+}
+
+// redactURL 节点地址的路径和参数里常带 API key，对外只展示协议与主机
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	if (u.Path == "" || u.Path == "/") && u.RawQuery == "" {
+		return u.Scheme + "://" + u.Host + u.Path
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
+
+// poolCall 在当前节点上调用，遇到连接类错误且已切换节点时在新节点上重试一次。只用于可以重复执行的调用
+func poolCall[T any](p *rpcPool, fn func(ChainClient) (T, error)) (T, error) {
+	v, switched, err := callOnce(p, fn)
+	if switched {
+		v, _, err = callOnce(p, fn)
+	}
+	return v, err
+}
+
+// callOnce 在当前节点上调用一次，返回是否因连接类错误切换了节点
+func callOnce[T any](p *rpcPool, fn func(ChainClient) (T, error)) (T, bool, error) {
+	var zero T
+	ep, client, err := p.current()
+	if err != nil {
+		return zero, false, err
+	}
+	v, err := fn(client)
+	return v, p.observe(ep, err), err
+}
+
+// 以下方法让 rpcPool 满足 ChainClient，调用转发到当前节点
+
+func (p *rpcPool) BlockNumber(ctx context.Context) (uint64, error) {
+	return poolCall(p, func(c ChainClient) (uint64, error) { return c.BlockNumber(ctx) })
+}
+
+func (p *rpcPool) ChainID(ctx context.Context) (*big.Int, error) {
+	return poolCall(p, func(c ChainClient) (*big.Int, error) { return c.ChainID(ctx) })
+}
+
+func (p *rpcPool) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return poolCall(p, func(c ChainClient) (*types.Block, error) { return c.BlockByHash(ctx, hash) })
+}
+
+func (p *rpcPool) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return poolCall(p, func(c ChainClient) (*types.Block, error) { return c.BlockByNumber(ctx, number) })
+}
+
+func (p *rpcPool) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return poolCall(p, func(c ChainClient) (*types.Header, error) { return c.HeaderByHash(ctx, hash) })
+}
+
+func (p *rpcPool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return poolCall(p, func(c ChainClient) (*types.Header, error) { return c.HeaderByNumber(ctx, number) })
+}
+
+func (p *rpcPool) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	return poolCall(p, func(c ChainClient) (uint, error) { return c.TransactionCount(ctx, blockHash) })
+}
+
+func (p *rpcPool) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	return poolCall(p, func(c ChainClient) (*types.Transaction, error) { return c.TransactionInBlock(ctx, blockHash, index) })
+}
+
+func (p *rpcPool) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return poolCall(p, func(c ChainClient) (ethereum.Subscription, error) { return c.SubscribeNewHead(ctx, ch) })
+}
+
+func (p *rpcPool) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return poolCall(p, func(c ChainClient) (*big.Int, error) { return c.BalanceAt(ctx, account, blockNumber) })
+}
+
+func (p *rpcPool) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return poolCall(p, func(c ChainClient) ([]byte, error) { return c.StorageAt(ctx, account, key, blockNumber) })
+}
+
+func (p *rpcPool) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return poolCall(p, func(c ChainClient) ([]byte, error) { return c.CodeAt(ctx, account, blockNumber) })
+}
+
+func (p *rpcPool) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return poolCall(p, func(c ChainClient) (uint64, error) { return c.NonceAt(ctx, account, blockNumber) })
+}
+
+func (p *rpcPool) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return poolCall(p, func(c ChainClient) ([]byte, error) { return c.CallContract(ctx, call, blockNumber) })
+}
+
+func (p *rpcPool) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return poolCall(p, func(c ChainClient) (uint64, error) { return c.EstimateGas(ctx, call) })
+}
+
+func (p *rpcPool) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return poolCall(p, func(c ChainClient) (*big.Int, error) { return c.SuggestGasPrice(ctx) })
+}
+
+func (p *rpcPool) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return poolCall(p, func(c ChainClient) (*big.Int, error) { return c.SuggestGasTipCap(ctx) })
+}
+
+func (p *rpcPool) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return poolCall(p, func(c ChainClient) (*ethereum.FeeHistory, error) {
+		return c.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	})
+}
+
+func (p *rpcPool) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return poolCall(p, func(c ChainClient) ([]types.Log, error) { return c.FilterLogs(ctx, q) })
+}
+
+func (p *rpcPool) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return poolCall(p, func(c ChainClient) (ethereum.Subscription, error) { return c.SubscribeFilterLogs(ctx, q, ch) })
+}
+
+func (p *rpcPool) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return poolCall(p, func(c ChainClient) (*big.Int, error) { return c.PendingBalanceAt(ctx, account) })
+}
+
+func (p *rpcPool) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	return poolCall(p, func(c ChainClient) ([]byte, error) { return c.PendingStorageAt(ctx, account, key) })
+}
+
+func (p *rpcPool) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return poolCall(p, func(c ChainClient) ([]byte, error) { return c.PendingCodeAt(ctx, account) })
+}
+
+func (p *rpcPool) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return poolCall(p, func(c ChainClient) (uint64, error) { return c.PendingNonceAt(ctx, account) })
+}
+
+func (p *rpcPool) PendingTransactionCount(ctx context.Context) (uint, error) {
+	return poolCall(p, func(c ChainClient) (uint, error) { return c.PendingTransactionCount(ctx) })
+}
+
+func (p *rpcPool) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	return poolCall(p, func(c ChainClient) ([]byte, error) { return c.PendingCallContract(ctx, call) })
+}
+
+func (p *rpcPool) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	var pending bool
+	tx, err := poolCall(p, func(c ChainClient) (*types.Transaction, error) {
+		tx, isPending, err := c.TransactionByHash(ctx, hash)
+		pending = isPending
+		return tx, err
+	})
+	return tx, pending, err
+}
+
+func (p *rpcPool) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return poolCall(p, func(c ChainClient) (*types.Receipt, error) { return c.TransactionReceipt(ctx, hash) })
+}
+
+// SendTransaction 不在其他节点上重试：连接中断时交易可能已被原节点收下并转发，
+// 重发会得到 "already known" 等错误而被误报为失败。节点仍会被标记并切换，调用方下次发送前按节点重新同步 nonce
+func (p *rpcPool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	_, _, err := callOnce(p, func(c ChainClient) (struct{}, error) { return struct{}{}, c.SendTransaction(ctx, tx) })
+	return err
+}
@@ -0,0 +1,69 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"scos/contracts"
+)
+
+var scosABI = mustABI(contracts.SCOSMetaData)
+
+// MintSCOS 由 SCOS owner 给 to 铸造 amount（最小单位）
+func (bc *BlockchainClient) MintSCOS(ctx context.Context, chain, to string, amount *big.Int) (string, error) {
+	return bc.transactSCOS(ctx, chain, "mint", common.HexToAddress(to), amount)
+}
+
+// SCOSBalance 该链 SCOS 的 balanceOf，返回最小单位
+func (bc *BlockchainClient) SCOSBalance(ctx context.Context, chain, owner string) (*big.Int, error) {
+	c, addr, err := bc.scosContract(chain)
+	if err != nil {
+		return nil, err
+	}
+	scos, err := contracts.NewSCOSCaller(addr, c.client)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := scos.BalanceOf(&bind.CallOpts{Context: ctx}, common.HexToAddress(owner))
+	return balance, asRevert(err)
+}
+
+// SCOSAllowance owner 授权金库转走的 SCOS，赎回与还款时金库以 burnFrom 销毁
+func (bc *BlockchainClient) SCOSAllowance(ctx context.Context, chain, owner string) (*big.Int, error) {
+	c, scos, err := bc.scosContract(chain)
+	if err != nil {
+		return nil, err
+	}
+	return bc.TokenAllowance(ctx, chain, scos.Hex(), owner, c.VaultAddress.Hex())
+}
+
+func (bc *BlockchainClient) transactSCOS(ctx context.Context, chain, method string, args ...interface{}) (string, error) {
+	c, scos, err := bc.scosContract(chain)
+	if err != nil {
+		return "", err
+	}
+	data, err := scosABI.Pack(method, args...)
+	if err != nil {
+		return "", err
+	}
+	tx, err := c.scosTM.send(ctx, scos, data, big.NewInt(0))
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
+func (bc *BlockchainClient) scosContract(chain string) (*cli, common.Address, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if c.info.SCOSAddress == "" {
+		return nil, common.Address{}, fmt.Errorf("%w: %s", ErrSCOSNotConfigured, chain)
+	}
+	return c, common.HexToAddress(c.info.SCOSAddress), nil
+}
@@ -0,0 +1,163 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"scos/config"
+)
+
+// Signer 为运营账户签名交易，私钥本身不一定在本进程内
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewSigner 按配置创建签名器
+func NewSigner(cfg config.SignerConfig) (Signer, error) {
+	switch cfg.Type {
+	case "keystore":
+		return newKeystoreSigner(cfg)
+	case "env":
+		hexKey := os.Getenv(cfg.Env)
+		if hexKey == "" {
+			return nil, fmt.Errorf("signer: environment variable %s is empty", cfg.Env)
+		}
+		return newHexKeySigner(hexKey)
+	case "file":
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("signer: %w", err)
+		}
+		return newHexKeySigner(string(data))
+	case "remote":
+		return NewRemoteSigner(cfg.URL, common.HexToAddress(cfg.Address))
+	default:
+		return nil, fmt.Errorf("signer: unknown type %q", cfg.Type)
+	}
+}
+
+type keySigner struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+// NewKeySigner 使用内存中的私钥签名
+func NewKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &keySigner{key: key, addr: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func newHexKeySigner(hexKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("signer: invalid private key: %w", err)
+	}
+	return NewKeySigner(key), nil
+}
+
+func newKeystoreSigner(cfg config.SignerConfig) (Signer, error) {
+	keyJSON, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("signer: %w", err)
+	}
+
+	password := os.Getenv(cfg.PasswordEnv)
+	if cfg.PasswordFile != "" {
+		data, err := os.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("signer: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("signer: decrypt keystore %s: %w", cfg.Path, err)
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.addr
+}
+
+func (s *keySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// RemoteSigner 通过 JSON-RPC eth_signTransaction 调用远程签名服务（如 Web3Signer）
+type RemoteSigner struct {
+	client *rpc.Client
+	addr   common.Address
+}
+
+func NewRemoteSigner(url string, addr common.Address) (*RemoteSigner, error) {
+	client, err := rpc.DialOptions(context.Background(), url,
+		rpc.WithHTTPClient(&http.Client{Timeout: 15 * time.Second}))
+	if err != nil {
+		return nil, fmt.Errorf("signer: dial %s: %w", url, err)
+	}
+	return &RemoteSigner{client: client, addr: addr}, nil
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.addr
+}
+
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := map[string]interface{}{
+		"from":    s.addr,
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"input":   hexutil.Bytes(tx.Data()),
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"chainId": (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	var raw hexutil.Bytes
+	if err := s.client.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer: decode signed tx: %w", err)
+	}
+
+	// 远程签名结果必须与请求的交易一致，且由约定账户签名
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New("remote signer: signed transaction does not match request")
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+	if from != s.addr {
+		return nil, fmt.Errorf("remote signer: signed by %s, expected %s", from.Hex(), s.addr.Hex())
+	}
+	return signed, nil
+}
@@ -0,0 +1,441 @@
+package blockchain
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"scos/config"
+)
+
+const (
+	// 估算结果上浮的比例，避免状态变化导致 out of gas
+	gasLimitBufferPercent = 20
+	// 节点接受同 nonce 替换交易的最低加价比例
+	minBumpPercent = 10
+	// 广播不随调用方取消，单独限时
+	broadcastTimeout = 15 * time.Second
+)
+
+// txManager 每条链一个，所有写操作经由它串行发送。
+// nonce 在本地递增分配，发送出错后下一次发送前重新向节点同步。
+type txManager struct {
+	chain  string
+	client ChainClient
+	fees   config.FeeConfig
+
+	mu       sync.Mutex // 串行化发送、替换与密钥轮换
+	chainID  *big.Int
+	nonce    uint64
+	synced   bool
+	inflight map[uint64]*inflightTx // 已广播未上链的交易，按 nonce 索引
+	rotating chan struct{}          // 密钥轮换期间非 nil，新的发送等待它关闭
+
+	signerMu sync.RWMutex
+	signer   Signer
+}
+
+// inflightTx 记录同一 nonce 的最新广播版本及历次替换的哈希
+type inflightTx struct {
+	tx       *types.Transaction
+	hashes   []common.Hash
+	sentAt   time.Time
+	cancelAt int // 被取消时 hashes[cancelAt:] 为取消交易的各个版本，0 表示未取消
+}
+
+// replacement 上链的不是最新版本时应改写的记录：取消之前的版本属于原操作的记录，
+// 其哈希停在取消前的最后一个版本；其余版本属于最新版本所在的记录
+func (p *inflightTx) replacement(chain string, mined common.Hash) (Replacement, bool) {
+	old := p.tx.Hash()
+	if p.cancelAt > 0 && slices.Index(p.hashes, mined) < p.cancelAt {
+		old = p.hashes[p.cancelAt-1]
+	}
+	return Replacement{Chain: chain, OldHash: old, NewHash: mined}, old != mined
+}
+
+// Replacement 同一 nonce 的交易换了哈希：被加价替换，或最终上链的是较早的版本
+type Replacement struct {
+	Chain   string
+	OldHash common.Hash
+	NewHash common.Hash
+}
+
+func newTxManager(chain string, client ChainClient, signer Signer, fees config.FeeConfig) *txManager {
+	return &txManager{
+		chain:    chain,
+		client:   client,
+		signer:   signer,
+		fees:     fees,
+		inflight: make(map[uint64]*inflightTx),
+	}
+}
+
+func (m *txManager) currentSigner() Signer {
+	m.signerMu.RLock()
+	defer m.signerMu.RUnlock()
+	return m.signer
+}
+
+// send 构造、签名并广播一笔交易
+func (m *txManager) send(ctx context.Context, to common.Address, data []byte, value *big.Int) (*types.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 轮换完成后用新签名器发送
+	for m.rotating != nil {
+		done := m.rotating
+		m.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			m.mu.Lock()
+			return nil, ctx.Err()
+		}
+		m.mu.Lock()
+	}
+
+	tx, err := m.sendLocked(ctx, to, data, value)
+	if err != nil && isNonceError(err) {
+		// 节点上的 nonce 已被占用（例如其他进程用同一账户发过交易），同步后重试一次
+		tx, err = m.sendLocked(ctx, to, data, value)
+	}
+	return tx, err
+}
+
+func (m *txManager) sendLocked(ctx context.Context, to common.Address, data []byte, value *big.Int) (*types.Transaction, error) {
+	signer := m.currentSigner()
+
+	if m.chainID == nil {
+		chainID, err := m.client.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.chainID = chainID
+	}
+	if !m.synced {
+		nonce, err := m.client.PendingNonceAt(ctx, signer.Address())
+		if err != nil {
+			return nil, err
+		}
+		m.nonce = nonce
+		m.synced = true
+	}
+
+	_, gasLimit, err := m.preflight(ctx, signer.Address(), to, data, value)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := suggestFees(ctx, m.client, m.fees)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := m.buildTx(fees, m.nonce, gasLimit, to, value, data)
+	signed, err := signer.SignTx(ctx, tx, m.chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.broadcast(ctx, signed); err != nil {
+		m.synced = false
+		return nil, err
+	}
+	m.nonce++
+	m.track(signed)
+	return signed, nil
+}
+
+// preflight 广播前先在 pending 状态上预执行并估算 gas，注定失败的交易不上链。返回 eth_call 结果与加上余量的 gas limit
+func (m *txManager) preflight(ctx context.Context, from, to common.Address, data []byte, value *big.Int) ([]byte, uint64, error) {
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    &to,
+		Value: value,
+		Data:  data,
+	}
+	ret, err := m.client.PendingCallContract(ctx, msg)
+	if err != nil {
+		return nil, 0, asRevert(err)
+	}
+	gasLimit, err := m.client.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, 0, asRevert(err)
+	}
+	return ret, gasLimit + gasLimit*gasLimitBufferPercent/100, nil
+}
+
+// broadcast 广播开始后不随调用方取消：否则交易可能已进入交易池，调用方却收到错误而没有记录
+func (m *txManager) broadcast(ctx context.Context, tx *types.Transaction) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), broadcastTimeout)
+	defer cancel()
+	return m.client.SendTransaction(ctx, tx)
+}
+
+func (m *txManager) track(tx *types.Transaction) {
+	m.inflight[tx.Nonce()] = &inflightTx{tx: tx, hashes: []common.Hash{tx.Hash()}, sentAt: time.Now()}
+}
+
+// replaceStuck 清理已上链的 nonce，并对超过 stuck_timeout 仍未上链的交易加价重发
+func (m *txManager) replaceStuck(ctx context.Context) []Replacement {
+	if m.fees.StuckTimeout <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.inflight) == 0 {
+		return nil
+	}
+
+	signer := m.currentSigner()
+	latest, err := m.client.NonceAt(ctx, signer.Address(), nil)
+	if err != nil {
+		log.Printf("tx manager %s: %v", m.chain, err)
+		return nil
+	}
+
+	var replaced []Replacement
+	for nonce, p := range m.inflight {
+		if nonce < latest {
+			delete(m.inflight, nonce)
+			if mined := m.minedHash(ctx, p); mined != (common.Hash{}) {
+				if r, ok := p.replacement(m.chain, mined); ok {
+					replaced = append(replaced, r)
+				}
+			}
+			continue
+		}
+		if time.Since(p.sentAt) < m.fees.StuckTimeout {
+			continue
+		}
+
+		next, err := m.bumpTx(ctx, p.tx, *p.tx.To(), p.tx.Value(), p.tx.Data(), p.tx.Gas())
+		if err != nil {
+			log.Printf("tx manager %s: cannot replace %s: %v", m.chain, p.tx.Hash().Hex(), err)
+			continue
+		}
+		signed, err := signer.SignTx(ctx, next, m.chainID)
+		if err != nil {
+			log.Printf("tx manager %s: sign replacement for %s: %v", m.chain, p.tx.Hash().Hex(), err)
+			continue
+		}
+		if err := m.broadcast(ctx, signed); err != nil {
+			log.Printf("tx manager %s: send replacement for %s: %v", m.chain, p.tx.Hash().Hex(), err)
+			continue
+		}
+
+		log.Printf("tx manager %s: replaced stuck tx %s with %s (nonce %d)", m.chain, p.tx.Hash().Hex(), signed.Hash().Hex(), nonce)
+		replaced = append(replaced, Replacement{Chain: m.chain, OldHash: p.tx.Hash(), NewHash: signed.Hash()})
+		p.tx = signed
+		p.hashes = append(p.hashes, signed.Hash())
+		p.sentAt = time.Now()
+	}
+	return replaced
+}
+
+// minedHash 找出同一 nonce 的各个版本中实际上链的那一个
+func (m *txManager) minedHash(ctx context.Context, p *inflightTx) common.Hash {
+	if len(p.hashes) == 1 {
+		return p.hashes[0]
+	}
+	for _, hash := range p.hashes {
+		if _, err := m.client.TransactionReceipt(ctx, hash); err == nil {
+			return hash
+		}
+	}
+	return common.Hash{}
+}
+
+// cancel 用同一 nonce 发送 0 金额的自转账，以更高费用顶替一笔未上链的运营交易
+func (m *txManager) cancel(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	signer := m.currentSigner()
+	var old *types.Transaction
+	var entry *inflightTx
+	for _, p := range m.inflight {
+		if slices.Contains(p.hashes, hash) {
+			old, entry = p.tx, p
+			break
+		}
+	}
+	if old == nil {
+		// 进程重启后本地没有记录，从节点交易池中查找
+		tx, pending, err := m.client.TransactionByHash(ctx, hash)
+		if err != nil || !pending {
+			return nil, ErrTxNotPending
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil || from != signer.Address() {
+			return nil, ErrNotOperatorTx
+		}
+		old = tx
+	}
+	if m.chainID == nil {
+		m.chainID = old.ChainId()
+	}
+
+	next, err := m.bumpTx(ctx, old, signer.Address(), big.NewInt(0), nil, params.TxGas)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := signer.SignTx(ctx, next, m.chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.broadcast(ctx, signed); err != nil {
+		return nil, err
+	}
+
+	// 保留之前各版本的哈希：最终上链的可能仍是原交易
+	if entry == nil {
+		entry = &inflightTx{hashes: []common.Hash{old.Hash()}}
+		m.inflight[old.Nonce()] = entry
+	}
+	if entry.cancelAt == 0 {
+		entry.cancelAt = len(entry.hashes)
+	}
+	entry.tx = signed
+	entry.hashes = append(entry.hashes, signed.Hash())
+	entry.sentAt = time.Now()
+	return signed, nil
+}
+
+// bumpTx 以 old 的 nonce 构造替换交易，费用取「按比例加价」与「当前市场价」中较高者，不超过 max_fee_gwei。
+// 节点要求替换交易各项费用至少高出 10%，达不到时返回 ErrFeeCeiling。
+func (m *txManager) bumpTx(ctx context.Context, old *types.Transaction, to common.Address, value *big.Int, data []byte, gas uint64) (*types.Transaction, error) {
+	market, err := suggestFees(ctx, m.client, m.fees)
+	if err != nil {
+		return nil, err
+	}
+	percent := m.fees.BumpPercent
+	if percent < minBumpPercent {
+		percent = minBumpPercent
+	}
+
+	if old.Type() == types.DynamicFeeTxType {
+		feeCap := capWei(maxWei(bumpWei(old.GasFeeCap(), percent), market.feeCap), m.fees.MaxFeeGwei)
+		tip := maxWei(bumpWei(old.GasTipCap(), percent), market.tipCap)
+		if tip.Cmp(feeCap) > 0 {
+			tip = new(big.Int).Set(feeCap)
+		}
+		if feeCap.Cmp(bumpWei(old.GasFeeCap(), minBumpPercent)) < 0 || tip.Cmp(bumpWei(old.GasTipCap(), minBumpPercent)) < 0 {
+			return nil, ErrFeeCeiling
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   m.chainID,
+			Nonce:     old.Nonce(),
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		}), nil
+	}
+
+	gasPrice := capWei(maxWei(bumpWei(old.GasPrice(), percent), market.gasPrice), m.fees.MaxFeeGwei)
+	if gasPrice.Cmp(bumpWei(old.GasPrice(), minBumpPercent)) < 0 {
+		return nil, ErrFeeCeiling
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    old.Nonce(),
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       &to,
+		Value:    value,
+		Data:     data,
+	}), nil
+}
+
+// buildTx 按费用模式构造 type-2 或 legacy 交易
+func (m *txManager) buildTx(fees *feeQuote, nonce, gasLimit uint64, to common.Address, value *big.Int, data []byte) *types.Transaction {
+	if fees.dynamic {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   m.chainID,
+			Nonce:     nonce,
+			GasTipCap: fees.tipCap,
+			GasFeeCap: fees.feeCap,
+			Gas:       gasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: fees.gasPrice,
+		Gas:      gasLimit,
+		To:       &to,
+		Value:    value,
+		Data:     data,
+	})
+}
+
+// rotate 阻止新的发送，等待旧账户在途交易上链后切换签名器。
+// 等待期间不持有 mu，卡住的交易仍可加价替换或取消
+func (m *txManager) rotate(ctx context.Context, next Signer) (common.Address, error) {
+	m.mu.Lock()
+	old := m.currentSigner().Address()
+	if m.rotating != nil {
+		m.mu.Unlock()
+		return old, ErrRotationInProgress
+	}
+	done := make(chan struct{})
+	m.rotating = done
+	m.mu.Unlock()
+
+	err := waitForDrain(ctx, m.client, old)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.signerMu.Lock()
+		m.signer = next
+		m.signerMu.Unlock()
+		m.synced = false
+		clear(m.inflight)
+	}
+	m.rotating = nil
+	close(done)
+	return old, err
+}
+
+// waitForDrain 等待账户在交易池中没有未打包的交易
+func waitForDrain(ctx context.Context, client ChainClient, addr common.Address) error {
+	for {
+		pending, err := client.PendingNonceAt(ctx, addr)
+		if err != nil {
+			return err
+		}
+		latest, err := client.NonceAt(ctx, addr, nil)
+		if err != nil {
+			return err
+		}
+		if pending <= latest {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// isNonceError 节点明确拒绝了该 nonce，重发不会造成重复交易
+func isNonceError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "replacement transaction underpriced")
+}
@@ -0,0 +1,201 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// UnsignedTx 由用户钱包签名的交易。Nonce 只是提示：构造时该账户的 pending nonce
+type UnsignedTx struct {
+	ChainID  *big.Int
+	From     common.Address
+	To       common.Address
+	Data     []byte
+	Value    *big.Int
+	Gas      uint64
+	Nonce    uint64
+	Dynamic  bool
+	GasPrice *big.Int // legacy
+	FeeCap   *big.Int // dynamic: maxFeePerGas
+	TipCap   *big.Int // dynamic: maxPriorityFeePerGas
+}
+
+// SubmittedTx 用户签名后经本服务广播的交易
+type SubmittedTx struct {
+	Hash common.Hash
+	From common.Address
+	Type string // stake, unstake, withdraw, repay, approve
+}
+
+// BuildStakeTx 构造由用户自己调用的 stakeStock，msg.sender 即用户
+func (bc *BlockchainClient) BuildStakeTx(ctx context.Context, chain, from, tokenAddr string, amount, scosAmount *big.Int) (*UnsignedTx, error) {
+	c, data, err := bc.packVault(chain, "stakeStock", common.HexToAddress(tokenAddr), amount, scosAmount)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildUnsigned(ctx, common.HexToAddress(from), c.VaultAddress, data)
+}
+
+// BuildUnstakeTx 构造由用户自己调用的 unstakeStock
+func (bc *BlockchainClient) BuildUnstakeTx(ctx context.Context, chain, from, tokenAddr string) (*UnsignedTx, error) {
+	c, data, err := bc.packVault(chain, "unstakeStock", common.HexToAddress(tokenAddr))
+	if err != nil {
+		return nil, err
+	}
+	return c.buildUnsigned(ctx, common.HexToAddress(from), c.VaultAddress, data)
+}
+
+// BuildWithdrawTx 构造由用户自己调用的 withdrawStock，抵押品转回用户，金库按链上价格检查剩余抵押品
+func (bc *BlockchainClient) BuildWithdrawTx(ctx context.Context, chain, from, tokenAddr string, amount *big.Int) (*UnsignedTx, error) {
+	c, data, err := bc.packVault(chain, "withdrawStock", common.HexToAddress(tokenAddr), amount)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildUnsigned(ctx, common.HexToAddress(from), c.VaultAddress, data)
+}
+
+// BuildRepayTx 构造由用户自己调用的 repayDebt，金库在同一笔交易中销毁用户偿还的 SCOS，需先授权
+func (bc *BlockchainClient) BuildRepayTx(ctx context.Context, chain, from, tokenAddr string, scosAmount *big.Int) (*UnsignedTx, error) {
+	c, data, err := bc.packVault(chain, "repayDebt", common.HexToAddress(tokenAddr), scosAmount)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildUnsigned(ctx, common.HexToAddress(from), c.VaultAddress, data)
+}
+
+// BuildApproveTx 构造授权金库转走代币的 approve：stakeStock 前授权股票代币，unstakeStock 与 repayDebt 前授权 SCOS
+func (bc *BlockchainClient) BuildApproveTx(ctx context.Context, chain, from, tokenAddr string, amount *big.Int) (*UnsignedTx, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+	data, err := tokenABI.Pack("approve", c.VaultAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildUnsigned(ctx, common.HexToAddress(from), common.HexToAddress(tokenAddr), data)
+}
+
+// buildUnsigned 以用户身份预执行并估算 gas，按该链的费用策略给出费用参数
+func (c *cli) buildUnsigned(ctx context.Context, from, to common.Address, data []byte) (*UnsignedTx, error) {
+	chainID, err := c.client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, gas, err := c.tm.preflight(ctx, from, to, data, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := c.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := suggestFees(ctx, c.client, c.info.Fees)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnsignedTx{
+		ChainID:  chainID,
+		From:     from,
+		To:       to,
+		Data:     data,
+		Value:    big.NewInt(0),
+		Gas:      gas,
+		Nonce:    nonce,
+		Dynamic:  fees.dynamic,
+		GasPrice: fees.gasPrice,
+		FeeCap:   fees.feeCap,
+		TipCap:   fees.tipCap,
+	}, nil
+}
+
+// SubmitSignedTx 校验并广播用户签名的交易。只接受 BuildXxxTx 能构造出的调用，其他交易返回 ErrUnsupportedTx
+func (bc *BlockchainClient) SubmitSignedTx(ctx context.Context, chain string, raw []byte) (*SubmittedTx, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("%w: decode: %v", ErrUnsupportedTx, err)
+	}
+	if !tx.Protected() {
+		return nil, fmt.Errorf("%w: transaction is not replay protected", ErrUnsupportedTx)
+	}
+	chainID, err := c.client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("%w: chain id %s does not match %s", ErrUnsupportedTx, tx.ChainId(), chainID)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedTx, err)
+	}
+
+	kind, err := bc.classify(c, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.tm.broadcast(ctx, tx); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			return nil, fmt.Errorf("%w: %v", ErrTxRejected, err)
+		}
+		return nil, err
+	}
+	return &SubmittedTx{Hash: tx.Hash(), From: from, Type: kind}, nil
+}
+
+// vaultTxTypes 用户可直接调用的金库方法及其交易类型
+var vaultTxTypes = map[string]string{
+	"stakeStock":    "stake",
+	"unstakeStock":  "unstake",
+	"withdrawStock": "withdraw",
+	"repayDebt":     "repay",
+}
+
+// classify 识别用户交易的类型：金库的 stakeStock/unstakeStock/withdrawStock/repayDebt 与授权给金库的 approve
+func (bc *BlockchainClient) classify(c *cli, tx *types.Transaction) (string, error) {
+	if tx.To() == nil || len(tx.Data()) < 4 {
+		return "", ErrUnsupportedTx
+	}
+	if tx.Value().Sign() != 0 {
+		return "", fmt.Errorf("%w: value must be 0", ErrUnsupportedTx)
+	}
+	to, data := *tx.To(), tx.Data()
+
+	if to == c.VaultAddress {
+		method, err := vaultABI.MethodById(data[:4])
+		if err != nil {
+			return "", ErrUnsupportedTx
+		}
+		if kind, ok := vaultTxTypes[method.Name]; ok {
+			return kind, nil
+		}
+		return "", ErrUnsupportedTx
+	}
+
+	method, err := tokenABI.MethodById(data[:4])
+	if err != nil || method.Name != "approve" {
+		return "", ErrUnsupportedTx
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedTx, err)
+	}
+	if args[0].(common.Address) == c.VaultAddress {
+		return "approve", nil
+	}
+	return "", ErrUnsupportedTx
+}
@@ -0,0 +1,157 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"scos/contracts"
+	"scos/money"
+)
+
+// 合约调用统一通过 contracts 包中生成的绑定编码
+var vaultABI = mustABI(contracts.StockVaultMetaData)
+
+func mustABI(meta *bind.MetaData) *abi.ABI {
+	parsed, err := meta.GetAbi()
+	if err != nil {
+		log.Panicf("parse contract abi: %v", err)
+	}
+	return parsed
+}
+
+// transact 编码金库合约调用并通过该链的 txManager 发送
+func (bc *BlockchainClient) transact(ctx context.Context, chain, method string, args ...interface{}) (string, error) {
+	c, data, err := bc.packVault(chain, method, args...)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := c.tm.send(ctx, c.VaultAddress, data, big.NewInt(0))
+	if err != nil {
+		return "", err
+	}
+
+	return tx.Hash().Hex(), nil
+}
+
+func (bc *BlockchainClient) packVault(chain, method string, args ...interface{}) (*cli, []byte, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := vaultABI.Pack(method, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, data, nil
+}
+
+func (bc *BlockchainClient) Liquidate(ctx context.Context, chain, userAddr, tokenAddr string) (string, error) {
+	return bc.transact(ctx, chain, "liquidate", common.HexToAddress(userAddr), common.HexToAddress(tokenAddr))
+}
+
+// SetTokenPrice 把每个股票代币以 SCOS 计的价格写入金库，stakeStock 按它与抵押率限制可借数量。
+// 金库中的价格为每个代币最小单位对应的 SCOS 最小单位，放大 1e18
+func (bc *BlockchainClient) SetTokenPrice(ctx context.Context, chain, tokenAddr string, price money.Decimal) (string, error) {
+	tokenDecimals, err := bc.TokenDecimals(ctx, chain, tokenAddr)
+	if err != nil {
+		return "", err
+	}
+	scosDecimals, err := bc.SCOSDecimals(ctx, chain)
+	if err != nil {
+		return "", err
+	}
+	scale := 18 + int(scosDecimals) - int(tokenDecimals)
+	if scale < 0 {
+		return "", fmt.Errorf("token %s has %d decimals, too many for the vault price", tokenAddr, tokenDecimals)
+	}
+	return bc.transact(ctx, chain, "setPrice", common.HexToAddress(tokenAddr), price.ToUnits(uint8(scale), money.Down))
+}
+
+// TokenAddress 该链 tokens 配置中股票代码对应的代币地址
+func (bc *BlockchainClient) TokenAddress(chain, symbol string) (string, bool) {
+	c, err := bc.get(chain)
+	if err != nil {
+		return "", false
+	}
+	token, ok := c.info.Tokens[symbol]
+	return token, ok
+}
+
+// TokenSymbol 按 tokens 配置反查代币的股票代码，未配置时返回空
+func (bc *BlockchainClient) TokenSymbol(chain, tokenAddr string) string {
+	c, err := bc.get(chain)
+	if err != nil {
+		return ""
+	}
+	for symbol, token := range c.info.Tokens {
+		if strings.EqualFold(token, tokenAddr) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// Vault 返回绑定到该链金库地址的只读合约
+func (bc *BlockchainClient) Vault(chain string) (*contracts.StockVaultCaller, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+	return contracts.NewStockVaultCaller(c.VaultAddress, c.client)
+}
+
+// StockToken 返回绑定到指定股票代币地址的只读合约
+func (bc *BlockchainClient) StockToken(chain, tokenAddr string) (*contracts.StockTokenCaller, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+	return contracts.NewStockTokenCaller(common.HexToAddress(tokenAddr), c.client)
+}
+
+// SCOS 返回绑定到该链 SCOS 地址的只读合约
+func (bc *BlockchainClient) SCOS(chain string) (*contracts.SCOSCaller, error) {
+	c, err := bc.lookup(chain)
+	if err != nil {
+		return nil, err
+	}
+	return contracts.NewSCOSCaller(common.HexToAddress(c.info.SCOSAddress), c.client)
+}
+
+// GetStakeInfo 链上的质押信息，user 为调用 stakeStock 的地址（即用户钱包）
+func (bc *BlockchainClient) GetStakeInfo(ctx context.Context, chain, user, tokenAddr string) (contracts.StockVaultStakeInfo, error) {
+	vault, err := bc.Vault(chain)
+	if err != nil {
+		return contracts.StockVaultStakeInfo{}, err
+	}
+	info, err := vault.GetStakeInfo(&bind.CallOpts{Context: ctx}, common.HexToAddress(user), common.HexToAddress(tokenAddr))
+	return info, asRevert(err)
+}
+
+// IsSupportedToken 代币是否已被金库 addSupportedToken
+func (bc *BlockchainClient) IsSupportedToken(ctx context.Context, chain, tokenAddr string) (bool, error) {
+	vault, err := bc.Vault(chain)
+	if err != nil {
+		return false, err
+	}
+	ok, err := vault.SupportedTokens(&bind.CallOpts{Context: ctx}, common.HexToAddress(tokenAddr))
+	return ok, asRevert(err)
+}
+
+// VaultSCOSToken 金库记录的 SCOS 合约地址
+func (bc *BlockchainClient) VaultSCOSToken(ctx context.Context, chain string) (common.Address, error) {
+	vault, err := bc.Vault(chain)
+	if err != nil {
+		return common.Address{}, err
+	}
+	addr, err := vault.ScosToken(&bind.CallOpts{Context: ctx})
+	return addr, asRevert(err)
+}
@@ -0,0 +1,39 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"scos/contracts"
+)
+
+// VaultClient 处理用户质押、赎回与买卖请求所需的链与金库操作。仓位操作由用户钱包签名，这里只构造交易；
+// *BlockchainClient 为连接真实节点的实现，blockchain/fake 提供内存实现供测试使用
+type VaultClient interface {
+	HasChain(chain string) bool
+	CheckChain(chain string) error
+	ChainID(chain string) (*big.Int, error)
+	GetVaultAddr(chain string) (common.Address, error)
+
+	// 金额换算使用的链上精度
+	TokenDecimals(ctx context.Context, chain, tokenAddr string) (uint8, error)
+	SCOSDecimals(ctx context.Context, chain string) (uint8, error)
+
+	IsSupportedToken(ctx context.Context, chain, tokenAddr string) (bool, error)
+	GetStakeInfo(ctx context.Context, chain, user, tokenAddr string) (contracts.StockVaultStakeInfo, error)
+
+	// 以 from 身份预执行并构造由用户签名的 stakeStock / unstakeStock / withdrawStock / repayDebt
+	BuildStakeTx(ctx context.Context, chain, from, tokenAddr string, amount, scosAmount *big.Int) (*UnsignedTx, error)
+	BuildUnstakeTx(ctx context.Context, chain, from, tokenAddr string) (*UnsignedTx, error)
+	BuildWithdrawTx(ctx context.Context, chain, from, tokenAddr string, amount *big.Int) (*UnsignedTx, error)
+	BuildRepayTx(ctx context.Context, chain, from, tokenAddr string, scosAmount *big.Int) (*UnsignedTx, error)
+	Liquidate(ctx context.Context, chain, userAddr, tokenAddr string) (string, error)
+
+	// SCOS 由索引器在质押上链后铸造给用户，赎回与还款时金库销毁用户偿还的部分
+	SCOSBalance(ctx context.Context, chain, owner string) (*big.Int, error)
+	SCOSAllowance(ctx context.Context, chain, owner string) (*big.Int, error)
+}
+
+var _ VaultClient = (*BlockchainClient)(nil)
@@ -1,40 +1,449 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
 )
 
+const defaultConfigPath = "config.yaml"
+
 type Config struct {
-	Port       string
-	PrivateKey string
-	Chains     map[string]ChainInfo
+	Port       string                  `yaml:"port"`
+	AdminToken string                  `yaml:"admin_token"`
+	Signer     SignerConfig            `yaml:"signer"`  // 默认签名器
+	Signers    map[string]SignerConfig `yaml:"signers"` // 具名签名器，供各链引用及密钥轮换
+	DB         DBConfig                `yaml:"db"`
+	Risk       RiskConfig              `yaml:"risk"`
+	Watcher    WatcherConfig           `yaml:"watcher"`
+	Indexer    IndexerConfig           `yaml:"indexer"`
+	Health     HealthConfig            `yaml:"health"`
+	Timeouts   TimeoutConfig           `yaml:"timeouts"`
+	Chains     map[string]ChainInfo    `yaml:"chains"`
+
+	envErrs []string
+}
+
+// SignerConfig 运营账户签名方式：
+//   - keystore: go-ethereum 加密 keystore 文件，密码来自 password_file 或 password_env
+//   - env:      私钥十六进制放在环境变量 env 中
+//   - file:     私钥十六进制放在挂载文件 path 中
+//   - remote:   通过 HTTP 调用远程签名服务（eth_signTransaction），签名账户为 address
+type SignerConfig struct {
+	Type         string `yaml:"type"`
+	Path         string `yaml:"path"`
+	PasswordFile string `yaml:"password_file"`
+	PasswordEnv  string `yaml:"password_env"`
+	Env          string `yaml:"env"`
+	URL          string `yaml:"url"`
+	Address      string `yaml:"address"`
+}
+
+type DBConfig struct {
+	Path string `yaml:"path"`
+}
+
+type RiskConfig struct {
+	CollateralRatio      float64       `yaml:"collateral_ratio"`      // 可借贷比例，1.4 即 140% 抵押率
+	LiquidationThreshold float64       `yaml:"liquidation_threshold"` // 健康度（抵押品价值 / (债务 x 抵押率)）低于 1 减该值时触发清算
+	MonitorInterval      time.Duration `yaml:"monitor_interval"`
+}
+
+// WatcherConfig 交易回执轮询
+type WatcherConfig struct {
+	Interval    time.Duration `yaml:"interval"`
+	DropTimeout time.Duration `yaml:"drop_timeout"` // 节点查不到交易且超过该时间视为被丢弃
+}
+
+// IndexerConfig 金库事件索引
+type IndexerConfig struct {
+	Interval  time.Duration `yaml:"interval"`
+	BatchSize uint64        `yaml:"batch_size"` // 单次 eth_getLogs 查询的区块数
+}
+
+// TimeoutConfig 单次操作的超时。操作同时随请求上下文取消：客户端断开或服务关闭时中止进行中的 RPC
+type TimeoutConfig struct {
+	Read     time.Duration `yaml:"read"`     // 链上只读查询
+	Write    time.Duration `yaml:"write"`    // 发送交易：预执行、估算 gas、签名与广播
+	Shutdown time.Duration `yaml:"shutdown"` // 关闭时等待进行中请求与后台任务退出的最长时间
+}
+
+// HealthConfig RPC 节点健康探测：落后最高区块超过 max_block_lag 或响应慢于 max_latency 视为不健康。
+// 不可用的节点从 reconnect_min 开始按倍数退避重连，最长间隔 reconnect_max
+type HealthConfig struct {
+	Interval     time.Duration `yaml:"interval"`
+	MaxBlockLag  uint64        `yaml:"max_block_lag"`
+	MaxLatency   time.Duration `yaml:"max_latency"`
+	ReconnectMin time.Duration `yaml:"reconnect_min"`
+	ReconnectMax time.Duration `yaml:"reconnect_max"`
 }
 
 type ChainInfo struct {
-	RPC          string
-	ChainID      string
-	SCOSAddress  string
-	VaultAddress string
+	RPC          string    `yaml:"rpc"`
+	RPCs         []string  `yaml:"rpcs"` // 备用节点，与 rpc 一起按顺序作为优先级
+	ChainID      string    `yaml:"chain_id"`
+	SCOSAddress  string    `yaml:"scos_address"`
+	VaultAddress string    `yaml:"vault_address"`
+	Signer       string    `yaml:"signer"`      // signers 中的名称，为空时使用默认签名器
+	SCOSSigner   string    `yaml:"scos_signer"` // SCOS 合约 owner 的签名器，用于 mint；为空时与 signer 相同
+	Fees         FeeConfig `yaml:"fees"`
+	// 交易回执与金库事件需要的确认数，0 视为 1；索引器只处理达到确认数的区块
+	Confirmations uint64 `yaml:"confirmations"`
+	// 金库合约部署区块，索引器从这里开始扫描
+	StartBlock uint64 `yaml:"start_block"`
+	// Multicall3 合约地址，为空时检测标准地址 0xcA11...CA11；都没有时批量读取退回并发单独调用
+	MulticallAddress string `yaml:"multicall_address"`
+	// 股票代码 => 该链上的股票代币地址。价格更新时同步到金库，索引器据此回填质押的股票代码
+	Tokens map[string]string `yaml:"tokens"`
+}
+
+// FeeConfig 交易费用策略
+type FeeConfig struct {
+	Mode       string  `yaml:"mode"`         // auto（默认，按最新区块是否有 baseFee 判断）/ dynamic / legacy
+	Multiplier float64 `yaml:"multiplier"`   // 节点建议费用的放大倍数，0 视为 1
+	MaxFeeGwei float64 `yaml:"max_fee_gwei"` // maxFeePerGas（legacy 为 gasPrice）上限，0 表示不限制
+	MaxTipGwei float64 `yaml:"max_tip_gwei"` // maxPriorityFeePerGas 上限，0 表示不限制
+
+	// 交易超过 stuck_timeout 未上链时以同一 nonce 加价 bump_percent 重发，直到 max_fee_gwei；为 0 时不自动替换
+	StuckTimeout time.Duration `yaml:"stuck_timeout"`
+	BumpPercent  uint64        `yaml:"bump_percent"`
+}
+
+const DefaultSigner = "default"
+
+// SignerConfig 返回指定名称的签名器配置，空名称或 "default" 对应默认签名器
+func (c *Config) SignerConfig(name string) (SignerConfig, bool) {
+	if name == "" || name == DefaultSigner {
+		return c.Signer, true
+	}
+	sc, ok := c.Signers[name]
+	return sc, ok
+}
+
+// LoadConfig 读取配置文件（SCOS_CONFIG，默认 config.yaml），再用环境变量覆盖，最后统一校验
+func LoadConfig() (*Config, error) {
+	cfg := defaultConfig()
+
+	path, explicit := os.LookupEnv("SCOS_CONFIG")
+	if !explicit {
+		path = defaultConfigPath
+	}
+	if err := cfg.loadFile(path); err != nil {
+		if explicit || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	cfg.applyEnv()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
-func LoadConfig() *Config {
+func defaultConfig() *Config {
 	return &Config{
-		Port:       getEnv("PORT", "8080"),
-		PrivateKey: "01c7939dc6827ee10bb7d26f420618c4af88c0029aa70be202f1ca7f29fe5bb4", // 写死私钥
-		Chains: map[string]ChainInfo{
-			"Reddio": {
-				RPC:          "https://reddio-dev.reddio.com/",
-				ChainID:      "50341",
-				SCOSAddress:  "0xeB5e9Af4b798ec27A0f24DA22C7A7b3b657D05d9",
-				VaultAddress: "0x0124e835BdE149aD885b765Bb8BF6f63735Fc4db",
-			},
-			"Scroll": {
-				RPC:         "https://sepolia-rpc.scroll.io",
-				ChainID:     "534351",
-				SCOSAddress: "",
-			},
+		Port: "8080",
+		Signer: SignerConfig{
+			Type: "env",
+			Env:  "SCOS_PRIVATE_KEY",
+		},
+		DB: DBConfig{
+			Path: "scos.db",
+		},
+		Risk: RiskConfig{
+			CollateralRatio:      1.4,
+			LiquidationThreshold: 0.25,
+			MonitorInterval:      time.Minute,
 		},
+		Watcher: WatcherConfig{
+			Interval:    15 * time.Second,
+			DropTimeout: 30 * time.Minute,
+		},
+		Indexer: IndexerConfig{
+			Interval:  15 * time.Second,
+			BatchSize: 2000,
+		},
+		Health: HealthConfig{
+			Interval:     15 * time.Second,
+			MaxBlockLag:  5,
+			MaxLatency:   3 * time.Second,
+			ReconnectMin: 2 * time.Second,
+			ReconnectMax: time.Minute,
+		},
+		Timeouts: TimeoutConfig{
+			Read:     10 * time.Second,
+			Write:    30 * time.Second,
+			Shutdown: 15 * time.Second,
+		},
+		Signers: map[string]SignerConfig{},
+		Chains:  map[string]ChainInfo{},
+	}
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv 环境变量优先级高于配置文件
+// 链相关变量按 SCOS_<CHAIN>_<FIELD> 命名，例如 SCOS_REDDIO_VAULT_ADDRESS
+func (c *Config) applyEnv() {
+	c.Port = getEnv("PORT", c.Port)
+	c.AdminToken = getEnv("SCOS_ADMIN_TOKEN", c.AdminToken)
+	c.Signer.Type = getEnv("SCOS_SIGNER_TYPE", c.Signer.Type)
+	c.Signer.Path = getEnv("SCOS_SIGNER_PATH", c.Signer.Path)
+	c.Signer.URL = getEnv("SCOS_SIGNER_URL", c.Signer.URL)
+	c.Signer.Address = getEnv("SCOS_SIGNER_ADDRESS", c.Signer.Address)
+	c.DB.Path = getEnv("SCOS_DB_PATH", c.DB.Path)
+
+	if v := os.Getenv("SCOS_COLLATERAL_RATIO"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.envErrs = append(c.envErrs, fmt.Sprintf("SCOS_COLLATERAL_RATIO: %v", err))
+		} else {
+			c.Risk.CollateralRatio = f
+		}
+	}
+	if v := os.Getenv("SCOS_LIQUIDATION_THRESHOLD"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.envErrs = append(c.envErrs, fmt.Sprintf("SCOS_LIQUIDATION_THRESHOLD: %v", err))
+		} else {
+			c.Risk.LiquidationThreshold = f
+		}
 	}
+	if v := os.Getenv("SCOS_MONITOR_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			c.envErrs = append(c.envErrs, fmt.Sprintf("SCOS_MONITOR_INTERVAL: %v", err))
+		} else {
+			c.Risk.MonitorInterval = d
+		}
+	}
+
+	for name, chain := range c.Chains {
+		prefix := "SCOS_" + envName(name) + "_"
+		chain.RPC = getEnv(prefix+"RPC", chain.RPC)
+		if v := os.Getenv(prefix + "RPCS"); v != "" {
+			chain.RPCs = strings.Split(v, ",")
+		}
+		chain.ChainID = getEnv(prefix+"CHAIN_ID", chain.ChainID)
+		chain.SCOSAddress = getEnv(prefix+"SCOS_ADDRESS", chain.SCOSAddress)
+		chain.VaultAddress = getEnv(prefix+"VAULT_ADDRESS", chain.VaultAddress)
+		chain.Signer = getEnv(prefix+"SIGNER", chain.Signer)
+		chain.SCOSSigner = getEnv(prefix+"SCOS_SIGNER", chain.SCOSSigner)
+		c.Chains[name] = chain
+	}
+}
+
+// Validate 汇总所有配置错误，一次性返回
+func (c *Config) Validate() error {
+	errs := append([]string(nil), c.envErrs...)
+
+	if _, err := strconv.ParseUint(c.Port, 10, 16); err != nil {
+		errs = append(errs, fmt.Sprintf("port %q is not a valid port number", c.Port))
+	}
+	errs = append(errs, c.Signer.validate("signer")...)
+	signerNames := make([]string, 0, len(c.Signers))
+	for name := range c.Signers {
+		signerNames = append(signerNames, name)
+	}
+	sort.Strings(signerNames)
+	for _, name := range signerNames {
+		if name == DefaultSigner {
+			errs = append(errs, fmt.Sprintf("signers: name %q is reserved for the top-level signer", name))
+			continue
+		}
+		errs = append(errs, c.Signers[name].validate("signers."+name)...)
+	}
+	if c.DB.Path == "" {
+		errs = append(errs, "db.path is required")
+	}
+	if c.Risk.CollateralRatio <= 1 {
+		errs = append(errs, fmt.Sprintf("risk.collateral_ratio must be greater than 1, got %v", c.Risk.CollateralRatio))
+	}
+	if c.Risk.LiquidationThreshold <= 0 || c.Risk.LiquidationThreshold >= 1 {
+		errs = append(errs, fmt.Sprintf("risk.liquidation_threshold must be between 0 and 1, got %v", c.Risk.LiquidationThreshold))
+	}
+	if c.Risk.MonitorInterval <= 0 {
+		errs = append(errs, "risk.monitor_interval must be positive")
+	}
+	if c.Watcher.Interval <= 0 || c.Watcher.DropTimeout <= 0 {
+		errs = append(errs, "watcher.interval and watcher.drop_timeout must be positive")
+	}
+	if c.Indexer.Interval <= 0 || c.Indexer.BatchSize == 0 {
+		errs = append(errs, "indexer.interval and indexer.batch_size must be positive")
+	}
+
+	if c.Health.Interval <= 0 || c.Health.MaxLatency <= 0 {
+		errs = append(errs, "health.interval and health.max_latency must be positive")
+	}
+	if c.Health.ReconnectMin <= 0 || c.Health.ReconnectMax < c.Health.ReconnectMin {
+		errs = append(errs, "health.reconnect_min must be positive and not greater than health.reconnect_max")
+	}
+	if c.Timeouts.Read <= 0 || c.Timeouts.Write <= 0 || c.Timeouts.Shutdown <= 0 {
+		errs = append(errs, "timeouts.read, timeouts.write and timeouts.shutdown must be positive")
+	}
+
+	if len(c.Chains) == 0 {
+		errs = append(errs, "no chains configured")
+	}
+	names := make([]string, 0, len(c.Chains))
+	for name := range c.Chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, c.chainErrors(name, c.Chains[name])...)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// ValidateChain 校验运行时新增的链配置，规则与启动时相同
+func (c *Config) ValidateChain(name string, ci ChainInfo) error {
+	if errs := c.chainErrors(name, ci); len(errs) > 0 {
+		return fmt.Errorf("invalid chain:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+func (c *Config) chainErrors(name string, ci ChainInfo) []string {
+	errs := ci.validate(name)
+	if _, ok := c.SignerConfig(ci.Signer); !ok {
+		errs = append(errs, fmt.Sprintf("chain %s: signer %q is not defined in signers", name, ci.Signer))
+	}
+	if _, ok := c.SignerConfig(ci.SCOSSigner); !ok {
+		errs = append(errs, fmt.Sprintf("chain %s: scos_signer %q is not defined in signers", name, ci.SCOSSigner))
+	}
+	return errs
+}
+
+func (ci ChainInfo) validate(name string) []string {
+	var errs []string
+	if len(ci.Endpoints()) == 0 {
+		errs = append(errs, fmt.Sprintf("chain %s: rpc or rpcs is required", name))
+	}
+	if _, ok := new(big.Int).SetString(ci.ChainID, 10); !ok {
+		errs = append(errs, fmt.Sprintf("chain %s: chain_id %q is not a decimal number", name, ci.ChainID))
+	}
+	if err := checkAddress(ci.VaultAddress); err != nil {
+		errs = append(errs, fmt.Sprintf("chain %s: vault_address %v", name, err))
+	}
+	if err := checkAddress(ci.SCOSAddress); err != nil {
+		errs = append(errs, fmt.Sprintf("chain %s: scos_address %v", name, err))
+	}
+	if ci.MulticallAddress != "" {
+		if err := checkAddress(ci.MulticallAddress); err != nil {
+			errs = append(errs, fmt.Sprintf("chain %s: multicall_address %v", name, err))
+		}
+	}
+	symbols := make([]string, 0, len(ci.Tokens))
+	for symbol := range ci.Tokens {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		if err := checkAddress(ci.Tokens[symbol]); err != nil {
+			errs = append(errs, fmt.Sprintf("chain %s: tokens.%s %v", name, symbol, err))
+		}
+	}
+	switch ci.Fees.Mode {
+	case "", "auto", "dynamic", "legacy":
+	default:
+		errs = append(errs, fmt.Sprintf("chain %s: fees.mode %q must be auto, dynamic or legacy", name, ci.Fees.Mode))
+	}
+	if ci.Fees.Multiplier < 0 || ci.Fees.MaxFeeGwei < 0 || ci.Fees.MaxTipGwei < 0 || ci.Fees.StuckTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("chain %s: fees values must not be negative", name))
+	}
+	if ci.Fees.StuckTimeout > 0 && ci.Fees.MaxFeeGwei == 0 {
+		errs = append(errs, fmt.Sprintf("chain %s: fees.max_fee_gwei is required as the replacement ceiling when fees.stuck_timeout is set", name))
+	}
+	return errs
+}
+
+// Endpoints 返回去重后的 RPC 列表，rpc 在前
+func (ci ChainInfo) Endpoints() []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range append([]string{ci.RPC}, ci.RPCs...) {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// validate 只检查字段是否齐全，密钥内容在创建签名器时读取
+func (sc SignerConfig) validate(name string) []string {
+	var errs []string
+	switch sc.Type {
+	case "keystore":
+		if sc.Path == "" {
+			errs = append(errs, fmt.Sprintf("%s: keystore signer requires path", name))
+		}
+		if sc.PasswordFile == "" && sc.PasswordEnv == "" {
+			errs = append(errs, fmt.Sprintf("%s: keystore signer requires password_file or password_env", name))
+		}
+	case "env":
+		if sc.Env == "" {
+			errs = append(errs, fmt.Sprintf("%s: env signer requires env", name))
+		}
+	case "file":
+		if sc.Path == "" {
+			errs = append(errs, fmt.Sprintf("%s: file signer requires path", name))
+		}
+	case "remote":
+		if sc.URL == "" {
+			errs = append(errs, fmt.Sprintf("%s: remote signer requires url", name))
+		}
+		if err := checkAddress(sc.Address); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: address %v", name, err))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("%s: unknown signer type %q", name, sc.Type))
+	}
+	return errs
+}
+
+func checkAddress(addr string) error {
+	if addr == "" {
+		return errors.New("is missing")
+	}
+	if !common.IsHexAddress(addr) {
+		return fmt.Errorf("%q is not a valid address", addr)
+	}
+	// 大小写混合时按 EIP-55 校验
+	hex := strings.TrimPrefix(addr, "0x")
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && common.HexToAddress(hex).Hex() != "0x"+hex {
+		return fmt.Errorf("%q has an invalid checksum", addr)
+	}
+	return nil
+}
+
+func envName(chain string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(chain))
 }
 
 func getEnv(key, defaultValue string) string {
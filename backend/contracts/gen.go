@@ -0,0 +1,4 @@
+// Package contracts 由 contracts/*.sol 生成的 StockVault、SCOS 与股票代币绑定，生成的文件不要手动修改
+package contracts
+
+//go:generate sh gen.sh
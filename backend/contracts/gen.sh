@@ -0,0 +1,33 @@
+#!/bin/sh
+# 从 contracts/*.sol 重新生成 Go 绑定（go generate ./contracts）。
+# 安装了 solc 时先编译合约，刷新 abi/ 与 build/（部署用字节码）；否则直接使用仓库中已提交的 abi/*.abi。
+# build/*.bin 会嵌入绑定，端到端测试据此部署合约，两者都需要提交。
+# Multicall3 是各链预部署的第三方合约，只提交 abi
+set -e
+cd "$(dirname "$0")"
+SRC=../../contracts
+
+if command -v solc >/dev/null 2>&1; then
+	out=$(mktemp -d)
+	solc --abi --bin --optimize --overwrite -o "$out" \
+		--base-path "$SRC/.." --include-path "$SRC/../node_modules" \
+		"$SRC/StockVault.sol" "$SRC/SCOS.sol" "$SRC/AppleStock.sol"
+	mkdir -p build
+	# 股票代币共用 Apple 合约的接口
+	for pair in StockVault:StockVault SCOS:SCOS Apple:StockToken; do
+		src=${pair%%:*}
+		dst=${pair##*:}
+		cp "$out/$src.abi" "abi/$dst.abi"
+		cp "$out/$src.bin" "build/$dst.bin"
+	done
+	rm -rf "$out"
+fi
+
+for name in StockVault SCOS StockToken Multicall3; do
+	bin=""
+	if [ -f "build/$name.bin" ]; then
+		bin="--bin build/$name.bin"
+	fi
+	go tool abigen --abi "abi/$name.abi" $bin --pkg contracts --type "$name" \
+		--out "$(echo "$name" | tr 'A-Z' 'a-z').go"
+done
@@ -0,0 +1,1752 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contracts
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// StockVaultStakeInfo is an auto generated low-level Go binding around an user-defined struct.
+type StockVaultStakeInfo struct {
+	Amount       *big.Int
+	BorrowedSCOS *big.Int
+	Timestamp    *big.Int
+	Active       bool
+}
+
+// StockVaultMetaData contains all meta data concerning the StockVault contract.
+var StockVaultMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_scosToken\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"_treasury\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_collateralRatio\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"uint256\",\"name\":\"scosAmount\",\"type\":\"uint256\",\"indexed\":false},{\"internalType\":\"uint256\",\"name\":\"remainingDebt\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"DebtRepaid\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"Liquidated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\",\"indexed\":true}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"uint256\",\"name\":\"price\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"PriceUpdated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\",\"indexed\":false},{\"internalType\":\"uint256\",\"name\":\"borrowedSCOS\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"StockStaked\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"StockUnstaked\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\",\"indexed\":true},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\",\"indexed\":false},{\"internalType\":\"uint256\",\"name\":\"remaining\",\"type\":\"uint256\",\"indexed\":false}],\"name\":\"StockWithdrawn\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"}],\"name\":\"addSupportedToken\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"collateralRatio\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"}],\"name\":\"getStakeInfo\",\"outputs\":[{\"internalType\":\"structStockVault.StakeInfo\",\"name\":\"\",\"type\":\"tuple\",\"components\":[{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"borrowedSCOS\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}]}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"}],\"name\":\"liquidate\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"maxBorrow\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"prices\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"scosAmount\",\"type\":\"uint256\"}],\"name\":\"repayDebt\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"scosToken\",\"outputs\":[{\"internalType\":\"contractIERC20\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"price\",\"type\":\"uint256\"}],\"name\":\"setPrice\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"scosAmount\",\"type\":\"uint256\"}],\"name\":\"stakeStock\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"supportedTokens\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"treasury\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"}],\"name\":\"unstakeStock\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"userStakes\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"borrowedSCOS\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"timestamp\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"withdrawStock\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+}
+
+// StockVaultABI is the input ABI used to generate the binding from.
+// Deprecated: Use StockVaultMetaData.ABI instead.
+var StockVaultABI = StockVaultMetaData.ABI
+
+// StockVault is an auto generated Go binding around an Ethereum contract.
+type StockVault struct {
+	StockVaultCaller     // Read-only binding to the contract
+	StockVaultTransactor // Write-only binding to the contract
+	StockVaultFilterer   // Log filterer for contract events
+}
+
+// StockVaultCaller is an auto generated read-only Go binding around an Ethereum contract.
+type StockVaultCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// StockVaultTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type StockVaultTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// StockVaultFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type StockVaultFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// StockVaultSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type StockVaultSession struct {
+	Contract     *StockVault       // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// StockVaultCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type StockVaultCallerSession struct {
+	Contract *StockVaultCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts     // Call options to use throughout this session
+}
+
+// StockVaultTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type StockVaultTransactorSession struct {
+	Contract     *StockVaultTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts     // Transaction auth options to use throughout this session
+}
+
+// StockVaultRaw is an auto generated low-level Go binding around an Ethereum contract.
+type StockVaultRaw struct {
+	Contract *StockVault // Generic contract binding to access the raw methods on
+}
+
+// StockVaultCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type StockVaultCallerRaw struct {
+	Contract *StockVaultCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// StockVaultTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type StockVaultTransactorRaw struct {
+	Contract *StockVaultTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewStockVault creates a new instance of StockVault, bound to a specific deployed contract.
+func NewStockVault(address common.Address, backend bind.ContractBackend) (*StockVault, error) {
+	contract, err := bindStockVault(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVault{StockVaultCaller: StockVaultCaller{contract: contract}, StockVaultTransactor: StockVaultTransactor{contract: contract}, StockVaultFilterer: StockVaultFilterer{contract: contract}}, nil
+}
+
+// NewStockVaultCaller creates a new read-only instance of StockVault, bound to a specific deployed contract.
+func NewStockVaultCaller(address common.Address, caller bind.ContractCaller) (*StockVaultCaller, error) {
+	contract, err := bindStockVault(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultCaller{contract: contract}, nil
+}
+
+// NewStockVaultTransactor creates a new write-only instance of StockVault, bound to a specific deployed contract.
+func NewStockVaultTransactor(address common.Address, transactor bind.ContractTransactor) (*StockVaultTransactor, error) {
+	contract, err := bindStockVault(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultTransactor{contract: contract}, nil
+}
+
+// NewStockVaultFilterer creates a new log filterer instance of StockVault, bound to a specific deployed contract.
+func NewStockVaultFilterer(address common.Address, filterer bind.ContractFilterer) (*StockVaultFilterer, error) {
+	contract, err := bindStockVault(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultFilterer{contract: contract}, nil
+}
+
+// bindStockVault binds a generic wrapper to an already deployed contract.
+func bindStockVault(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := StockVaultMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_StockVault *StockVaultRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _StockVault.Contract.StockVaultCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_StockVault *StockVaultRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _StockVault.Contract.StockVaultTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_StockVault *StockVaultRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _StockVault.Contract.StockVaultTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_StockVault *StockVaultCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _StockVault.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_StockVault *StockVaultTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _StockVault.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_StockVault *StockVaultTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _StockVault.Contract.contract.Transact(opts, method, params...)
+}
+
+// CollateralRatio is a free data retrieval call binding the contract method 0xb4eae1cb.
+//
+// Solidity: function collateralRatio() view returns(uint256)
+func (_StockVault *StockVaultCaller) CollateralRatio(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "collateralRatio")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// CollateralRatio is a free data retrieval call binding the contract method 0xb4eae1cb.
+//
+// Solidity: function collateralRatio() view returns(uint256)
+func (_StockVault *StockVaultSession) CollateralRatio() (*big.Int, error) {
+	return _StockVault.Contract.CollateralRatio(&_StockVault.CallOpts)
+}
+
+// CollateralRatio is a free data retrieval call binding the contract method 0xb4eae1cb.
+//
+// Solidity: function collateralRatio() view returns(uint256)
+func (_StockVault *StockVaultCallerSession) CollateralRatio() (*big.Int, error) {
+	return _StockVault.Contract.CollateralRatio(&_StockVault.CallOpts)
+}
+
+// GetStakeInfo is a free data retrieval call binding the contract method 0xd77c8f14.
+//
+// Solidity: function getStakeInfo(address user, address token) view returns((uint256,uint256,uint256,bool))
+func (_StockVault *StockVaultCaller) GetStakeInfo(opts *bind.CallOpts, user common.Address, token common.Address) (StockVaultStakeInfo, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "getStakeInfo", user, token)
+
+	if err != nil {
+		return *new(StockVaultStakeInfo), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(StockVaultStakeInfo)).(*StockVaultStakeInfo)
+
+	return out0, err
+
+}
+
+// GetStakeInfo is a free data retrieval call binding the contract method 0xd77c8f14.
+//
+// Solidity: function getStakeInfo(address user, address token) view returns((uint256,uint256,uint256,bool))
+func (_StockVault *StockVaultSession) GetStakeInfo(user common.Address, token common.Address) (StockVaultStakeInfo, error) {
+	return _StockVault.Contract.GetStakeInfo(&_StockVault.CallOpts, user, token)
+}
+
+// GetStakeInfo is a free data retrieval call binding the contract method 0xd77c8f14.
+//
+// Solidity: function getStakeInfo(address user, address token) view returns((uint256,uint256,uint256,bool))
+func (_StockVault *StockVaultCallerSession) GetStakeInfo(user common.Address, token common.Address) (StockVaultStakeInfo, error) {
+	return _StockVault.Contract.GetStakeInfo(&_StockVault.CallOpts, user, token)
+}
+
+// MaxBorrow is a free data retrieval call binding the contract method 0xeaf0ddcf.
+//
+// Solidity: function maxBorrow(address token, uint256 amount) view returns(uint256)
+func (_StockVault *StockVaultCaller) MaxBorrow(opts *bind.CallOpts, token common.Address, amount *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "maxBorrow", token, amount)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// MaxBorrow is a free data retrieval call binding the contract method 0xeaf0ddcf.
+//
+// Solidity: function maxBorrow(address token, uint256 amount) view returns(uint256)
+func (_StockVault *StockVaultSession) MaxBorrow(token common.Address, amount *big.Int) (*big.Int, error) {
+	return _StockVault.Contract.MaxBorrow(&_StockVault.CallOpts, token, amount)
+}
+
+// MaxBorrow is a free data retrieval call binding the contract method 0xeaf0ddcf.
+//
+// Solidity: function maxBorrow(address token, uint256 amount) view returns(uint256)
+func (_StockVault *StockVaultCallerSession) MaxBorrow(token common.Address, amount *big.Int) (*big.Int, error) {
+	return _StockVault.Contract.MaxBorrow(&_StockVault.CallOpts, token, amount)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_StockVault *StockVaultCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_StockVault *StockVaultSession) Owner() (common.Address, error) {
+	return _StockVault.Contract.Owner(&_StockVault.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_StockVault *StockVaultCallerSession) Owner() (common.Address, error) {
+	return _StockVault.Contract.Owner(&_StockVault.CallOpts)
+}
+
+// Prices is a free data retrieval call binding the contract method 0xcfed246b.
+//
+// Solidity: function prices(address ) view returns(uint256)
+func (_StockVault *StockVaultCaller) Prices(opts *bind.CallOpts, arg0 common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "prices", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Prices is a free data retrieval call binding the contract method 0xcfed246b.
+//
+// Solidity: function prices(address ) view returns(uint256)
+func (_StockVault *StockVaultSession) Prices(arg0 common.Address) (*big.Int, error) {
+	return _StockVault.Contract.Prices(&_StockVault.CallOpts, arg0)
+}
+
+// Prices is a free data retrieval call binding the contract method 0xcfed246b.
+//
+// Solidity: function prices(address ) view returns(uint256)
+func (_StockVault *StockVaultCallerSession) Prices(arg0 common.Address) (*big.Int, error) {
+	return _StockVault.Contract.Prices(&_StockVault.CallOpts, arg0)
+}
+
+// ScosToken is a free data retrieval call binding the contract method 0xd1fce563.
+//
+// Solidity: function scosToken() view returns(address)
+func (_StockVault *StockVaultCaller) ScosToken(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "scosToken")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// ScosToken is a free data retrieval call binding the contract method 0xd1fce563.
+//
+// Solidity: function scosToken() view returns(address)
+func (_StockVault *StockVaultSession) ScosToken() (common.Address, error) {
+	return _StockVault.Contract.ScosToken(&_StockVault.CallOpts)
+}
+
+// ScosToken is a free data retrieval call binding the contract method 0xd1fce563.
+//
+// Solidity: function scosToken() view returns(address)
+func (_StockVault *StockVaultCallerSession) ScosToken() (common.Address, error) {
+	return _StockVault.Contract.ScosToken(&_StockVault.CallOpts)
+}
+
+// SupportedTokens is a free data retrieval call binding the contract method 0x68c4ac26.
+//
+// Solidity: function supportedTokens(address ) view returns(bool)
+func (_StockVault *StockVaultCaller) SupportedTokens(opts *bind.CallOpts, arg0 common.Address) (bool, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "supportedTokens", arg0)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// SupportedTokens is a free data retrieval call binding the contract method 0x68c4ac26.
+//
+// Solidity: function supportedTokens(address ) view returns(bool)
+func (_StockVault *StockVaultSession) SupportedTokens(arg0 common.Address) (bool, error) {
+	return _StockVault.Contract.SupportedTokens(&_StockVault.CallOpts, arg0)
+}
+
+// SupportedTokens is a free data retrieval call binding the contract method 0x68c4ac26.
+//
+// Solidity: function supportedTokens(address ) view returns(bool)
+func (_StockVault *StockVaultCallerSession) SupportedTokens(arg0 common.Address) (bool, error) {
+	return _StockVault.Contract.SupportedTokens(&_StockVault.CallOpts, arg0)
+}
+
+// Treasury is a free data retrieval call binding the contract method 0x61d027b3.
+//
+// Solidity: function treasury() view returns(address)
+func (_StockVault *StockVaultCaller) Treasury(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "treasury")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Treasury is a free data retrieval call binding the contract method 0x61d027b3.
+//
+// Solidity: function treasury() view returns(address)
+func (_StockVault *StockVaultSession) Treasury() (common.Address, error) {
+	return _StockVault.Contract.Treasury(&_StockVault.CallOpts)
+}
+
+// Treasury is a free data retrieval call binding the contract method 0x61d027b3.
+//
+// Solidity: function treasury() view returns(address)
+func (_StockVault *StockVaultCallerSession) Treasury() (common.Address, error) {
+	return _StockVault.Contract.Treasury(&_StockVault.CallOpts)
+}
+
+// UserStakes is a free data retrieval call binding the contract method 0x862d2d7c.
+//
+// Solidity: function userStakes(address , address ) view returns(uint256 amount, uint256 borrowedSCOS, uint256 timestamp, bool active)
+func (_StockVault *StockVaultCaller) UserStakes(opts *bind.CallOpts, arg0 common.Address, arg1 common.Address) (struct {
+	Amount       *big.Int
+	BorrowedSCOS *big.Int
+	Timestamp    *big.Int
+	Active       bool
+}, error) {
+	var out []interface{}
+	err := _StockVault.contract.Call(opts, &out, "userStakes", arg0, arg1)
+
+	outstruct := new(struct {
+		Amount       *big.Int
+		BorrowedSCOS *big.Int
+		Timestamp    *big.Int
+		Active       bool
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Amount = *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+	outstruct.BorrowedSCOS = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	outstruct.Timestamp = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	outstruct.Active = *abi.ConvertType(out[3], new(bool)).(*bool)
+
+	return *outstruct, err
+
+}
+
+// UserStakes is a free data retrieval call binding the contract method 0x862d2d7c.
+//
+// Solidity: function userStakes(address , address ) view returns(uint256 amount, uint256 borrowedSCOS, uint256 timestamp, bool active)
+func (_StockVault *StockVaultSession) UserStakes(arg0 common.Address, arg1 common.Address) (struct {
+	Amount       *big.Int
+	BorrowedSCOS *big.Int
+	Timestamp    *big.Int
+	Active       bool
+}, error) {
+	return _StockVault.Contract.UserStakes(&_StockVault.CallOpts, arg0, arg1)
+}
+
+// UserStakes is a free data retrieval call binding the contract method 0x862d2d7c.
+//
+// Solidity: function userStakes(address , address ) view returns(uint256 amount, uint256 borrowedSCOS, uint256 timestamp, bool active)
+func (_StockVault *StockVaultCallerSession) UserStakes(arg0 common.Address, arg1 common.Address) (struct {
+	Amount       *big.Int
+	BorrowedSCOS *big.Int
+	Timestamp    *big.Int
+	Active       bool
+}, error) {
+	return _StockVault.Contract.UserStakes(&_StockVault.CallOpts, arg0, arg1)
+}
+
+// AddSupportedToken is a paid mutator transaction binding the contract method 0x6d69fcaf.
+//
+// Solidity: function addSupportedToken(address token) returns()
+func (_StockVault *StockVaultTransactor) AddSupportedToken(opts *bind.TransactOpts, token common.Address) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "addSupportedToken", token)
+}
+
+// AddSupportedToken is a paid mutator transaction binding the contract method 0x6d69fcaf.
+//
+// Solidity: function addSupportedToken(address token) returns()
+func (_StockVault *StockVaultSession) AddSupportedToken(token common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.AddSupportedToken(&_StockVault.TransactOpts, token)
+}
+
+// AddSupportedToken is a paid mutator transaction binding the contract method 0x6d69fcaf.
+//
+// Solidity: function addSupportedToken(address token) returns()
+func (_StockVault *StockVaultTransactorSession) AddSupportedToken(token common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.AddSupportedToken(&_StockVault.TransactOpts, token)
+}
+
+// Liquidate is a paid mutator transaction binding the contract method 0x86b9d81f.
+//
+// Solidity: function liquidate(address user, address token) returns()
+func (_StockVault *StockVaultTransactor) Liquidate(opts *bind.TransactOpts, user common.Address, token common.Address) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "liquidate", user, token)
+}
+
+// Liquidate is a paid mutator transaction binding the contract method 0x86b9d81f.
+//
+// Solidity: function liquidate(address user, address token) returns()
+func (_StockVault *StockVaultSession) Liquidate(user common.Address, token common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.Liquidate(&_StockVault.TransactOpts, user, token)
+}
+
+// Liquidate is a paid mutator transaction binding the contract method 0x86b9d81f.
+//
+// Solidity: function liquidate(address user, address token) returns()
+func (_StockVault *StockVaultTransactorSession) Liquidate(user common.Address, token common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.Liquidate(&_StockVault.TransactOpts, user, token)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_StockVault *StockVaultTransactor) RenounceOwnership(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "renounceOwnership")
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_StockVault *StockVaultSession) RenounceOwnership() (*types.Transaction, error) {
+	return _StockVault.Contract.RenounceOwnership(&_StockVault.TransactOpts)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_StockVault *StockVaultTransactorSession) RenounceOwnership() (*types.Transaction, error) {
+	return _StockVault.Contract.RenounceOwnership(&_StockVault.TransactOpts)
+}
+
+// RepayDebt is a paid mutator transaction binding the contract method 0x79e20bdc.
+//
+// Solidity: function repayDebt(address token, uint256 scosAmount) returns()
+func (_StockVault *StockVaultTransactor) RepayDebt(opts *bind.TransactOpts, token common.Address, scosAmount *big.Int) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "repayDebt", token, scosAmount)
+}
+
+// RepayDebt is a paid mutator transaction binding the contract method 0x79e20bdc.
+//
+// Solidity: function repayDebt(address token, uint256 scosAmount) returns()
+func (_StockVault *StockVaultSession) RepayDebt(token common.Address, scosAmount *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.RepayDebt(&_StockVault.TransactOpts, token, scosAmount)
+}
+
+// RepayDebt is a paid mutator transaction binding the contract method 0x79e20bdc.
+//
+// Solidity: function repayDebt(address token, uint256 scosAmount) returns()
+func (_StockVault *StockVaultTransactorSession) RepayDebt(token common.Address, scosAmount *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.RepayDebt(&_StockVault.TransactOpts, token, scosAmount)
+}
+
+// SetPrice is a paid mutator transaction binding the contract method 0x00e4768b.
+//
+// Solidity: function setPrice(address token, uint256 price) returns()
+func (_StockVault *StockVaultTransactor) SetPrice(opts *bind.TransactOpts, token common.Address, price *big.Int) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "setPrice", token, price)
+}
+
+// SetPrice is a paid mutator transaction binding the contract method 0x00e4768b.
+//
+// Solidity: function setPrice(address token, uint256 price) returns()
+func (_StockVault *StockVaultSession) SetPrice(token common.Address, price *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.SetPrice(&_StockVault.TransactOpts, token, price)
+}
+
+// SetPrice is a paid mutator transaction binding the contract method 0x00e4768b.
+//
+// Solidity: function setPrice(address token, uint256 price) returns()
+func (_StockVault *StockVaultTransactorSession) SetPrice(token common.Address, price *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.SetPrice(&_StockVault.TransactOpts, token, price)
+}
+
+// StakeStock is a paid mutator transaction binding the contract method 0xe6e61a17.
+//
+// Solidity: function stakeStock(address token, uint256 amount, uint256 scosAmount) returns()
+func (_StockVault *StockVaultTransactor) StakeStock(opts *bind.TransactOpts, token common.Address, amount *big.Int, scosAmount *big.Int) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "stakeStock", token, amount, scosAmount)
+}
+
+// StakeStock is a paid mutator transaction binding the contract method 0xe6e61a17.
+//
+// Solidity: function stakeStock(address token, uint256 amount, uint256 scosAmount) returns()
+func (_StockVault *StockVaultSession) StakeStock(token common.Address, amount *big.Int, scosAmount *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.StakeStock(&_StockVault.TransactOpts, token, amount, scosAmount)
+}
+
+// StakeStock is a paid mutator transaction binding the contract method 0xe6e61a17.
+//
+// Solidity: function stakeStock(address token, uint256 amount, uint256 scosAmount) returns()
+func (_StockVault *StockVaultTransactorSession) StakeStock(token common.Address, amount *big.Int, scosAmount *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.StakeStock(&_StockVault.TransactOpts, token, amount, scosAmount)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_StockVault *StockVaultTransactor) TransferOwnership(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "transferOwnership", newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_StockVault *StockVaultSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.TransferOwnership(&_StockVault.TransactOpts, newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_StockVault *StockVaultTransactorSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.TransferOwnership(&_StockVault.TransactOpts, newOwner)
+}
+
+// UnstakeStock is a paid mutator transaction binding the contract method 0xcf244553.
+//
+// Solidity: function unstakeStock(address token) returns()
+func (_StockVault *StockVaultTransactor) UnstakeStock(opts *bind.TransactOpts, token common.Address) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "unstakeStock", token)
+}
+
+// UnstakeStock is a paid mutator transaction binding the contract method 0xcf244553.
+//
+// Solidity: function unstakeStock(address token) returns()
+func (_StockVault *StockVaultSession) UnstakeStock(token common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.UnstakeStock(&_StockVault.TransactOpts, token)
+}
+
+// UnstakeStock is a paid mutator transaction binding the contract method 0xcf244553.
+//
+// Solidity: function unstakeStock(address token) returns()
+func (_StockVault *StockVaultTransactorSession) UnstakeStock(token common.Address) (*types.Transaction, error) {
+	return _StockVault.Contract.UnstakeStock(&_StockVault.TransactOpts, token)
+}
+
+// WithdrawStock is a paid mutator transaction binding the contract method 0x8c8bc974.
+//
+// Solidity: function withdrawStock(address token, uint256 amount) returns()
+func (_StockVault *StockVaultTransactor) WithdrawStock(opts *bind.TransactOpts, token common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _StockVault.contract.Transact(opts, "withdrawStock", token, amount)
+}
+
+// WithdrawStock is a paid mutator transaction binding the contract method 0x8c8bc974.
+//
+// Solidity: function withdrawStock(address token, uint256 amount) returns()
+func (_StockVault *StockVaultSession) WithdrawStock(token common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.WithdrawStock(&_StockVault.TransactOpts, token, amount)
+}
+
+// WithdrawStock is a paid mutator transaction binding the contract method 0x8c8bc974.
+//
+// Solidity: function withdrawStock(address token, uint256 amount) returns()
+func (_StockVault *StockVaultTransactorSession) WithdrawStock(token common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _StockVault.Contract.WithdrawStock(&_StockVault.TransactOpts, token, amount)
+}
+
+// StockVaultDebtRepaidIterator is returned from FilterDebtRepaid and is used to iterate over the raw logs and unpacked data for DebtRepaid events raised by the StockVault contract.
+type StockVaultDebtRepaidIterator struct {
+	Event *StockVaultDebtRepaid // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultDebtRepaidIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultDebtRepaid)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultDebtRepaid)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultDebtRepaidIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultDebtRepaidIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultDebtRepaid represents a DebtRepaid event raised by the StockVault contract.
+type StockVaultDebtRepaid struct {
+	User          common.Address
+	Token         common.Address
+	ScosAmount    *big.Int
+	RemainingDebt *big.Int
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterDebtRepaid is a free log retrieval operation binding the contract event 0xb2828294ad940d9ae096e368b4f878a8dabf30da36ce9dca5f5ad8eaf6ed052a.
+//
+// Solidity: event DebtRepaid(address indexed user, address indexed token, uint256 scosAmount, uint256 remainingDebt)
+func (_StockVault *StockVaultFilterer) FilterDebtRepaid(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*StockVaultDebtRepaidIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "DebtRepaid", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultDebtRepaidIterator{contract: _StockVault.contract, event: "DebtRepaid", logs: logs, sub: sub}, nil
+}
+
+// WatchDebtRepaid is a free log subscription operation binding the contract event 0xb2828294ad940d9ae096e368b4f878a8dabf30da36ce9dca5f5ad8eaf6ed052a.
+//
+// Solidity: event DebtRepaid(address indexed user, address indexed token, uint256 scosAmount, uint256 remainingDebt)
+func (_StockVault *StockVaultFilterer) WatchDebtRepaid(opts *bind.WatchOpts, sink chan<- *StockVaultDebtRepaid, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "DebtRepaid", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultDebtRepaid)
+				if err := _StockVault.contract.UnpackLog(event, "DebtRepaid", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDebtRepaid is a log parse operation binding the contract event 0xb2828294ad940d9ae096e368b4f878a8dabf30da36ce9dca5f5ad8eaf6ed052a.
+//
+// Solidity: event DebtRepaid(address indexed user, address indexed token, uint256 scosAmount, uint256 remainingDebt)
+func (_StockVault *StockVaultFilterer) ParseDebtRepaid(log types.Log) (*StockVaultDebtRepaid, error) {
+	event := new(StockVaultDebtRepaid)
+	if err := _StockVault.contract.UnpackLog(event, "DebtRepaid", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// StockVaultLiquidatedIterator is returned from FilterLiquidated and is used to iterate over the raw logs and unpacked data for Liquidated events raised by the StockVault contract.
+type StockVaultLiquidatedIterator struct {
+	Event *StockVaultLiquidated // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultLiquidatedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultLiquidated)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultLiquidated)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultLiquidatedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultLiquidatedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultLiquidated represents a Liquidated event raised by the StockVault contract.
+type StockVaultLiquidated struct {
+	User   common.Address
+	Token  common.Address
+	Amount *big.Int
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterLiquidated is a free log retrieval operation binding the contract event 0xde0aa27286f5cb3a4ed853dc4823ead62d63e92cdf13de09d6aece56970721a4.
+//
+// Solidity: event Liquidated(address indexed user, address indexed token, uint256 amount)
+func (_StockVault *StockVaultFilterer) FilterLiquidated(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*StockVaultLiquidatedIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "Liquidated", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultLiquidatedIterator{contract: _StockVault.contract, event: "Liquidated", logs: logs, sub: sub}, nil
+}
+
+// WatchLiquidated is a free log subscription operation binding the contract event 0xde0aa27286f5cb3a4ed853dc4823ead62d63e92cdf13de09d6aece56970721a4.
+//
+// Solidity: event Liquidated(address indexed user, address indexed token, uint256 amount)
+func (_StockVault *StockVaultFilterer) WatchLiquidated(opts *bind.WatchOpts, sink chan<- *StockVaultLiquidated, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "Liquidated", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultLiquidated)
+				if err := _StockVault.contract.UnpackLog(event, "Liquidated", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseLiquidated is a log parse operation binding the contract event 0xde0aa27286f5cb3a4ed853dc4823ead62d63e92cdf13de09d6aece56970721a4.
+//
+// Solidity: event Liquidated(address indexed user, address indexed token, uint256 amount)
+func (_StockVault *StockVaultFilterer) ParseLiquidated(log types.Log) (*StockVaultLiquidated, error) {
+	event := new(StockVaultLiquidated)
+	if err := _StockVault.contract.UnpackLog(event, "Liquidated", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// StockVaultOwnershipTransferredIterator is returned from FilterOwnershipTransferred and is used to iterate over the raw logs and unpacked data for OwnershipTransferred events raised by the StockVault contract.
+type StockVaultOwnershipTransferredIterator struct {
+	Event *StockVaultOwnershipTransferred // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultOwnershipTransferredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultOwnershipTransferred)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultOwnershipTransferred)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultOwnershipTransferredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultOwnershipTransferredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultOwnershipTransferred represents a OwnershipTransferred event raised by the StockVault contract.
+type StockVaultOwnershipTransferred struct {
+	PreviousOwner common.Address
+	NewOwner      common.Address
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterOwnershipTransferred is a free log retrieval operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_StockVault *StockVaultFilterer) FilterOwnershipTransferred(opts *bind.FilterOpts, previousOwner []common.Address, newOwner []common.Address) (*StockVaultOwnershipTransferredIterator, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultOwnershipTransferredIterator{contract: _StockVault.contract, event: "OwnershipTransferred", logs: logs, sub: sub}, nil
+}
+
+// WatchOwnershipTransferred is a free log subscription operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_StockVault *StockVaultFilterer) WatchOwnershipTransferred(opts *bind.WatchOpts, sink chan<- *StockVaultOwnershipTransferred, previousOwner []common.Address, newOwner []common.Address) (event.Subscription, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultOwnershipTransferred)
+				if err := _StockVault.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseOwnershipTransferred is a log parse operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_StockVault *StockVaultFilterer) ParseOwnershipTransferred(log types.Log) (*StockVaultOwnershipTransferred, error) {
+	event := new(StockVaultOwnershipTransferred)
+	if err := _StockVault.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// StockVaultPriceUpdatedIterator is returned from FilterPriceUpdated and is used to iterate over the raw logs and unpacked data for PriceUpdated events raised by the StockVault contract.
+type StockVaultPriceUpdatedIterator struct {
+	Event *StockVaultPriceUpdated // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultPriceUpdatedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultPriceUpdated)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultPriceUpdated)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultPriceUpdatedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultPriceUpdatedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultPriceUpdated represents a PriceUpdated event raised by the StockVault contract.
+type StockVaultPriceUpdated struct {
+	Token common.Address
+	Price *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterPriceUpdated is a free log retrieval operation binding the contract event 0x0d86730737b142fc160892fa8a0f2db687a92a0e294d1ad70624cf5acef03b84.
+//
+// Solidity: event PriceUpdated(address indexed token, uint256 price)
+func (_StockVault *StockVaultFilterer) FilterPriceUpdated(opts *bind.FilterOpts, token []common.Address) (*StockVaultPriceUpdatedIterator, error) {
+
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "PriceUpdated", tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultPriceUpdatedIterator{contract: _StockVault.contract, event: "PriceUpdated", logs: logs, sub: sub}, nil
+}
+
+// WatchPriceUpdated is a free log subscription operation binding the contract event 0x0d86730737b142fc160892fa8a0f2db687a92a0e294d1ad70624cf5acef03b84.
+//
+// Solidity: event PriceUpdated(address indexed token, uint256 price)
+func (_StockVault *StockVaultFilterer) WatchPriceUpdated(opts *bind.WatchOpts, sink chan<- *StockVaultPriceUpdated, token []common.Address) (event.Subscription, error) {
+
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "PriceUpdated", tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultPriceUpdated)
+				if err := _StockVault.contract.UnpackLog(event, "PriceUpdated", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParsePriceUpdated is a log parse operation binding the contract event 0x0d86730737b142fc160892fa8a0f2db687a92a0e294d1ad70624cf5acef03b84.
+//
+// Solidity: event PriceUpdated(address indexed token, uint256 price)
+func (_StockVault *StockVaultFilterer) ParsePriceUpdated(log types.Log) (*StockVaultPriceUpdated, error) {
+	event := new(StockVaultPriceUpdated)
+	if err := _StockVault.contract.UnpackLog(event, "PriceUpdated", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// StockVaultStockStakedIterator is returned from FilterStockStaked and is used to iterate over the raw logs and unpacked data for StockStaked events raised by the StockVault contract.
+type StockVaultStockStakedIterator struct {
+	Event *StockVaultStockStaked // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultStockStakedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultStockStaked)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultStockStaked)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultStockStakedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultStockStakedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultStockStaked represents a StockStaked event raised by the StockVault contract.
+type StockVaultStockStaked struct {
+	User         common.Address
+	Token        common.Address
+	Amount       *big.Int
+	BorrowedSCOS *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterStockStaked is a free log retrieval operation binding the contract event 0x5cd86af3901d872d592f39f936d8c2bee0c4f712d5b30401c0bdb3f3ff1702c2.
+//
+// Solidity: event StockStaked(address indexed user, address indexed token, uint256 amount, uint256 borrowedSCOS)
+func (_StockVault *StockVaultFilterer) FilterStockStaked(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*StockVaultStockStakedIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "StockStaked", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultStockStakedIterator{contract: _StockVault.contract, event: "StockStaked", logs: logs, sub: sub}, nil
+}
+
+// WatchStockStaked is a free log subscription operation binding the contract event 0x5cd86af3901d872d592f39f936d8c2bee0c4f712d5b30401c0bdb3f3ff1702c2.
+//
+// Solidity: event StockStaked(address indexed user, address indexed token, uint256 amount, uint256 borrowedSCOS)
+func (_StockVault *StockVaultFilterer) WatchStockStaked(opts *bind.WatchOpts, sink chan<- *StockVaultStockStaked, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "StockStaked", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultStockStaked)
+				if err := _StockVault.contract.UnpackLog(event, "StockStaked", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseStockStaked is a log parse operation binding the contract event 0x5cd86af3901d872d592f39f936d8c2bee0c4f712d5b30401c0bdb3f3ff1702c2.
+//
+// Solidity: event StockStaked(address indexed user, address indexed token, uint256 amount, uint256 borrowedSCOS)
+func (_StockVault *StockVaultFilterer) ParseStockStaked(log types.Log) (*StockVaultStockStaked, error) {
+	event := new(StockVaultStockStaked)
+	if err := _StockVault.contract.UnpackLog(event, "StockStaked", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// StockVaultStockUnstakedIterator is returned from FilterStockUnstaked and is used to iterate over the raw logs and unpacked data for StockUnstaked events raised by the StockVault contract.
+type StockVaultStockUnstakedIterator struct {
+	Event *StockVaultStockUnstaked // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultStockUnstakedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultStockUnstaked)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultStockUnstaked)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultStockUnstakedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultStockUnstakedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultStockUnstaked represents a StockUnstaked event raised by the StockVault contract.
+type StockVaultStockUnstaked struct {
+	User   common.Address
+	Token  common.Address
+	Amount *big.Int
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterStockUnstaked is a free log retrieval operation binding the contract event 0x07abb7ffe29794956bddb6244881c2ad6d473956d86d170c05e038966ac500de.
+//
+// Solidity: event StockUnstaked(address indexed user, address indexed token, uint256 amount)
+func (_StockVault *StockVaultFilterer) FilterStockUnstaked(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*StockVaultStockUnstakedIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "StockUnstaked", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultStockUnstakedIterator{contract: _StockVault.contract, event: "StockUnstaked", logs: logs, sub: sub}, nil
+}
+
+// WatchStockUnstaked is a free log subscription operation binding the contract event 0x07abb7ffe29794956bddb6244881c2ad6d473956d86d170c05e038966ac500de.
+//
+// Solidity: event StockUnstaked(address indexed user, address indexed token, uint256 amount)
+func (_StockVault *StockVaultFilterer) WatchStockUnstaked(opts *bind.WatchOpts, sink chan<- *StockVaultStockUnstaked, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "StockUnstaked", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultStockUnstaked)
+				if err := _StockVault.contract.UnpackLog(event, "StockUnstaked", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseStockUnstaked is a log parse operation binding the contract event 0x07abb7ffe29794956bddb6244881c2ad6d473956d86d170c05e038966ac500de.
+//
+// Solidity: event StockUnstaked(address indexed user, address indexed token, uint256 amount)
+func (_StockVault *StockVaultFilterer) ParseStockUnstaked(log types.Log) (*StockVaultStockUnstaked, error) {
+	event := new(StockVaultStockUnstaked)
+	if err := _StockVault.contract.UnpackLog(event, "StockUnstaked", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// StockVaultStockWithdrawnIterator is returned from FilterStockWithdrawn and is used to iterate over the raw logs and unpacked data for StockWithdrawn events raised by the StockVault contract.
+type StockVaultStockWithdrawnIterator struct {
+	Event *StockVaultStockWithdrawn // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *StockVaultStockWithdrawnIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(StockVaultStockWithdrawn)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(StockVaultStockWithdrawn)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *StockVaultStockWithdrawnIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *StockVaultStockWithdrawnIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// StockVaultStockWithdrawn represents a StockWithdrawn event raised by the StockVault contract.
+type StockVaultStockWithdrawn struct {
+	User      common.Address
+	Token     common.Address
+	Amount    *big.Int
+	Remaining *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterStockWithdrawn is a free log retrieval operation binding the contract event 0x40ff70d6a22130026766b2345b8b289727537e98b9d8db2426477157b6ab2898.
+//
+// Solidity: event StockWithdrawn(address indexed user, address indexed token, uint256 amount, uint256 remaining)
+func (_StockVault *StockVaultFilterer) FilterStockWithdrawn(opts *bind.FilterOpts, user []common.Address, token []common.Address) (*StockVaultStockWithdrawnIterator, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.FilterLogs(opts, "StockWithdrawn", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return &StockVaultStockWithdrawnIterator{contract: _StockVault.contract, event: "StockWithdrawn", logs: logs, sub: sub}, nil
+}
+
+// WatchStockWithdrawn is a free log subscription operation binding the contract event 0x40ff70d6a22130026766b2345b8b289727537e98b9d8db2426477157b6ab2898.
+//
+// Solidity: event StockWithdrawn(address indexed user, address indexed token, uint256 amount, uint256 remaining)
+func (_StockVault *StockVaultFilterer) WatchStockWithdrawn(opts *bind.WatchOpts, sink chan<- *StockVaultStockWithdrawn, user []common.Address, token []common.Address) (event.Subscription, error) {
+
+	var userRule []interface{}
+	for _, userItem := range user {
+		userRule = append(userRule, userItem)
+	}
+	var tokenRule []interface{}
+	for _, tokenItem := range token {
+		tokenRule = append(tokenRule, tokenItem)
+	}
+
+	logs, sub, err := _StockVault.contract.WatchLogs(opts, "StockWithdrawn", userRule, tokenRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(StockVaultStockWithdrawn)
+				if err := _StockVault.contract.UnpackLog(event, "StockWithdrawn", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseStockWithdrawn is a log parse operation binding the contract event 0x40ff70d6a22130026766b2345b8b289727537e98b9d8db2426477157b6ab2898.
+//
+// Solidity: event StockWithdrawn(address indexed user, address indexed token, uint256 amount, uint256 remaining)
+func (_StockVault *StockVaultFilterer) ParseStockWithdrawn(log types.Log) (*StockVaultStockWithdrawn, error) {
+	event := new(StockVaultStockWithdrawn)
+	if err := _StockVault.contract.UnpackLog(event, "StockWithdrawn", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
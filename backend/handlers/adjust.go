@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+
+	"scos/contracts"
+	"scos/models"
+	"scos/money"
+)
+
+// AdjustRequest 部分还款（amount 为 SCOS 数量）或部分取回抵押品（amount 为股票代币数量），返回由用户钱包签名的交易
+type AdjustRequest struct {
+	UserAddress  string `json:"user_address" binding:"required"`
+	TokenAddress string `json:"token_address" binding:"required"`
+	Chain        string `json:"chain" binding:"required"`
+	Amount       string `json:"amount" binding:"required"`
+}
+
+// RepayDebt 返回用户自己调用 repayDebt 的交易，金库在同一笔交易中销毁用户偿还的 SCOS。
+// 还款只会改善仓位，只拒绝超过剩余债务的数量；用户需持有并授权金库转走相应的 SCOS
+func (h *StakingHandler) RepayDebt(c *gin.Context) {
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+
+	req, stake, info, amount, ok := h.bindAdjust(ctx, c, "repay")
+	if !ok {
+		return
+	}
+
+	decimals, err := h.bc.SCOSDecimals(ctx, req.Chain)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS decimals")
+		return
+	}
+	repay, err := amount.Units(decimals)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("amount %s has more than %d decimals", req.Amount, decimals)})
+		return
+	}
+	if repay.Cmp(info.BorrowedSCOS) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repay amount exceeds debt", "scos_borrowed": money.FromUnits(info.BorrowedSCOS, decimals)})
+		return
+	}
+	if !h.checkSCOS(ctx, c, req.Chain, req.UserAddress, repay, decimals) {
+		return
+	}
+
+	tx, err := h.bc.BuildRepayTx(ctx, req.Chain, req.UserAddress, req.TokenAddress, repay)
+	if err != nil {
+		chainError(c, err, "Failed to build repay transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tx":             unsignedTxJSON(tx),
+		"stake_id":       stake.ID,
+		"scos_borrowed":  money.FromUnits(new(big.Int).Sub(info.BorrowedSCOS, repay), decimals),
+		"scos_repay_wei": repay.String(),
+	})
+}
+
+// WithdrawStock 返回用户自己调用 withdrawStock 的交易，抵押品转回用户；全部取回使用 /api/redeem。
+// 剩余抵押品按当前价格仍需满足抵押率，金库按链上价格再次检查
+func (h *StakingHandler) WithdrawStock(c *gin.Context) {
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+
+	req, stake, info, amount, ok := h.bindAdjust(ctx, c, "withdraw")
+	if !ok {
+		return
+	}
+	price, ok := h.stakePrice(c, stake)
+	if !ok {
+		return
+	}
+
+	decimals, err := h.bc.TokenDecimals(ctx, req.Chain, req.TokenAddress)
+	if err != nil {
+		chainError(c, err, "Failed to read token decimals")
+		return
+	}
+	scosDecimals, err := h.bc.SCOSDecimals(ctx, req.Chain)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS decimals")
+		return
+	}
+	withdraw, err := amount.Units(decimals)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("amount %s has more than %d decimals", req.Amount, decimals)})
+		return
+	}
+	if withdraw.Cmp(info.Amount) >= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be less than the staked amount, use /api/redeem to withdraw everything"})
+		return
+	}
+
+	remaining := money.FromUnits(new(big.Int).Sub(info.Amount, withdraw), decimals)
+	debt := money.FromUnits(info.BorrowedSCOS, scosDecimals)
+	if !h.withinRatio(remaining, debt, price) {
+		// 剩余抵押品至少为 债务 x 抵押率 / 价格，按代币精度向上取整
+		required := debt.Mul(h.ratio()).Div(price).ToUnits(decimals, money.Up)
+		available := new(big.Int).Sub(info.Amount, required)
+		if available.Sign() < 0 {
+			available.SetInt64(0)
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":            "Remaining collateral would fall below the collateral ratio",
+			"code":             "collateral_ratio",
+			"max_withdrawable": money.FromUnits(available, decimals),
+		})
+		return
+	}
+
+	tx, err := h.bc.BuildWithdrawTx(ctx, req.Chain, req.UserAddress, req.TokenAddress, withdraw)
+	if err != nil {
+		chainError(c, err, "Failed to build withdraw transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tx":         unsignedTxJSON(tx),
+		"stake_id":   stake.ID,
+		"amount":     remaining,
+		"amount_wei": withdraw.String(),
+	})
+}
+
+// bindAdjust 解析请求，查找数据库中的活跃质押并读取链上仓位，失败时写入响应并返回 false。
+// 数量以链上仓位为准，数据库记录提供股票代码与质押 ID
+func (h *StakingHandler) bindAdjust(ctx context.Context, c *gin.Context, action string) (*AdjustRequest, models.StakeRecord, contracts.StockVaultStakeInfo, money.Decimal, bool) {
+	var req AdjustRequest
+	var stake models.StakeRecord
+	var info contracts.StockVaultStakeInfo
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, stake, info, money.Decimal{}, false
+	}
+
+	if err := h.bc.CheckChain(req.Chain); err != nil {
+		chainError(c, err, fmt.Sprintf("Failed to build %s transaction", action))
+		return nil, stake, info, money.Decimal{}, false
+	}
+	if !common.IsHexAddress(req.UserAddress) || !common.IsHexAddress(req.TokenAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return nil, stake, info, money.Decimal{}, false
+	}
+
+	amount, err := money.Parse(req.Amount)
+	if err != nil || amount.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid amount %q", req.Amount)})
+		return nil, stake, info, money.Decimal{}, false
+	}
+
+	// 地址大小写不影响匹配，与索引器查找质押记录的方式一致
+	if err := h.db.Where("LOWER(user_address) = ? AND LOWER(token_address) = ? AND chain = ? AND status = ?",
+		strings.ToLower(req.UserAddress), strings.ToLower(req.TokenAddress), req.Chain, "active").First(&stake).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active stake found"})
+		return nil, stake, info, money.Decimal{}, false
+	}
+
+	info, err = h.bc.GetStakeInfo(ctx, req.Chain, req.UserAddress, req.TokenAddress)
+	if err != nil {
+		chainError(c, err, "Failed to read stake")
+		return nil, stake, info, money.Decimal{}, false
+	}
+	if !info.Active {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active stake found"})
+		return nil, stake, info, money.Decimal{}, false
+	}
+	return &req, stake, info, amount, true
+}
+
+// stakePrice 质押股票的当前价格，不存在时写入 404
+func (h *StakingHandler) stakePrice(c *gin.Context, stake models.StakeRecord) (money.Decimal, bool) {
+	var price models.TokenPrice
+	if err := h.db.Where("symbol = ?", stake.StockSymbol).First(&price).Error; err != nil || price.Price.Sign() <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock price not found"})
+		return money.Decimal{}, false
+	}
+	return price.Price, true
+}
+
+// withinRatio 债务 x 抵押率 <= 抵押品 x 价格，与质押时可借数量的计算一致
+func (h *StakingHandler) withinRatio(amount, debt, price money.Decimal) bool {
+	return debt.Mul(h.ratio()).Cmp(amount.Mul(price)) <= 0
+}
+
+func (h *StakingHandler) ratio() money.Decimal {
+	return money.FromFloat(h.risk.CollateralRatio)
+}
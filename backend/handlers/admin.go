@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"scos/blockchain"
+	"scos/config"
+	"scos/models"
+)
+
+// 等待旧账户在途交易上链的最长时间
+const rotateDrainTimeout = 5 * time.Minute
+
+type AdminHandler struct {
+	db  *gorm.DB
+	bc  blockchain.AdminClient
+	cfg *config.Config
+}
+
+func NewAdminHandler(db *gorm.DB, bc blockchain.AdminClient, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{db: db, bc: bc, cfg: cfg}
+}
+
+// AdminAuth 校验 X-Admin-Token 请求头
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+type RotateSignerRequest struct {
+	Signer string `json:"signer" binding:"required"`
+}
+
+func (h *AdminHandler) RotateSigner(c *gin.Context) {
+	chain := c.Param("chain")
+
+	var req RotateSignerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.bc.HasChain(chain) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chain not found", "code": "unknown_chain"})
+		return
+	}
+	if _, ok := h.cfg.SignerConfig(req.Signer); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Signer not configured"})
+		return
+	}
+
+	signer, err := h.loadSigner(req.Signer)
+	if err != nil {
+		log.Printf("Failed to load signer %s: %v", req.Signer, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load signer"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), rotateDrainTimeout)
+	defer cancel()
+
+	old, err := h.bc.RotateSigner(ctx, chain, signer)
+	if errors.Is(err, blockchain.ErrUnknownChain) || errors.Is(err, blockchain.ErrChainUnavailable) {
+		chainError(c, err, "Failed to rotate signer")
+		return
+	}
+	if errors.Is(err, blockchain.ErrRotationInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Signer rotation already in progress", "code": "rotation_in_progress"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to rotate signer on %s: %v", chain, err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to drain pending transactions, signer unchanged"})
+		return
+	}
+
+	// 记录轮换，启动时按记录恢复签名器
+	rotation := models.KeyRotation{
+		Chain:      chain,
+		SignerName: req.Signer,
+		OldAddress: old.Hex(),
+		NewAddress: signer.Address().Hex(),
+		CreatedAt:  time.Now(),
+	}
+	log.Printf("Rotated signer on %s: %s -> %s", chain, rotation.OldAddress, rotation.NewAddress)
+	if err := h.db.Create(&rotation).Error; err != nil {
+		log.Printf("Failed to record signer rotation on %s: %v", chain, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":       "Signer rotated but the rotation was not recorded, it will be undone on restart",
+			"code":        "rotation_not_recorded",
+			"new_address": rotation.NewAddress,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+type CancelTxRequest struct {
+	TxHash string `json:"tx_hash" binding:"required"`
+}
+
+// CancelTransaction 用同一 nonce 的 0 金额自转账取消一笔未上链的运营交易
+func (h *AdminHandler) CancelTransaction(c *gin.Context) {
+	chain := c.Param("chain")
+
+	var req CancelTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.bc.HasChain(chain) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chain not found", "code": "unknown_chain"})
+		return
+	}
+
+	ctx, cancel := withTimeout(c, h.cfg.Timeouts.Write)
+	defer cancel()
+	cancelHash, err := h.bc.CancelTx(ctx, chain, req.TxHash)
+	switch {
+	case errors.Is(err, blockchain.ErrTxNotPending), errors.Is(err, blockchain.ErrNotOperatorTx):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, blockchain.ErrFeeCeiling):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		chainError(c, err, "Failed to cancel transaction")
+		return
+	}
+
+	// 记录取消交易，原交易由回执监控最终标记为 dropped 或 confirmed
+	operator, _ := h.bc.SignerAddress(chain)
+	tx := models.Transaction{
+		UserAddress: operator.Hex(),
+		Type:        "cancel",
+		TxHash:      cancelHash,
+		Chain:       chain,
+		Status:      "pending",
+		CreatedAt:   time.Now(),
+	}
+	if err := h.db.Create(&tx).Error; err != nil {
+		log.Printf("Failed to record cancel transaction %s on %s: %v", cancelHash, chain, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "Cancel transaction broadcast but not recorded",
+			"code":              "tx_not_recorded",
+			"cancelled_tx_hash": req.TxHash,
+			"tx_hash":           cancelHash,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cancelled_tx_hash": req.TxHash,
+		"tx_hash":           cancelHash,
+		"status":            "pending",
+	})
+}
+
+// loadSigner 优先复用已加载的同名签名器，否则按配置创建
+func (h *AdminHandler) loadSigner(name string) (blockchain.Signer, error) {
+	if name == "" {
+		name = config.DefaultSigner
+	}
+	if signer, ok := h.bc.Signer(name); ok {
+		return signer, nil
+	}
+	sc, ok := h.cfg.SignerConfig(name)
+	if !ok {
+		return nil, fmt.Errorf("signer %q is not defined", name)
+	}
+	return blockchain.NewSigner(sc)
+}
+
+// AddChain 运行时添加一条链。请求体与 config.yaml 中 chains 下的条目格式相同，
+// 也可以用 JSON（JSON 是 YAML 的子集），时长写作 "5m"。新增的链不会写回配置文件
+func (h *AdminHandler) AddChain(c *gin.Context) {
+	chain := c.Param("chain")
+
+	var info config.ChainInfo
+	if err := yaml.NewDecoder(c.Request.Body).Decode(&info); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.cfg.ValidateChain(chain, info); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signer, err := h.loadSigner(info.Signer)
+	if err != nil {
+		log.Printf("Failed to load signer %s: %v", info.Signer, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load signer"})
+		return
+	}
+	scosSigner := signer
+	if info.SCOSSigner != "" {
+		if scosSigner, err = h.loadSigner(info.SCOSSigner); err != nil {
+			log.Printf("Failed to load signer %s: %v", info.SCOSSigner, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load signer"})
+			return
+		}
+	}
+
+	if err := h.bc.AddChain(chain, info, signer, scosSigner); err != nil {
+		if errors.Is(err, blockchain.ErrChainExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Chain already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"chain": chain, "status": "added"})
+}
+
+// EnableChain / DisableChain 停用的链保留配置，所有上链操作返回 503
+func (h *AdminHandler) EnableChain(c *gin.Context) {
+	h.setChainEnabled(c, true)
+}
+
+func (h *AdminHandler) DisableChain(c *gin.Context) {
+	h.setChainEnabled(c, false)
+}
+
+func (h *AdminHandler) setChainEnabled(c *gin.Context, enabled bool) {
+	chain := c.Param("chain")
+	if err := h.bc.SetChainEnabled(chain, enabled); err != nil {
+		chainError(c, err, "Failed to update chain")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chain": chain, "enabled": enabled})
+}
+
+// RemoveChain 移除一条链，数据库中的质押与交易记录保留
+func (h *AdminHandler) RemoveChain(c *gin.Context) {
+	chain := c.Param("chain")
+	if err := h.bc.RemoveChain(chain); err != nil {
+		chainError(c, err, "Failed to remove chain")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chain": chain, "status": "removed"})
+}
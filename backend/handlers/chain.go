@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"scos/blockchain"
+	"scos/config"
+	"scos/models"
+	"scos/money"
+)
+
+type ChainHandler struct {
+	db       *gorm.DB
+	bc       blockchain.ChainReadClient
+	timeouts config.TimeoutConfig
+}
+
+func NewChainHandler(db *gorm.DB, bc blockchain.ChainReadClient, timeouts config.TimeoutConfig) *ChainHandler {
+	return &ChainHandler{db: db, bc: bc, timeouts: timeouts}
+}
+
+// GetStatus 各链当前使用的 RPC 节点与健康状况
+func (h *ChainHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"chains": h.bc.Status()})
+}
+
+// GetPosition 链上金库中的质押仓位，与数据库中的记录一并返回
+func (h *ChainHandler) GetPosition(c *gin.Context) {
+	chain, user, token := c.Param("chain"), c.Param("user"), c.Param("token")
+	if !common.IsHexAddress(user) || !common.IsHexAddress(token) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return
+	}
+	if err := h.bc.CheckChain(chain); err != nil {
+		chainError(c, err, "Failed to read position")
+		return
+	}
+
+	var records []models.StakeRecord
+	if err := h.db.Where("chain = ? AND user_address = ? AND token_address = ?", chain, user, token).
+		Order("id desc").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stakes"})
+		return
+	}
+
+	// 仓位记在链上 msg.sender 名下；早期由运营账户代为质押的记录没有 Staker，按运营账户查询
+	staker := user
+	for _, r := range records {
+		if r.Status != "active" {
+			continue
+		}
+		if r.Staker != "" {
+			staker = r.Staker
+		} else if addr, err := h.bc.SignerAddress(chain); err == nil {
+			staker = addr.Hex()
+		}
+		break
+	}
+
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+	info, err := h.bc.GetStakeInfo(ctx, chain, staker, token)
+	if err != nil {
+		chainError(c, err, "Failed to read position")
+		return
+	}
+	supported, err := h.bc.IsSupportedToken(ctx, chain, token)
+	if err != nil {
+		chainError(c, err, "Failed to read position")
+		return
+	}
+	decimals, err := h.bc.TokenDecimals(ctx, chain, token)
+	if err != nil {
+		chainError(c, err, "Failed to read token decimals")
+		return
+	}
+	scosDecimals, err := h.bc.SCOSDecimals(ctx, chain)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS decimals")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chain":     chain,
+		"user":      user,
+		"token":     token,
+		"staker":    staker,
+		"supported": supported,
+		"on_chain": gin.H{
+			"amount":        money.FromUnits(info.Amount, decimals),
+			"scos_borrowed": money.FromUnits(info.BorrowedSCOS, scosDecimals),
+			"timestamp":     info.Timestamp.Uint64(),
+			"active":        info.Active,
+		},
+		"db": records,
+	})
+}
+
+// GetBalance 链上 ERC20 余额及对金库的授权额度，与数据库中的活跃质押一并返回
+func (h *ChainHandler) GetBalance(c *gin.Context) {
+	chain, token, user := c.Param("chain"), c.Param("token"), c.Param("user")
+	if !common.IsHexAddress(user) || !common.IsHexAddress(token) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return
+	}
+	vault, err := h.bc.GetVaultAddr(chain)
+	if err != nil {
+		chainError(c, err, "Failed to read balance")
+		return
+	}
+
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+	decimals, err := h.bc.TokenDecimals(ctx, chain, token)
+	if err != nil {
+		chainError(c, err, "Failed to read token decimals")
+		return
+	}
+	balance, err := h.bc.TokenBalance(ctx, chain, token, user)
+	if err != nil {
+		chainError(c, err, "Failed to read balance")
+		return
+	}
+	allowance, err := h.bc.TokenAllowance(ctx, chain, token, user, vault.Hex())
+	if err != nil {
+		chainError(c, err, "Failed to read allowance")
+		return
+	}
+
+	var stakes []models.StakeRecord
+	if err := h.db.Where("chain = ? AND user_address = ? AND token_address = ? AND status = ?", chain, user, token, "active").
+		Find(&stakes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stakes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chain":              chain,
+		"token":              token,
+		"user":               user,
+		"decimals":           decimals,
+		"balance":            money.FromUnits(balance, decimals),
+		"allowance_to_vault": money.FromUnits(allowance, decimals),
+		"db": gin.H{
+			"active_stakes": len(stakes),
+			"staked":        stakes,
+		},
+	})
+}
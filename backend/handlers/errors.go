@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"scos/blockchain"
+)
+
+// chainError 把区块链调用错误转换为 API 响应：链不存在 404，链不可用 503，合约 revert 422 并带上原因，
+// 超时 504，请求被取消（客户端断开或服务关闭）503
+func chainError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, blockchain.ErrUnknownChain) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": msg,
+			"code":  "unknown_chain",
+		})
+		return
+	}
+	if errors.Is(err, blockchain.ErrChainUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": msg,
+			"code":  "chain_unavailable",
+		})
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error": msg,
+			"code":  "timeout",
+		})
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		log.Printf("%s: %v", msg, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": msg,
+			"code":  "canceled",
+		})
+		return
+	}
+
+	var revert *blockchain.RevertError
+	if errors.As(err, &revert) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":         msg,
+			"code":          "execution_reverted",
+			"revert_reason": revert.Reason,
+		})
+		return
+	}
+
+	log.Printf("%s: %v", msg, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"scos/blockchain"
+	"scos/models"
+)
+
+// 签名有效期上限，避免长期有效的授权被截获后使用
+const maxIntentLifetime = time.Hour
+
+// IntentAuth 用户操作请求附带的 EIP-712 签名授权，nonce 为该用户未使用过的任意 uint256
+type IntentAuth struct {
+	Nonce     string `json:"nonce"`
+	Expiry    int64  `json:"expiry"`    // unix 秒
+	Signature string `json:"signature"` // eth_signTypedData_v4 的结果
+}
+
+// Intent 被签名的用户操作。Amount 为请求中的原始字符串
+type Intent struct {
+	Action string // buy, sell
+	User   string
+	Token  string
+	Amount string
+	Chain  string
+	Nonce  string
+	Expiry int64
+}
+
+var intentTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"Intent": {
+		{Name: "action", Type: "string"},
+		{Name: "user", Type: "address"},
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "string"},
+		{Name: "chain", Type: "string"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "expiry", Type: "uint256"},
+	},
+}
+
+func intentDomain(chainID *big.Int, vault common.Address) apitypes.TypedDataDomain {
+	return apitypes.TypedDataDomain{
+		Name:              "SCOS",
+		Version:           "1",
+		ChainId:           (*math.HexOrDecimal256)(chainID),
+		VerifyingContract: vault.Hex(),
+	}
+}
+
+// TypedData 钱包签名用的 EIP-712 结构，domain 绑定链 ID 与该链的金库合约
+func (in Intent) TypedData(chainID *big.Int, vault common.Address) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       intentTypes,
+		PrimaryType: "Intent",
+		Domain:      intentDomain(chainID, vault),
+		Message: apitypes.TypedDataMessage{
+			"action": in.Action,
+			"user":   in.User,
+			"token":  in.Token,
+			"amount": in.Amount,
+			"chain":  in.Chain,
+			"nonce":  in.Nonce,
+			"expiry": fmt.Sprint(in.Expiry),
+		},
+	}
+}
+
+// intentVerifier 校验用户操作的签名并记录已使用的 nonce
+type intentVerifier struct {
+	db *gorm.DB
+	bc blockchain.VaultClient
+}
+
+// verify 校验签名者为 in.User、签名未过期且 nonce 未使用；consume 为 true 时记录 nonce（dry_run 不消耗）。
+// 失败时写入响应并返回 false
+func (v *intentVerifier) verify(c *gin.Context, in Intent, auth IntentAuth, consume bool) bool {
+	if auth.Signature == "" || auth.Nonce == "" || auth.Expiry == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing intent signature", "code": "invalid_signature"})
+		return false
+	}
+	now := time.Now()
+	if expiry := time.Unix(auth.Expiry, 0); !expiry.After(now) || expiry.Sub(now) > maxIntentLifetime {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Intent expired or expiry too far in the future", "code": "intent_expired"})
+		return false
+	}
+	if !common.IsHexAddress(in.User) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return false
+	}
+	// 签名中的 nonce 按数值计算哈希，"07" 与 "7" 的签名相同，只接受规范写法，防止换一种写法重放
+	n, ok := new(big.Int).SetString(auth.Nonce, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 || n.String() != auth.Nonce {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nonce must be a decimal uint256 without sign or leading zeros"})
+		return false
+	}
+	nonce := n.String()
+
+	chainID, err := v.bc.ChainID(in.Chain)
+	if err != nil {
+		chainError(c, err, "Failed to verify intent")
+		return false
+	}
+	vault, err := v.bc.GetVaultAddr(in.Chain)
+	if err != nil {
+		chainError(c, err, "Failed to verify intent")
+		return false
+	}
+
+	in.Nonce, in.Expiry = nonce, auth.Expiry
+	hash, _, err := apitypes.TypedDataAndHash(in.TypedData(chainID, vault))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	signer, err := recoverSigner(hash, auth.Signature)
+	if err != nil || signer != common.HexToAddress(in.User) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature does not match user_address", "code": "invalid_signature"})
+		return false
+	}
+
+	user := strings.ToLower(in.User)
+	var used int64
+	if err := v.db.Model(&models.IntentNonce{}).Where("user_address = ? AND nonce = ?", user, nonce).Count(&used).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check nonce"})
+		return false
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Intent nonce already used", "code": "nonce_used"})
+		return false
+	}
+	if !consume {
+		return true
+	}
+
+	// 唯一索引保证并发请求中只有一个能使用同一 nonce
+	record := models.IntentNonce{UserAddress: user, Nonce: nonce, Action: in.Action, Chain: in.Chain, CreatedAt: now}
+	if err := v.db.Create(&record).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Intent nonce already used", "code": "nonce_used"})
+		return false
+	}
+	return true
+}
+
+// recoverSigner 从 65 字节签名恢复地址，v 可以是 27/28 或 0/1
+func recoverSigner(hash []byte, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature must be %d bytes", crypto.SignatureLength)
+	}
+	sig = append([]byte(nil), sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// GetIntentDomain 返回该链签名用户操作所需的 EIP-712 domain 与类型定义
+func (h *ChainHandler) GetIntentDomain(c *gin.Context) {
+	chain := c.Param("chain")
+	chainID, err := h.bc.ChainID(chain)
+	if err != nil {
+		chainError(c, err, "Failed to load intent domain")
+		return
+	}
+	vault, err := h.bc.GetVaultAddr(chain)
+	if err != nil {
+		chainError(c, err, "Failed to load intent domain")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"domain": gin.H{
+			"name":              "SCOS",
+			"version":           "1",
+			"chainId":           chainID,
+			"verifyingContract": vault.Hex(),
+		},
+		"types":        intentTypes,
+		"primary_type": "Intent",
+	})
+}
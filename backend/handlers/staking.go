@@ -1,33 +1,40 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"net/http"
-	"strconv"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"scos/blockchain"
+	"scos/config"
 	"scos/models"
+	"scos/money"
 )
 
 type StakingHandler struct {
-	db *gorm.DB
-	bc *blockchain.BlockchainClient
+	db       *gorm.DB
+	bc       blockchain.VaultClient
+	risk     config.RiskConfig
+	timeouts config.TimeoutConfig
 }
 
-func NewStakingHandler(db *gorm.DB, bc *blockchain.BlockchainClient) *StakingHandler {
-	return &StakingHandler{db: db, bc: bc}
+func NewStakingHandler(db *gorm.DB, bc blockchain.VaultClient, risk config.RiskConfig, timeouts config.TimeoutConfig) *StakingHandler {
+	return &StakingHandler{db: db, bc: bc, risk: risk, timeouts: timeouts}
 }
 
+// StakeRequest 质押报价并构造由用户钱包签名的 stakeStock，链上 msg.sender 即用户
 type StakeRequest struct {
 	UserAddress  string `json:"user_address" binding:"required"`
 	TokenAddress string `json:"token_address" binding:"required"`
 	Chain        string `json:"chain" binding:"required"`
 	Amount       string `json:"amount" binding:"required"`
 	StockSymbol  string `json:"stock_symbol" binding:"required"`
+	DryRun       bool   `json:"dry_run"` // 构造交易本身只以用户身份预执行，不广播也不写库；为 true 时响应中带上 dry_run
 }
 
 func (h *StakingHandler) GetStakeRecords(c *gin.Context) {
@@ -42,6 +49,8 @@ func (h *StakingHandler) GetStakeRecords(c *gin.Context) {
 	c.JSON(http.StatusOK, records)
 }
 
+// StakeStock 按当前价格报价并返回用户自己调用 stakeStock 的交易，用户需先 approve 金库转走股票代币。
+// 金库按链上价格与抵押率限制借出数量；质押达到确认数后由索引器写入质押记录并把借出的 SCOS 铸造给用户
 func (h *StakingHandler) StakeStock(c *gin.Context) {
 	var req StakeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -49,74 +58,110 @@ func (h *StakingHandler) StakeStock(c *gin.Context) {
 		return
 	}
 
-	// 获取指定股票价格
-	var price models.TokenPrice
-	if err := h.db.Where("symbol = ?", req.StockSymbol).First(&price).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Stock price not found"})
+	if err := h.bc.CheckChain(req.Chain); err != nil {
+		chainError(c, err, "Failed to build stake transaction")
+		return
+	}
+	if !common.IsHexAddress(req.UserAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
 		return
 	}
 
-	// 计算可借贷SCOS数量 (数量/140% x 价格)
-	amount, _ := strconv.ParseFloat(req.Amount, 64)
-	scosAmount := (amount / 1.4) * price.Price
-
-	// 调用区块链合约
-	amountWei := new(big.Int)
-	amountWei.SetString(req.Amount+"000000", 10) // 假设6位小数
-
-	scosAmountWei := new(big.Int)
-	scosAmountWei.SetString(fmt.Sprintf("%.0f", scosAmount*1000000), 10)
+	quote, ok := quoteStake(c, h.db, h.bc, h.risk, h.timeouts.Read, req.Chain, req.StockSymbol, req.TokenAddress, req.Amount)
+	if !ok {
+		return
+	}
 
-	txHash, err := h.bc.StakeStock(req.Chain, req.TokenAddress, amountWei, scosAmountWei)
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+	tx, err := h.bc.BuildStakeTx(ctx, req.Chain, req.UserAddress, req.TokenAddress, quote.amountWei, quote.scosAmountWei)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stake on blockchain"})
+		chainError(c, err, "Failed to build stake transaction")
 		return
 	}
 
-	// 记录到数据库
-	stake := models.StakeRecord{
-		UserAddress:  req.UserAddress,
-		TokenAddress: req.TokenAddress,
-		StockSymbol:  req.StockSymbol,
-		Chain:        req.Chain,
-		Amount:       req.Amount,
-		SCOSBorrowed: fmt.Sprintf("%.6f", scosAmount),
-		Status:       "active",
-		StakePrice:   fmt.Sprintf("%.6f", price.Price),
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+	c.JSON(http.StatusOK, withDryRun(gin.H{
+		"tx":              unsignedTxJSON(tx),
+		"scos_borrowed":   quote.scosAmount,
+		"stake_price":     quote.price,
+		"amount_wei":      quote.amountWei.String(),
+		"scos_amount_wei": quote.scosAmountWei.String(),
+	}, req.DryRun))
+}
+
+// withDryRun 用户签名的交易接口不广播也不写库，dry_run 请求与普通请求的结果相同，只在响应中标明
+func withDryRun(resp gin.H, dryRun bool) gin.H {
+	if dryRun {
+		resp["dry_run"] = true
 	}
+	return resp
+}
 
-	if err := h.db.Create(&stake).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save stake record"})
-		return
+// stakeQuote 质押数量对应的可借贷 SCOS 及调用合约的金额
+type stakeQuote struct {
+	price         money.Decimal
+	amount        money.Decimal
+	scosAmount    money.Decimal // 实际借出的 SCOS，即 scosAmountWei 按 SCOS 精度换算的值
+	amountWei     *big.Int
+	scosAmountWei *big.Int
+}
+
+// quoteStake 按当前价格与抵押率计算质押报价：可借 SCOS = 数量 x 价格 / 抵押率，按 SCOS 精度向下取整；
+// 质押数量必须能按代币精度精确表示。失败时写入响应（数量无效 400，价格不存在 404，链错误按 chainError）并返回 false
+func quoteStake(c *gin.Context, db *gorm.DB, bc blockchain.VaultClient, risk config.RiskConfig, timeout time.Duration, chain, symbol, token, amountStr string) (*stakeQuote, bool) {
+	amount, err := money.Parse(amountStr)
+	if err != nil || amount.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid amount %q", amountStr)})
+		return nil, false
 	}
 
-	// 记录交易
-	tx := models.Transaction{
-		UserAddress: req.UserAddress,
-		Type:        "stake",
-		TxHash:      txHash,
-		Chain:       req.Chain,
-		Status:      "pending",
-		CreatedAt:   time.Now(),
+	// 获取指定股票价格
+	var price models.TokenPrice
+	if err := db.Where("symbol = ?", symbol).First(&price).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock price not found"})
+		return nil, false
+	}
+
+	ctx, cancel := withTimeout(c, timeout)
+	defer cancel()
+	tokenDecimals, err := bc.TokenDecimals(ctx, chain, token)
+	if err != nil {
+		chainError(c, err, "Failed to read token decimals")
+		return nil, false
+	}
+	scosDecimals, err := bc.SCOSDecimals(ctx, chain)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS decimals")
+		return nil, false
 	}
-	h.db.Create(&tx)
 
-	c.JSON(http.StatusOK, gin.H{
-		"stake_id":      stake.ID,
-		"scos_borrowed": scosAmount,
-		"tx_hash":       txHash,
-		"status":        "success",
-	})
+	amountWei, err := amount.Units(tokenDecimals)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("amount %s has more than %d decimals", amountStr, tokenDecimals)})
+		return nil, false
+	}
+	// 借出的 SCOS 向下取整，不会超过抵押品按抵押率折算的价值
+	scosAmountWei := amount.Mul(price.Price).Div(money.FromFloat(risk.CollateralRatio)).ToUnits(scosDecimals, money.Down)
+
+	return &stakeQuote{
+		price:         price.Price,
+		amount:        amount,
+		scosAmount:    money.FromUnits(scosAmountWei, scosDecimals),
+		amountWei:     amountWei,
+		scosAmountWei: scosAmountWei,
+	}, true
 }
 
+// RedeemRequest 构造由用户钱包签名的 unstakeStock
 type RedeemRequest struct {
 	UserAddress  string `json:"user_address" binding:"required"`
 	TokenAddress string `json:"token_address" binding:"required"`
 	Chain        string `json:"chain" binding:"required"`
+	DryRun       bool   `json:"dry_run"`
 }
 
+// RedeemStock 返回用户自己调用 unstakeStock 的交易。金库在同一笔交易中销毁用户
+// 偿还剩余债务的 SCOS，因此先检查用户的 SCOS 余额与对金库的授权，不足时返回 422
 func (h *StakingHandler) RedeemStock(c *gin.Context) {
 	var req RedeemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -124,28 +169,83 @@ func (h *StakingHandler) RedeemStock(c *gin.Context) {
 		return
 	}
 
-	// 查找活跃质押
-	var stake models.StakeRecord
-	if err := h.db.Where("user_address = ? AND token_address = ? AND chain = ? AND status = ?",
-		req.UserAddress, req.TokenAddress, req.Chain, "active").First(&stake).Error; err != nil {
+	if err := h.bc.CheckChain(req.Chain); err != nil {
+		chainError(c, err, "Failed to build unstake transaction")
+		return
+	}
+	if !common.IsHexAddress(req.UserAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return
+	}
+
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+	info, err := h.bc.GetStakeInfo(ctx, req.Chain, req.UserAddress, req.TokenAddress)
+	if err != nil {
+		chainError(c, err, "Failed to read stake")
+		return
+	}
+	if !info.Active {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No active stake found"})
 		return
 	}
+	decimals, err := h.bc.SCOSDecimals(ctx, req.Chain)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS decimals")
+		return
+	}
+	if !h.checkSCOS(ctx, c, req.Chain, req.UserAddress, info.BorrowedSCOS, decimals) {
+		return
+	}
 
-	_, err := h.bc.UnstakeStock(req.Chain, req.TokenAddress)
+	tx, err := h.bc.BuildUnstakeTx(ctx, req.Chain, req.UserAddress, req.TokenAddress)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unstake on blockchain"})
+		chainError(c, err, "Failed to build unstake transaction")
 		return
 	}
 
-	// 更新状态
-	stake.Status = "redeemed"
-	stake.UpdatedAt = time.Now()
-	h.db.Save(&stake)
+	c.JSON(http.StatusOK, withDryRun(gin.H{
+		"tx":             unsignedTxJSON(tx),
+		"amount_wei":     info.Amount.String(),
+		"scos_repay":     money.FromUnits(info.BorrowedSCOS, decimals),
+		"scos_repay_wei": info.BorrowedSCOS.String(),
+	}, req.DryRun))
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"stake_id": stake.ID,
-		"status":   "redeemed",
-		"message":  "Stock redeemed successfully",
-	})
+// checkSCOS 用户需持有并授权金库转走 required（SCOS 最小单位），不足时写入 422 并返回 false
+func (h *StakingHandler) checkSCOS(ctx context.Context, c *gin.Context, chain, user string, required *big.Int, decimals uint8) bool {
+	if required.Sign() <= 0 {
+		return true
+	}
+	balance, err := h.bc.SCOSBalance(ctx, chain, user)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS balance")
+		return false
+	}
+	if balance.Cmp(required) < 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":         "Insufficient SCOS to repay",
+			"code":          "insufficient_scos",
+			"scos_required": money.FromUnits(required, decimals),
+			"scos_balance":  money.FromUnits(balance, decimals),
+		})
+		return false
+	}
+	allowance, err := h.bc.SCOSAllowance(ctx, chain, user)
+	if err != nil {
+		chainError(c, err, "Failed to read SCOS allowance")
+		return false
+	}
+	if allowance.Cmp(required) < 0 {
+		vault, _ := h.bc.GetVaultAddr(chain)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":          "Approve the vault to transfer the SCOS first",
+			"code":           "insufficient_allowance",
+			"spender":        vault.Hex(),
+			"scos_required":  money.FromUnits(required, decimals),
+			"scos_allowance": money.FromUnits(allowance, decimals),
+		})
+		return false
+	}
+	return true
 }
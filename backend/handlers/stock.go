@@ -1,21 +1,27 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
-	"strconv"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"scos/blockchain"
+	"scos/config"
 	"scos/models"
+	"scos/money"
 )
 
 type StockHandler struct {
-	db *gorm.DB
+	db       *gorm.DB
+	bc       blockchain.StockClient
+	timeouts config.TimeoutConfig
 }
 
-func NewStockHandler(db *gorm.DB) *StockHandler {
-	return &StockHandler{db: db}
+func NewStockHandler(db *gorm.DB, bc blockchain.StockClient, timeouts config.TimeoutConfig) *StockHandler {
+	return &StockHandler{db: db, bc: bc, timeouts: timeouts}
 }
 
 func (h *StockHandler) GetStockPrice(c *gin.Context) {
@@ -46,17 +52,23 @@ func (h *StockHandler) GetAllStockPrices(c *gin.Context) {
 	})
 }
 
+// UpdateStockPrice 管理接口：更新股票价格，并同步到 tokens 配置了该股票的每条链的金库。
+// 金库按链上价格限制质押可借的 SCOS；同步失败的链列在 failed 中，价格仍以数据库为准，可重试
 func (h *StockHandler) UpdateStockPrice(c *gin.Context) {
 	symbol := c.Param("symbol")
 
 	var req struct {
-		Price float64 `json:"price" binding:"required"`
+		Price money.Decimal `json:"price"` // 字符串或数字，按十进制精确解析
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Price.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price must be positive"})
+		return
+	}
 
 	var price models.TokenPrice
 	err := h.db.Where("symbol = ?", symbol).First(&price).Error
@@ -66,22 +78,51 @@ func (h *StockHandler) UpdateStockPrice(c *gin.Context) {
 			Price:     req.Price,
 			UpdatedAt: time.Now(),
 		}
-		h.db.Create(&price)
-	} else {
+		err = h.db.Create(&price).Error
+	} else if err == nil {
 		price.Price = req.Price
 		price.UpdatedAt = time.Now()
-		h.db.Save(&price)
+		err = h.db.Save(&price).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save price"})
+		return
+	}
+
+	ctx, cancel := withTimeout(c, h.timeouts.Write)
+	defer cancel()
+	chains := gin.H{}
+	failed := gin.H{}
+	for _, chain := range h.bc.Chains() {
+		token, ok := h.bc.TokenAddress(chain, symbol)
+		if !ok {
+			continue
+		}
+		txHash, err := h.bc.SetTokenPrice(ctx, chain, token, req.Price)
+		if err != nil {
+			log.Printf("Failed to push %s price to %s: %v", symbol, chain, err)
+			failed[chain] = err.Error()
+			continue
+		}
+		chains[chain] = txHash
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"symbol":     price.Symbol,
 		"price":      price.Price,
 		"updated_at": price.UpdatedAt,
+		"chains":     chains,
+		"failed":     failed,
 	})
 }
 
+// GetUserSCOSBalance 各可用链上的 SCOS 余额（balanceOf）之和，db_scos_borrowed 为数据库中活跃质押的借出总额
 func (h *StockHandler) GetUserSCOSBalance(c *gin.Context) {
 	userAddress := c.Param("address")
+	if !common.IsHexAddress(userAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return
+	}
 
 	var stakes []models.StakeRecord
 	if err := h.db.Where("user_address = ? AND status = ?", userAddress, "active").Find(&stakes).Error; err != nil {
@@ -89,15 +130,45 @@ func (h *StockHandler) GetUserSCOSBalance(c *gin.Context) {
 		return
 	}
 
-	totalSCOS := 0.0
+	var dbSCOS money.Decimal
 	for _, stake := range stakes {
-		scos, _ := strconv.ParseFloat(stake.SCOSBorrowed, 64)
-		totalSCOS += scos
+		dbSCOS = dbSCOS.Add(stake.SCOSBorrowed)
+	}
+
+	// 不可用或未配置 SCOS 的链跳过，并在 unavailable 中列出
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+	var total money.Decimal
+	chains := gin.H{}
+	unavailable := []string{}
+	for _, chain := range h.bc.Chains() {
+		scos, err := h.bc.SCOSAddress(chain)
+		if err != nil || scos == "" {
+			continue
+		}
+		decimals, err := h.bc.TokenDecimals(ctx, chain, scos)
+		if err != nil {
+			log.Printf("read SCOS decimals on %s: %v", chain, err)
+			unavailable = append(unavailable, chain)
+			continue
+		}
+		balance, err := h.bc.TokenBalance(ctx, chain, scos, userAddress)
+		if err != nil {
+			log.Printf("read SCOS balance on %s: %v", chain, err)
+			unavailable = append(unavailable, chain)
+			continue
+		}
+		amount := money.FromUnits(balance, decimals)
+		chains[chain] = amount
+		total = total.Add(amount)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"address":       userAddress,
-		"scos_balance":  totalSCOS,
-		"active_stakes": len(stakes),
+		"address":          userAddress,
+		"scos_balance":     total,
+		"chains":           chains,
+		"unavailable":      unavailable,
+		"active_stakes":    len(stakes),
+		"db_scos_borrowed": dbSCOS,
 	})
 }
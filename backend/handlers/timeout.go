@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withTimeout 以请求上下文为父创建操作上下文，客户端断开或服务关闭时一并取消；d 为 0 时不另设超时
+func withTimeout(c *gin.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+	return context.WithTimeout(c.Request.Context(), d)
+}
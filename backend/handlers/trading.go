@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -8,15 +9,20 @@ import (
 	"gorm.io/gorm"
 	"scos/blockchain"
 	"scos/models"
+	"scos/money"
 )
 
+// offchainStatus 不上链的买卖记录的状态
+const offchainStatus = "offchain"
+
 type TradingHandler struct {
-	db *gorm.DB
-	bc *blockchain.BlockchainClient
+	db      *gorm.DB
+	bc      blockchain.VaultClient
+	intents *intentVerifier
 }
 
-func NewTradingHandler(db *gorm.DB, bc *blockchain.BlockchainClient) *TradingHandler {
-	return &TradingHandler{db: db, bc: bc}
+func NewTradingHandler(db *gorm.DB, bc blockchain.VaultClient) *TradingHandler {
+	return &TradingHandler{db: db, bc: bc, intents: &intentVerifier{db: db, bc: bc}}
 }
 
 type BuyRequest struct {
@@ -24,6 +30,8 @@ type BuyRequest struct {
 	TokenAddress string `json:"token_address" binding:"required"`
 	Chain        string `json:"chain" binding:"required"`
 	Amount       string `json:"amount" binding:"required"`
+	DryRun       bool   `json:"dry_run"`
+	IntentAuth
 }
 
 func (h *TradingHandler) BuyStock(c *gin.Context) {
@@ -33,16 +41,42 @@ func (h *TradingHandler) BuyStock(c *gin.Context) {
 		return
 	}
 
-	// 记录交易
+	if !h.bc.HasChain(req.Chain) {
+		chainError(c, fmt.Errorf("%w: %s", blockchain.ErrUnknownChain, req.Chain), "Unknown chain")
+		return
+	}
+
+	if !validAmount(c, req.Amount) {
+		return
+	}
+
+	intent := Intent{Action: "buy", User: req.UserAddress, Token: req.TokenAddress, Amount: req.Amount, Chain: req.Chain}
+	if !h.intents.verify(c, intent, req.IntentAuth, !req.DryRun) {
+		return
+	}
+
+	// 买卖目前不上链，dry_run 只返回校验结果，不写库
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"status":  "success",
+			"message": "Buy order would be processed",
+		})
+		return
+	}
+
+	// 记录交易，买卖目前不上链，没有交易哈希，状态为 offchain，回执监控不会处理
 	tx := models.Transaction{
 		UserAddress: req.UserAddress,
 		Type:        "buy",
-		TxHash:      "pending",
 		Chain:       req.Chain,
-		Status:      "pending",
+		Status:      offchainStatus,
 		CreatedAt:   time.Now(),
 	}
-	h.db.Create(&tx)
+	if err := h.db.Create(&tx).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record buy order"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"transaction_id": tx.ID,
@@ -51,11 +85,23 @@ func (h *TradingHandler) BuyStock(c *gin.Context) {
 	})
 }
 
+// validAmount 数量须为正的十进制数，在校验签名前检查，无效请求不消耗 nonce
+func validAmount(c *gin.Context, amountStr string) bool {
+	amount, err := money.Parse(amountStr)
+	if err != nil || amount.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid amount %q", amountStr)})
+		return false
+	}
+	return true
+}
+
 type SellRequest struct {
 	UserAddress  string `json:"user_address" binding:"required"`
 	TokenAddress string `json:"token_address" binding:"required"`
 	Chain        string `json:"chain" binding:"required"`
 	Amount       string `json:"amount" binding:"required"`
+	DryRun       bool   `json:"dry_run"`
+	IntentAuth
 }
 
 func (h *TradingHandler) SellStock(c *gin.Context) {
@@ -65,16 +111,42 @@ func (h *TradingHandler) SellStock(c *gin.Context) {
 		return
 	}
 
-	// 记录交易
+	if !h.bc.HasChain(req.Chain) {
+		chainError(c, fmt.Errorf("%w: %s", blockchain.ErrUnknownChain, req.Chain), "Unknown chain")
+		return
+	}
+
+	if !validAmount(c, req.Amount) {
+		return
+	}
+
+	intent := Intent{Action: "sell", User: req.UserAddress, Token: req.TokenAddress, Amount: req.Amount, Chain: req.Chain}
+	if !h.intents.verify(c, intent, req.IntentAuth, !req.DryRun) {
+		return
+	}
+
+	// 买卖目前不上链，dry_run 只返回校验结果，不写库
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"status":  "success",
+			"message": "Sell order would be processed",
+		})
+		return
+	}
+
+	// 记录交易，买卖目前不上链，没有交易哈希，状态为 offchain，回执监控不会处理
 	tx := models.Transaction{
 		UserAddress: req.UserAddress,
 		Type:        "sell",
-		TxHash:      "pending",
 		Chain:       req.Chain,
-		Status:      "pending",
+		Status:      offchainStatus,
 		CreatedAt:   time.Now(),
 	}
-	h.db.Create(&tx)
+	if err := h.db.Create(&tx).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record sell order"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"transaction_id": tx.ID,
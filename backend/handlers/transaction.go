@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"scos/models"
+)
+
+type TransactionHandler struct {
+	db *gorm.DB
+}
+
+func NewTransactionHandler(db *gorm.DB) *TransactionHandler {
+	return &TransactionHandler{db: db}
+}
+
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	hash := c.Param("hash")
+
+	var tx models.Transaction
+	if err := h.db.Where("tx_hash = ? OR original_tx_hash = ?", hash, hash).First(&tx).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tx)
+}
+
+func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
+	userAddr := c.Param("user_addr")
+
+	var txs []models.Transaction
+	if err := h.db.Where("user_address = ?", userAddr).Order("created_at desc").Find(&txs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, txs)
+}
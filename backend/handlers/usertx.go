@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"scos/blockchain"
+	"scos/config"
+	"scos/models"
+	"scos/money"
+)
+
+// UserTxHandler 构造由用户钱包签名的交易并广播签名结果，链上 msg.sender 即用户本人
+type UserTxHandler struct {
+	db       *gorm.DB
+	bc       blockchain.UserTxClient
+	timeouts config.TimeoutConfig
+}
+
+func NewUserTxHandler(db *gorm.DB, bc blockchain.UserTxClient, timeouts config.TimeoutConfig) *UserTxHandler {
+	return &UserTxHandler{db: db, bc: bc, timeouts: timeouts}
+}
+
+type BuildApproveRequest struct {
+	UserAddress  string `json:"user_address" binding:"required"`
+	TokenAddress string `json:"token_address" binding:"required"`
+	Chain        string `json:"chain" binding:"required"`
+	Amount       string `json:"amount" binding:"required"`
+}
+
+type SubmitTxRequest struct {
+	Chain string `json:"chain" binding:"required"`
+	RawTx string `json:"raw_tx" binding:"required"` // 签名后的交易，0x 开头的 RLP 编码
+}
+
+// BuildApprove 返回授权金库转走代币的 approve 交易：质押前授权股票代币，赎回与还款前授权 SCOS。amount 按代币精度解析
+func (h *UserTxHandler) BuildApprove(c *gin.Context) {
+	var req BuildApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(req.UserAddress) || !common.IsHexAddress(req.TokenAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return
+	}
+
+	ctx, cancel := withTimeout(c, h.timeouts.Read)
+	defer cancel()
+	decimals, err := h.bc.TokenDecimals(ctx, req.Chain, req.TokenAddress)
+	if err != nil {
+		chainError(c, err, "Failed to read token decimals")
+		return
+	}
+	amount, err := parseUnits(req.Amount, decimals)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tx, err := h.bc.BuildApproveTx(ctx, req.Chain, req.UserAddress, req.TokenAddress, amount)
+	if err != nil {
+		chainError(c, err, "Failed to build approve transaction")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tx": unsignedTxJSON(tx)})
+}
+
+// Submit 广播用户签名的交易并记录，由回执监控跟踪；质押记录由索引器根据链上事件写入
+func (h *UserTxHandler) Submit(c *gin.Context) {
+	var req SubmitTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	raw, err := hexutil.Decode(req.RawTx)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "raw_tx must be 0x-prefixed hex"})
+		return
+	}
+
+	ctx, cancel := withTimeout(c, h.timeouts.Write)
+	defer cancel()
+	sent, err := h.bc.SubmitSignedTx(ctx, req.Chain, raw)
+	if errors.Is(err, blockchain.ErrUnsupportedTx) || errors.Is(err, blockchain.ErrTxRejected) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		chainError(c, err, "Failed to broadcast transaction")
+		return
+	}
+
+	tx := models.Transaction{
+		UserAddress: sent.From.Hex(),
+		Type:        sent.Type,
+		TxHash:      sent.Hash.Hex(),
+		Chain:       req.Chain,
+		Status:      "pending",
+		Source:      "user",
+		CreatedAt:   time.Now(),
+	}
+	// 交易已广播，记录失败时仍返回哈希，方便用户自行追踪
+	if err := h.db.Create(&tx).Error; err != nil {
+		log.Printf("Failed to record user transaction %s on %s: %v", tx.TxHash, req.Chain, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Transaction broadcast but not recorded",
+			"code":    "tx_not_recorded",
+			"tx_hash": tx.TxHash,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transaction_id": tx.ID,
+		"tx_hash":        tx.TxHash,
+		"from":           tx.UserAddress,
+		"type":           tx.Type,
+		"status":         tx.Status,
+	})
+}
+
+// unsignedTxJSON 钱包可直接签名的交易字段，数值为十进制字符串
+func unsignedTxJSON(tx *blockchain.UnsignedTx) gin.H {
+	out := gin.H{
+		"chain_id": tx.ChainID.String(),
+		"from":     tx.From.Hex(),
+		"to":       tx.To.Hex(),
+		"data":     hexutil.Encode(tx.Data),
+		"value":    tx.Value.String(),
+		"gas":      tx.Gas,
+		"nonce":    tx.Nonce,
+	}
+	if tx.Dynamic {
+		out["type"] = 2
+		out["max_fee_per_gas"] = tx.FeeCap.String()
+		out["max_priority_fee_per_gas"] = tx.TipCap.String()
+	} else {
+		out["type"] = 0
+		out["gas_price"] = tx.GasPrice.String()
+	}
+	return out
+}
+
+// parseUnits 把用户输入的正数金额按代币精度精确转换为最小单位，小数位超过精度时报错
+func parseUnits(s string, decimals uint8) (*big.Int, error) {
+	amount, err := money.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("amount %q must be positive", s)
+	}
+	units, err := amount.Units(decimals)
+	if err != nil {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", s, decimals)
+	}
+	return units, nil
+}
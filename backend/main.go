@@ -1,163 +1,139 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"scos/blockchain"
 	"scos/config"
-	"scos/handlers"
 	"scos/models"
+	"scos/money"
+	"scos/server"
 )
 
 func main() {
 	// 加载配置
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load config:\n", err)
+	}
 
 	// 初始化数据库
-	db, err := gorm.Open(sqlite.Open("scos.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(cfg.DB.Path), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
 	// 自动迁移
-	db.AutoMigrate(&models.User{}, &models.StakeRecord{}, &models.TokenPrice{}, &models.Transaction{})
+	if err := server.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	// 恢复管理接口记录的密钥轮换
+	if err := server.ApplyKeyRotations(db, cfg); err != nil {
+		log.Fatal("Failed to load key rotations:", err)
+	}
 
-	// 初始化区块链客户端
-	bc, err := blockchain.NewBlockchainClient(cfg.Chains, cfg.PrivateKey)
+	// 初始化签名器与区块链客户端
+	signers, err := blockchain.NewSigners(cfg)
+	if err != nil {
+		log.Fatal("Failed to create signer:", err)
+	}
+	bc, err := blockchain.NewBlockchainClient(cfg.Chains, signers)
 	if err != nil {
 		log.Fatal("Failed to create blockchain client:", err)
 	}
 
-	// 初始化处理器
-	stockHandler := handlers.NewStockHandler(db)
-	stakingHandler := handlers.NewStakingHandler(db, bc)
-	tradingHandler := handlers.NewTradingHandler(db, bc)
-
-	// 设置Gin
-	r := gin.Default()
-
-	// CORS配置
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
-	// API路由
-	api := r.Group("/api")
-	{
-		// Stock价格相关
-		api.GET("/stock/:symbol/price", stockHandler.GetStockPrice)
-		api.GET("/stocks/prices", stockHandler.GetAllStockPrices)
-		api.POST("/stock/:symbol/price", stockHandler.UpdateStockPrice)
-		api.GET("/user/:address/scos", stockHandler.GetUserSCOSBalance)
-
-		// 质押相关
-		api.GET("/stake/:user_addr", stakingHandler.GetStakeRecords)
-		api.POST("/stake", stakingHandler.StakeStock)
-		api.POST("/redeem", stakingHandler.RedeemStock)
-
-		// 交易相关
-		api.POST("/buy", tradingHandler.BuyStock)
-		api.POST("/sell", tradingHandler.SellStock)
-	}
+	// API 路由
+	r := server.New(db, bc, cfg)
 
 	// 初始化一些测试数据
 	initTestData(db)
 
+	// 收到 SIGINT/SIGTERM 时取消根上下文：后台任务退出，进行中请求的 RPC 一并中止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	background := func(run func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run()
+		}()
+	}
+
 	// 启动清算监控
-	go startLiquidationMonitor(db, bc)
+	background(func() { server.StartLiquidationMonitor(ctx, db, bc, cfg.Risk, cfg.Timeouts) })
+
+	// 启动 RPC 节点健康探测，不可用的链在后台重连
+	background(func() { bc.RunHealthChecks(ctx, cfg.Health) })
+
+	// 启动交易回执监控
+	background(func() { blockchain.NewReceiptWatcher(db, bc, cfg.Watcher).Run(ctx) })
+
+	// 启动金库事件索引
+	background(func() { blockchain.NewIndexer(db, bc, cfg.Indexer).Run(ctx) })
+
+	// 请求上下文派生自根上下文，关闭时客户端断开与否都会取消
+	srv := &http.Server{
+		Addr:        ":" + cfg.Port,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down")
 
-	log.Printf("Server starting on port %s", cfg.Port)
-	r.Run(":" + cfg.Port)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		log.Printf("Background tasks did not stop within %s", cfg.Timeouts.Shutdown)
+	}
 }
 
 func initTestData(db *gorm.DB) {
 	// 初始化三个股票价格
 	stocks := []models.TokenPrice{
-		{Symbol: "APPLE", Price: 3000.0, UpdatedAt: time.Now()},
-		{Symbol: "GOOGLE", Price: 3000.0, UpdatedAt: time.Now()},
-		{Symbol: "MICROSOFT", Price: 3000.0, UpdatedAt: time.Now()},
+		{Symbol: "APPLE", Price: money.NewFromInt(3000), UpdatedAt: time.Now()},
+		{Symbol: "GOOGLE", Price: money.NewFromInt(3000), UpdatedAt: time.Now()},
+		{Symbol: "MICROSOFT", Price: money.NewFromInt(3000), UpdatedAt: time.Now()},
 	}
 
 	for _, stock := range stocks {
 		var existingStock models.TokenPrice
 		if err := db.Where("symbol = ?", stock.Symbol).First(&existingStock).Error; err == gorm.ErrRecordNotFound {
 			db.Create(&stock)
-			fmt.Printf("Initialized %s stock price: $%.2f\n", stock.Symbol, stock.Price)
-		}
-	}
-}
-
-func startLiquidationMonitor(db *gorm.DB, bc *blockchain.BlockchainClient) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		checkLiquidations(db, bc)
-	}
-}
-
-func checkLiquidations(db *gorm.DB, bc *blockchain.BlockchainClient) {
-	var stakes []models.StakeRecord
-	db.Where("status = ?", "active").Find(&stakes)
-
-	// 获取所有当前股票价格
-	var currentPrices []models.TokenPrice
-	db.Find(&currentPrices)
-
-	priceMap := make(map[string]float64)
-	for _, price := range currentPrices {
-		priceMap[price.Symbol] = price.Price
-	}
-
-	for _, stake := range stakes {
-		// 解析质押时的价格和当前价格
-		stakePrice := 0.0
-		if _, err := fmt.Sscanf(stake.StakePrice, "%f", &stakePrice); err != nil {
-			continue
-		}
-
-		// 根据stake记录中的TokenAddress确定股票类型
-		// 这里需要一个映射关系，或者在StakeRecord中添加Symbol字段
-		currentPrice := 3000.0 // 默认价格，实际应该根据token地址查询
-
-		// 检查是否跌价超过25%
-		priceDropRatio := (stakePrice - currentPrice) / stakePrice
-		if priceDropRatio > 0.25 {
-			// 执行清算
-			log.Printf("Liquidating stake %d due to price drop: %.2f%%", stake.ID, priceDropRatio*100)
-
-			txHash, err := bc.Liquidate(stake.Chain, stake.UserAddress, stake.TokenAddress)
-			if err != nil {
-				log.Printf("Failed to liquidate stake %d: %v", stake.ID, err)
-				continue
-			}
-
-			stake.Status = "liquidated"
-			stake.UpdatedAt = time.Now()
-			db.Save(&stake)
-
-			// 记录清算交易
-			tx := models.Transaction{
-				UserAddress: stake.UserAddress,
-				Type:        "liquidate",
-				TxHash:      txHash,
-				Chain:       stake.Chain,
-				Status:      "completed",
-				CreatedAt:   time.Now(),
-			}
-			db.Create(&tx)
+			fmt.Printf("Initialized %s stock price: $%s\n", stock.Symbol, stock.Price.StringFixed(2, money.HalfEven))
 		}
 	}
 }
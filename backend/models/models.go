@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"scos/money"
 )
 
 type User struct {
@@ -11,33 +13,106 @@ type User struct {
 }
 
 type StakeRecord struct {
-	ID              uint      `json:"id" gorm:"primaryKey"`
-	UserAddress     string    `json:"user_address"`
-	TokenAddress    string    `json:"token_address"`
-	StockSymbol     string    `json:"stock_symbol"`
-	Chain           string    `json:"chain"`
-	ContractAddress string    `json:"contract_address"`
-	Amount          string    `json:"amount"`
-	SCOSBorrowed    string    `json:"scos_borrowed"`
-	Status          string    `json:"status"` // active, redeemed, liquidated
-	StakePrice      string    `json:"stake_price"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	UserAddress     string        `json:"user_address"`
+	TokenAddress    string        `json:"token_address"`
+	StockSymbol     string        `json:"stock_symbol"`
+	Chain           string        `json:"chain"`
+	ContractAddress string        `json:"contract_address"`
+	Amount          money.Decimal `json:"amount"`        // 质押的股票代币数量
+	SCOSBorrowed    money.Decimal `json:"scos_borrowed"` // 借出的 SCOS，按 SCOS 精度向下取整
+	Status          string        `json:"status"`        // active, redeemed, liquidated
+	StakePrice      money.Decimal `json:"stake_price"`   // 索引到质押时的股票价格，代币未配置股票代码时为 0
+	Staker          string        `json:"staker"`        // 链上 msg.sender，即用户钱包，由索引器写入
+	TxHash          string        `json:"tx_hash"`       // 质押交易哈希
+	BlockNumber     uint64        `json:"block_number"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
 }
 
 type TokenPrice struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Symbol    string    `json:"symbol" gorm:"unique"`
-	Price     float64   `json:"price"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	Symbol    string        `json:"symbol" gorm:"unique"`
+	Price     money.Decimal `json:"price"`
+	UpdatedAt time.Time     `json:"updated_at"`
 }
 
 type Transaction struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	UserAddress       string    `json:"user_address"`
+	Type              string    `json:"type"` // stake, unstake, withdraw, repay, buy, sell, liquidate, cancel, approve, mint
+	TxHash            string    `json:"tx_hash" gorm:"index"`
+	OriginalTxHash    string    `json:"original_tx_hash,omitempty" gorm:"index"` // 被加价替换前最初返回给调用方的哈希
+	Chain             string    `json:"chain"`
+	Status            string    `json:"status"` // pending, confirmed, failed, dropped；queued 为索引器已排队、尚未发送的 mint；offchain 为不上链的买卖
+	BlockNumber       uint64    `json:"block_number"`
+	GasUsed           uint64    `json:"gas_used"`
+	EffectiveGasPrice string    `json:"effective_gas_price"`
+	RevertReason      string    `json:"revert_reason"`
+	Source            string    `json:"source"`                             // api（默认）、user（用户签名后经 /api/tx/submit 广播）或 indexer
+	StakeID           uint      `json:"stake_id,omitempty" gorm:"index"`    // 质押、赎回、部分取回、还款及对应 mint 所属的质押记录
+	LinkedTxID        uint      `json:"linked_tx_id,omitempty"`             // 同一操作的另一笔交易：stake 与 mint 互相指向
+	StakeEvent        string    `json:"stake_event,omitempty" gorm:"index"` // mint 对应的 StockStaked 事件（交易哈希:日志序号），重组后重新索引时据此去重
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type KeyRotation struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Chain      string    `json:"chain"`
+	SignerName string    `json:"signer_name"`
+	OldAddress string    `json:"old_address"`
+	NewAddress string    `json:"new_address"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IndexCheckpoint 每条链金库事件已索引到的区块
+type IndexCheckpoint struct {
+	Chain     string    `json:"chain" gorm:"primaryKey"`
+	Block     uint64    `json:"block"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VaultEvent StockVault 合约事件原始记录
+type VaultEvent struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Chain        string    `json:"chain" gorm:"uniqueIndex:idx_vault_event_log"`
+	TxHash       string    `json:"tx_hash" gorm:"uniqueIndex:idx_vault_event_log"`
+	LogIndex     uint      `json:"log_index" gorm:"uniqueIndex:idx_vault_event_log"`
+	BlockNumber  uint64    `json:"block_number" gorm:"index"`
+	BlockHash    string    `json:"block_hash"`
+	Event        string    `json:"event"` // StockStaked, StockUnstaked, StockWithdrawn, DebtRepaid, Liquidated
+	UserAddress  string    `json:"user_address"`
+	TokenAddress string    `json:"token_address"`
+	Amount       string    `json:"amount"`              // 链上最小单位
+	BorrowedSCOS string    `json:"borrowed_scos"`       // 链上最小单位，StockStaked 为借出数量，DebtRepaid 为还款数量
+	Remaining    string    `json:"remaining,omitempty"` // StockWithdrawn 剩余质押数量或 DebtRepaid 剩余债务，链上最小单位
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IndexedBlock 索引器处理过的区块哈希，用于发现链重组
+type IndexedBlock struct {
+	Chain      string `json:"chain" gorm:"primaryKey"`
+	Number     uint64 `json:"number" gorm:"primaryKey;autoIncrement:false"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parent_hash"`
+}
+
+// StakeChange 索引器对 StakeRecord 的修改记录，重组回滚时按倒序恢复
+type StakeChange struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Chain       string `json:"chain" gorm:"index"`
+	BlockNumber uint64 `json:"block_number" gorm:"index"`
+	StakeID     uint   `json:"stake_id"`
+	Before      string `json:"before"` // 修改前记录的 JSON，为空表示由索引器新建
+}
+
+// IntentNonce 已使用的 EIP-712 意图 nonce，同一用户的 nonce 只能使用一次
+type IntentNonce struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
-	UserAddress string    `json:"user_address"`
-	Type        string    `json:"type"` // stake, unstake, buy, sell, liquidate
-	TxHash      string    `json:"tx_hash"`
+	UserAddress string    `json:"user_address" gorm:"uniqueIndex:idx_intent_nonce"` // 小写
+	Nonce       string    `json:"nonce" gorm:"uniqueIndex:idx_intent_nonce"`        // 规范十进制 uint256，同一用户内唯一，不同用户可以重复
+	Action      string    `json:"action"`                                           // buy, sell；较早的记录可能为 stake, redeem
 	Chain       string    `json:"chain"`
-	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 }
@@ -0,0 +1,249 @@
+// Package money 提供金额与价格使用的十进制定点数
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// Rounding 把金额截取到指定小数位时的舍入方式
+type Rounding int
+
+const (
+	// Down 向零截断，用于系统付出的金额（如可借出的 SCOS），宁少不多
+	Down Rounding = iota
+	// Up 远离零进位，用于用户应付的金额（如还款、所需抵押）
+	Up
+	// HalfEven 四舍六入五成双，仅用于展示
+	HalfEven
+)
+
+// MaxScale String 对无限小数（如除以 3）保留的位数
+const MaxScale = 18
+
+var plainDecimal = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Decimal 精确的十进制数。加减乘除在有理数上进行，不会产生误差；只有 Round、ToUnits 等
+// 截取小数位的操作按调用方给出的 Rounding 舍入。零值表示 0
+type Decimal struct {
+	r *big.Rat
+}
+
+// Parse 解析普通十进制字符串（如 "1.5"、"-2"），不接受指数、分数或空字符串
+func Parse(s string) (Decimal, error) {
+	if !plainDecimal.MatchString(s) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	r, _ := new(big.Rat).SetString(s)
+	return Decimal{r}, nil
+}
+
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func NewFromInt(v int64) Decimal {
+	return Decimal{new(big.Rat).SetInt64(v)}
+}
+
+// FromFloat 按 float64 的最短十进制表示转换（1.4 得到 1.4 而不是 1.399999...），用于配置中的比例
+func FromFloat(f float64) Decimal {
+	return MustParse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// FromUnits 链上最小单位转换为金额，如 decimals 为 6 时 1500000 -> 1.5
+func FromUnits(v *big.Int, decimals uint8) Decimal {
+	if v == nil {
+		return Decimal{}
+	}
+	return Decimal{new(big.Rat).SetFrac(v, pow10(int(decimals)))}
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+	return d.r
+}
+
+func (d Decimal) Add(o Decimal) Decimal { return Decimal{new(big.Rat).Add(d.rat(), o.rat())} }
+func (d Decimal) Sub(o Decimal) Decimal { return Decimal{new(big.Rat).Sub(d.rat(), o.rat())} }
+func (d Decimal) Mul(o Decimal) Decimal { return Decimal{new(big.Rat).Mul(d.rat(), o.rat())} }
+
+// Div 精确除法，除数为 0 时 panic
+func (d Decimal) Div(o Decimal) Decimal { return Decimal{new(big.Rat).Quo(d.rat(), o.rat())} }
+
+func (d Decimal) Cmp(o Decimal) int { return d.rat().Cmp(o.rat()) }
+func (d Decimal) Sign() int         { return d.rat().Sign() }
+func (d Decimal) IsZero() bool      { return d.Sign() == 0 }
+
+// Round 保留 places 位小数
+func (d Decimal) Round(places int, mode Rounding) Decimal {
+	return Decimal{new(big.Rat).SetFrac(d.scaled(places, mode), pow10(places))}
+}
+
+// ToUnits 按代币精度转换为链上最小单位，多出的小数位按 mode 舍入
+func (d Decimal) ToUnits(decimals uint8, mode Rounding) *big.Int {
+	return d.scaled(int(decimals), mode)
+}
+
+// Units 按代币精度精确转换为链上最小单位，小数位超过精度时返回错误
+func (d Decimal) Units(decimals uint8) (*big.Int, error) {
+	v := new(big.Rat).Mul(d.rat(), new(big.Rat).SetInt(pow10(int(decimals))))
+	if !v.IsInt() {
+		return nil, fmt.Errorf("%s has more than %d decimals", d, decimals)
+	}
+	return new(big.Int).Set(v.Num()), nil
+}
+
+// scaled 返回 d x 10^places 按 mode 取整后的整数
+func (d Decimal) scaled(places int, mode Rounding) *big.Int {
+	v := new(big.Rat).Mul(d.rat(), new(big.Rat).SetInt(pow10(places)))
+	q, rem := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+	away := false
+	switch mode {
+	case Up:
+		away = true
+	case HalfEven:
+		twice := new(big.Int).Lsh(new(big.Int).Abs(rem), 1)
+		c := twice.Cmp(v.Denom())
+		away = c > 0 || (c == 0 && q.Bit(0) == 1)
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(v.Sign())))
+	}
+	return q
+}
+
+// String 有限小数原样输出并去掉末尾的 0；无限小数按 HalfEven 保留 MaxScale 位
+func (d Decimal) String() string {
+	r := d.rat()
+	places, ok := terminatingPlaces(r.Denom())
+	if !ok {
+		return trimZeros(d.StringFixed(MaxScale, HalfEven))
+	}
+	return trimZeros(r.FloatString(places))
+}
+
+// StringFixed 固定输出 places 位小数
+func (d Decimal) StringFixed(places int, mode Rounding) string {
+	return d.Round(places, mode).rat().FloatString(places)
+}
+
+// MarshalJSON 输出为字符串，避免客户端按浮点数解析丢失精度
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON 接受字符串或数字，数字按原始文本解析
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value 以十进制字符串存储
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan 读取字符串列；旧版本以浮点数存储的价格按最短十进制表示转换，空字符串视为 0
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	case float64:
+		*d = FromFloat(v)
+	case int64:
+		*d = NewFromInt(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+	return nil
+}
+
+func (d *Decimal) scanString(s string) error {
+	if s == "" {
+		*d = Decimal{}
+		return nil
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// GormDataType 数据库列类型
+func (Decimal) GormDataType() string {
+	return "text"
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// terminatingPlaces 分母只含因子 2 和 5 时返回精确表示所需的小数位数
+func terminatingPlaces(den *big.Int) (int, bool) {
+	n := new(big.Int).Set(den)
+	two, five := 0, 0
+	for n.Bit(0) == 0 && n.Sign() > 0 {
+		n.Rsh(n, 1)
+		two++
+	}
+	m := new(big.Int)
+	for {
+		q, r := new(big.Int).QuoRem(n, big.NewInt(5), m)
+		if r.Sign() != 0 {
+			break
+		}
+		n = q
+		five++
+	}
+	if n.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	return max(two, five), true
+}
+
+func trimZeros(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '.' {
+			j := len(s)
+			for j > i+1 && s[j-1] == '0' {
+				j--
+			}
+			if j == i+1 {
+				j = i
+			}
+			return s[:j]
+		}
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/models"
+	"scos/money"
+)
+
+// StartLiquidationMonitor 按 risk.MonitorInterval 定期检查清算，ctx 取消时返回
+func StartLiquidationMonitor(ctx context.Context, db *gorm.DB, bc blockchain.LiquidationClient, risk config.RiskConfig, timeouts config.TimeoutConfig) {
+	ticker := time.NewTicker(risk.MonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			CheckLiquidations(ctx, db, bc, risk, timeouts)
+		}
+	}
+}
+
+// CheckLiquidations 按当前价格计算仓位健康度 = 抵押品 x 价格 / (债务 x 抵押率)，
+// 低于 1 - 清算阈值且链上仍活跃的仓位发送清算交易。质押时健康度不低于 1，没有债务的仓位不会被清算
+func CheckLiquidations(ctx context.Context, db *gorm.DB, bc blockchain.LiquidationClient, risk config.RiskConfig, timeouts config.TimeoutConfig) {
+	var stakes []models.StakeRecord
+	db.Where("status = ?", "active").Find(&stakes)
+
+	// 获取所有当前股票价格
+	var currentPrices []models.TokenPrice
+	db.Find(&currentPrices)
+
+	priceMap := make(map[string]money.Decimal)
+	for _, price := range currentPrices {
+		priceMap[price.Symbol] = price.Price
+	}
+
+	// 先按价格筛出需要清算的仓位，按链分组
+	type candidate struct {
+		stake  models.StakeRecord
+		health money.Decimal
+	}
+	ratio := money.FromFloat(risk.CollateralRatio)
+	minHealth := money.NewFromInt(1).Sub(money.FromFloat(risk.LiquidationThreshold))
+	candidates := make(map[string][]candidate)
+	for _, stake := range stakes {
+		if stake.SCOSBorrowed.Sign() <= 0 {
+			continue
+		}
+
+		// 按质押时的股票代码查询当前价格，没有价格时跳过
+		currentPrice, ok := priceMap[stake.StockSymbol]
+		if !ok {
+			continue
+		}
+
+		health := stake.Amount.Mul(currentPrice).Div(stake.SCOSBorrowed.Mul(ratio))
+		if health.Cmp(minHealth) < 0 {
+			// 链不可用时跳过，下一轮再检查
+			if !bc.Available(stake.Chain) {
+				continue
+			}
+			candidates[stake.Chain] = append(candidates[stake.Chain], candidate{stake, health})
+		}
+	}
+
+	for chain, list := range candidates {
+		if ctx.Err() != nil {
+			return
+		}
+		// 每条链一次批量读取候选仓位的链上状态，链上已关闭的仓位不再发送清算交易
+		operator, err := bc.SignerAddress(chain)
+		if err != nil {
+			continue
+		}
+		keys := make([]blockchain.StakeKey, len(list))
+		for i, cand := range list {
+			staker := operator
+			if cand.stake.Staker != "" {
+				staker = common.HexToAddress(cand.stake.Staker)
+			}
+			keys[i] = blockchain.StakeKey{Staker: staker, Token: common.HexToAddress(cand.stake.TokenAddress)}
+		}
+		readCtx, cancel := context.WithTimeout(ctx, timeouts.Read)
+		infos, err := bc.GetStakeInfos(readCtx, chain, keys)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to read stakes on %s: %v", chain, err)
+			continue
+		}
+
+		for i, cand := range list {
+			stake := cand.stake
+			if infos[i].Err != nil {
+				log.Printf("Failed to read stake %d on chain: %v", stake.ID, infos[i].Err)
+				continue
+			}
+			if !infos[i].Value.Active {
+				log.Printf("Stake %d is no longer active on chain, skipping liquidation", stake.ID)
+				continue
+			}
+			// 上一轮发出的清算交易尚未上链时不重复发送
+			var inflight int64
+			if err := db.Model(&models.Transaction{}).Where("type = ? AND stake_id = ? AND status = ?", "liquidate", stake.ID, "pending").
+				Count(&inflight).Error; err != nil {
+				log.Printf("Failed to check pending liquidation for stake %d: %v", stake.ID, err)
+				continue
+			}
+			if inflight > 0 {
+				continue
+			}
+
+			// 执行清算
+			log.Printf("Liquidating stake %d, health %s%%", stake.ID, cand.health.Mul(money.NewFromInt(100)).StringFixed(2, money.HalfEven))
+
+			writeCtx, cancel := context.WithTimeout(ctx, timeouts.Write)
+			// 链上仓位记在实际调用 stakeStock 的地址名下
+			txHash, err := bc.Liquidate(writeCtx, stake.Chain, keys[i].Staker.Hex(), stake.TokenAddress)
+			cancel()
+			if err != nil {
+				log.Printf("Failed to liquidate stake %d: %v", stake.ID, err)
+				continue
+			}
+
+			// 记录清算交易；仓位状态由索引器根据 Liquidated 事件更新，交易 revert 或被重组掉时仓位仍保持活跃
+			tx := models.Transaction{
+				UserAddress: stake.UserAddress,
+				Type:        "liquidate",
+				TxHash:      txHash,
+				Chain:       stake.Chain,
+				Status:      "pending",
+				StakeID:     stake.ID,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if err := db.Create(&tx).Error; err != nil {
+				log.Printf("Failed to record liquidation tx %s for stake %d: %v", txHash, stake.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/handlers"
+	"scos/models"
+)
+
+// Migrate 创建或更新服务使用的所有表
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.User{}, &models.StakeRecord{}, &models.TokenPrice{}, &models.Transaction{}, &models.KeyRotation{},
+		&models.IndexCheckpoint{}, &models.VaultEvent{}, &models.IndexedBlock{}, &models.StakeChange{}, &models.IntentNonce{}); err != nil {
+		return err
+	}
+	// 早期的买卖记录以 pending 写入且没有交易哈希，永远不会确认，改为 offchain
+	return db.Model(&models.Transaction{}).
+		Where("type IN ? AND status = ? AND tx_hash = ?", []string{"buy", "sell"}, "pending", "").
+		Update("status", "offchain").Error
+}
+
+// ApplyKeyRotations 把 key_rotations 中每条链最近一次轮换的签名器写入 cfg，在创建签名器与区块链客户端之前调用，
+// 使管理接口的轮换在重启后仍然有效。运行时添加的链不在配置中，其轮换随之丢失；签名器已从配置删除时保留配置文件的设置
+func ApplyKeyRotations(db *gorm.DB, cfg *config.Config) error {
+	var rotations []models.KeyRotation
+	if err := db.Order("id").Find(&rotations).Error; err != nil {
+		return err
+	}
+	latest := make(map[string]models.KeyRotation)
+	for _, r := range rotations {
+		latest[r.Chain] = r
+	}
+
+	for chain, r := range latest {
+		info, ok := cfg.Chains[chain]
+		if !ok {
+			continue
+		}
+		if _, ok := cfg.SignerConfig(r.SignerName); !ok {
+			log.Printf("chain %s: rotated signer %q is no longer configured, keeping %q", chain, r.SignerName, info.Signer)
+			continue
+		}
+		// 未单独配置 scos_signer（或与运营账户相同）时 SCOS 的 mint 随轮换一起切换，重启后保持一致
+		if info.SCOSSigner == "" || signerKey(info.SCOSSigner) == signerKey(info.Signer) {
+			info.SCOSSigner = ""
+		}
+		info.Signer = r.SignerName
+		cfg.Chains[chain] = info
+		log.Printf("chain %s: using rotated signer %s (%s)", chain, r.SignerName, r.NewAddress)
+	}
+	return nil
+}
+
+// signerKey 空名称即默认签名器
+func signerKey(name string) string {
+	if name == "" {
+		return config.DefaultSigner
+	}
+	return name
+}
+
+// New 创建注册了全部 API 路由的 Gin 引擎，main 与端到端测试共用
+func New(db *gorm.DB, bc blockchain.Client, cfg *config.Config) *gin.Engine {
+	// 初始化处理器
+	stockHandler := handlers.NewStockHandler(db, bc, cfg.Timeouts)
+	stakingHandler := handlers.NewStakingHandler(db, bc, cfg.Risk, cfg.Timeouts)
+	tradingHandler := handlers.NewTradingHandler(db, bc)
+	adminHandler := handlers.NewAdminHandler(db, bc, cfg)
+	txHandler := handlers.NewTransactionHandler(db)
+	chainHandler := handlers.NewChainHandler(db, bc, cfg.Timeouts)
+	userTxHandler := handlers.NewUserTxHandler(db, bc, cfg.Timeouts)
+
+	// 设置Gin
+	r := gin.Default()
+
+	// CORS配置
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{"*"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// API路由
+	api := r.Group("/api")
+	{
+		// Stock价格相关
+		api.GET("/stock/:symbol/price", stockHandler.GetStockPrice)
+		api.GET("/stocks/prices", stockHandler.GetAllStockPrices)
+		api.GET("/user/:address/scos", stockHandler.GetUserSCOSBalance)
+
+		// 质押相关：stake 与 redeem 返回由用户钱包签名的交易，签名后经 /tx/submit 广播
+		api.GET("/stake/:user_addr", stakingHandler.GetStakeRecords)
+		api.POST("/stake", stakingHandler.StakeStock)
+		api.POST("/redeem", stakingHandler.RedeemStock)
+		api.POST("/repay", stakingHandler.RepayDebt)
+		api.POST("/withdraw", stakingHandler.WithdrawStock)
+
+		// 交易相关
+		api.POST("/buy", tradingHandler.BuyStock)
+		api.POST("/sell", tradingHandler.SellStock)
+
+		// 交易状态
+		api.GET("/tx/:hash", txHandler.GetTransaction)
+		api.GET("/transactions/:user_addr", txHandler.GetUserTransactions)
+
+		// 由用户钱包签名的交易
+		api.POST("/tx/build/approve", userTxHandler.BuildApprove)
+		api.POST("/tx/submit", userTxHandler.Submit)
+
+		// 链与 RPC 节点状态
+		api.GET("/chains/status", chainHandler.GetStatus)
+		api.GET("/intents/domain/:chain", chainHandler.GetIntentDomain)
+		api.GET("/chain/:chain/position/:user/:token", chainHandler.GetPosition)
+		api.GET("/chain/:chain/balance/:token/:user", chainHandler.GetBalance)
+	}
+
+	// 管理接口，未配置 admin_token 时不开放
+	if cfg.AdminToken != "" {
+		admin := api.Group("/admin", handlers.AdminAuth(cfg.AdminToken))
+		{
+			admin.POST("/stock/:symbol/price", stockHandler.UpdateStockPrice)
+			admin.POST("/chains/:chain/rotate-signer", adminHandler.RotateSigner)
+			admin.POST("/chains/:chain/cancel-tx", adminHandler.CancelTransaction)
+			admin.POST("/chains/:chain", adminHandler.AddChain)
+			admin.POST("/chains/:chain/enable", adminHandler.EnableChain)
+			admin.POST("/chains/:chain/disable", adminHandler.DisableChain)
+			admin.DELETE("/chains/:chain", adminHandler.RemoveChain)
+		}
+	} else {
+		log.Printf("admin_token not set, admin API disabled")
+	}
+
+	return r
+}
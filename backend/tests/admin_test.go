@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"encoding/hex"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/handlers"
+	"scos/models"
+	"scos/server"
+)
+
+// rotationConfig 默认签名器与具名签名器 op2 都从环境变量读取私钥
+func rotationConfig(t *testing.T) *config.Config {
+	for _, env := range []string{"SCOS_TEST_DEFAULT_KEY", "SCOS_TEST_OP2_KEY"} {
+		key, _ := crypto.GenerateKey()
+		t.Setenv(env, hex.EncodeToString(crypto.FromECDSA(key)))
+	}
+	return &config.Config{
+		AdminToken: "secret",
+		Signer:     config.SignerConfig{Type: "env", Env: "SCOS_TEST_DEFAULT_KEY"},
+		Signers:    map[string]config.SignerConfig{"op2": {Type: "env", Env: "SCOS_TEST_OP2_KEY"}},
+		Chains:     map[string]config.ChainInfo{"sim": {RPC: "http://sim", ChainID: "1337", VaultAddress: APPLEtokenAddr}},
+	}
+}
+
+func newRotationClient(t *testing.T, cfg *config.Config) *blockchain.BlockchainClient {
+	signers, err := blockchain.NewSigners(cfg)
+	require.NoError(t, err)
+	bc, err := blockchain.NewBlockchainClient(cfg.Chains, signers)
+	require.NoError(t, err)
+	return bc
+}
+
+func newRotationRouter(db *gorm.DB, bc *blockchain.BlockchainClient, cfg *config.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := handlers.NewAdminHandler(db, bc, cfg)
+	r.POST("/api/admin/chains/:chain/rotate-signer", handlers.AdminAuth(cfg.AdminToken), admin.RotateSigner)
+	return r
+}
+
+func TestAdmin_RotateSignerSurvivesRestart(t *testing.T) {
+	node := newSimChain(t, 1)
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return node, nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, server.Migrate(db))
+
+	cfg := rotationConfig(t)
+	bc := newRotationClient(t, cfg)
+	r := newRotationRouter(db, bc, cfg)
+	before, err := bc.SignerAddress("sim")
+	require.NoError(t, err)
+
+	code, _ := doJSON(r, http.MethodPost, "/api/admin/chains/sim/rotate-signer", gin.H{"signer": "missing"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, resp := doJSON(r, http.MethodPost, "/api/admin/chains/Reddoi/rotate-signer", gin.H{"signer": "op2"})
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "unknown_chain", resp["code"])
+
+	code, resp = doJSON(r, http.MethodPost, "/api/admin/chains/sim/rotate-signer", gin.H{"signer": "op2"})
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, before.Hex(), resp["old_address"])
+	rotated, err := bc.SignerAddress("sim")
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Hex(), resp["new_address"])
+	assert.NotEqual(t, before, rotated)
+
+	// 重启：配置文件仍指向默认签名器，启动时按记录恢复轮换
+	restarted := &config.Config{Signer: cfg.Signer, Signers: cfg.Signers, Chains: map[string]config.ChainInfo{"sim": cfg.Chains["sim"]}}
+	require.NoError(t, server.ApplyKeyRotations(db, restarted))
+	assert.Equal(t, "op2", restarted.Chains["sim"].Signer)
+	address, err := newRotationClient(t, restarted).SignerAddress("sim")
+	require.NoError(t, err)
+	assert.Equal(t, rotated, address)
+
+	// 记录写入失败时报告错误：内存中已切换，重启后会恢复为上一次记录的签名器
+	require.NoError(t, db.Migrator().DropTable(&models.KeyRotation{}))
+	code, resp = doJSON(r, http.MethodPost, "/api/admin/chains/sim/rotate-signer", gin.H{"signer": config.DefaultSigner})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "rotation_not_recorded", resp["code"])
+	assert.Equal(t, before.Hex(), resp["new_address"])
+}
+
+func TestAdmin_ApplyKeyRotationsSkipsUnknownSigners(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, server.Migrate(db))
+	require.NoError(t, db.Create(&[]models.KeyRotation{
+		{Chain: "sim", SignerName: "op2"},
+		{Chain: "sim", SignerName: "removed"},
+		{Chain: "gone", SignerName: "op2"},
+	}).Error)
+
+	cfg := rotationConfig(t)
+	require.NoError(t, server.ApplyKeyRotations(db, cfg))
+	assert.Equal(t, "", cfg.Chains["sim"].Signer)
+	assert.NotContains(t, cfg.Chains, "gone")
+}
+
+func TestAdmin_CancelTransactionReportsUnrecordedCancel(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{StuckTimeout: time.Hour})
+	original := sendOperatorTx(t, bc)
+
+	// 没有 transactions 表：取消交易已广播但无法记录
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/admin/chains/:chain/cancel-tx", handlers.NewAdminHandler(db, bc, &config.Config{}).CancelTransaction)
+
+	code, resp := doJSON(r, http.MethodPost, "/api/admin/chains/sim/cancel-tx", gin.H{"tx_hash": original.Hex()})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "tx_not_recorded", resp["code"])
+	assert.Equal(t, original.Hex(), resp["cancelled_tx_hash"])
+	sent := node.sentTxs()
+	require.Len(t, sent, 2)
+	assert.Equal(t, sent[1].Hash().Hex(), resp["tx_hash"])
+}
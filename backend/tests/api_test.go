@@ -4,115 +4,72 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/contracts"
 )
 
+// 不依赖链上状态的单元测试中使用的占位地址
 const (
-	baseURL        = "http://localhost:8080"
-	userAddr       = "0x36a15f8d63742eaabf9ebb32a8551db13d6a3167"
 	SCOStokenAddr  = "0xeB5e9Af4b798ec27A0f24DA22C7A7b3b657D05d9"
 	APPLEtokenAddr = "0xE49f7C3b573bb2a4A54dbCCA9c06e5fc84C537DD"
 	symbol         = "APPLE"
-	chainName      = "Reddio"
 )
 
 // 1. 获取Stock价格
 func TestGetStockPrice(t *testing.T) {
-	url := fmt.Sprintf("%s/api/stock/%s/price", baseURL, symbol)
-	data, code := doRequest(t, "GET", url, nil)
+	h := newHarness(t)
 
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("获取Stock价格成功: %s", string(data))
+	code, resp := h.do(http.MethodGet, fmt.Sprintf("/api/stock/%s/price", symbol), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "3000", resp["price"])
 }
 
 // 2. 更新Stock价格 (管理员)
 func TestUpdateStockPrice(t *testing.T) {
-	url := fmt.Sprintf("%s/api/stock/%s/price", baseURL, symbol)
-	body := map[string]interface{}{"price": 120.0}
-	data, code := doRequest(t, "POST", url, body)
-
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("更新Stock价格成功: %s", string(data))
+	h := newHarness(t)
+
+	// 只能通过管理接口更新
+	code, _ := h.do(http.MethodPost, fmt.Sprintf("/api/stock/%s/price", symbol), map[string]interface{}{"price": 120.0})
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, resp := h.do(http.MethodPost, fmt.Sprintf("/api/admin/stock/%s/price", symbol), map[string]interface{}{"price": 120.0})
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Contains(t, resp["chains"], harnessChain)
+	assert.Empty(t, resp["failed"])
+	h.mine()
+
+	code, resp = h.do(http.MethodGet, fmt.Sprintf("/api/stock/%s/price", symbol), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "120", resp["price"])
+
+	// 同步到金库，用于限制质押可借的 SCOS
+	vault, err := contracts.NewStockVaultCaller(h.vault, h.sim.Client())
+	require.NoError(t, err)
+	price, err := vault.Prices(nil, h.apple)
+	require.NoError(t, err)
+	assert.Equal(t, harnessVaultPrice(120), price)
 }
 
 // 3. 获取用户SCOS余额
 func TestGetUserSCOS(t *testing.T) {
-	url := fmt.Sprintf("%s/api/user/%s/scos", baseURL, userAddr)
-	data, code := doRequest(t, "GET", url, nil)
-
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("获取用户SCOS余额成功: %s", string(data))
-}
-
-// 4. 质押Stock
-func TestStakeStock(t *testing.T) {
-	url := fmt.Sprintf("%s/api/stake", baseURL)
-	body := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-		"amount":        "100",
-		"stock_symbol":  symbol,
-	}
-	data, code := doRequest(t, "POST", url, body)
-
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("质押Stock成功: %s", string(data))
-}
-
-// 5. 赎回Stock
-func TestRedeemStock(t *testing.T) {
-	url := fmt.Sprintf("%s/api/redeem", baseURL)
-	body := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-	}
-	data, code := doRequest(t, "POST", url, body)
-
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("赎回Stock成功: %s", string(data))
-}
-
-// 6. 买入Stock
-func TestBuyStock(t *testing.T) {
-	url := fmt.Sprintf("%s/api/buy", baseURL)
-	body := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-		"amount":        "100",
-	}
-	data, code := doRequest(t, "POST", url, body)
+	h := newHarness(t)
 
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("买入Stock成功: %s", string(data))
+	code, resp := h.do(http.MethodGet, fmt.Sprintf("/api/user/%s/scos", userAddr), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "0", resp["scos_balance"])
+	assert.Contains(t, resp["chains"], harnessChain)
 }
 
-// 7. 卖出Stock
-func TestSellStock(t *testing.T) {
-	url := fmt.Sprintf("%s/api/sell", baseURL)
-	body := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-		"amount":        "100",
-	}
-	data, code := doRequest(t, "POST", url, body)
+// 4. 获取签名用户操作的 EIP-712 domain
+func TestGetIntentDomain(t *testing.T) {
+	h := newHarness(t)
 
-	if code != http.StatusOK {
-		t.Errorf("期望状态码200, 得到%d, 响应: %s", code, string(data))
-	}
-	t.Logf("卖出Stock成功: %s", string(data))
+	code, resp := h.do(http.MethodGet, "/api/intents/domain/"+harnessChain, nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	domain := resp["domain"].(map[string]interface{})
+	assert.EqualValues(t, 1337, domain["chainId"])
+	assert.Equal(t, h.vault.Hex(), domain["verifyingContract"])
 }
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/handlers"
+	"scos/models"
+	"scos/money"
+)
+
+// selectorStubCode 按函数选择器返回存储中的 4 个字：slot = selector<<4 + i，忽略参数
+func selectorStubCode() []byte {
+	code := []byte{
+		0x60, 0x00, 0x35, // PUSH1 0 CALLDATALOAD
+		0x60, 0xe0, 0x1c, // PUSH1 0xe0 SHR
+		0x60, 0x04, 0x1b, // PUSH1 4 SHL
+	}
+	for i := byte(0); i < 4; i++ {
+		// DUP1 PUSH1 i ADD SLOAD PUSH1 i*32 MSTORE
+		code = append(code, 0x80, 0x60, i, 0x01, 0x54, 0x60, i*32, 0x52)
+	}
+	return append(code, 0x60, 0x80, 0x60, 0x00, 0xf3) // RETURN(0, 128)
+}
+
+// stubAccount 部署选择器桩合约，returns 为 函数签名 -> 返回的字
+func stubAccount(returns map[string][]*big.Int) types.Account {
+	storage := map[common.Hash]common.Hash{}
+	for sig, words := range returns {
+		sel := new(big.Int).SetBytes(crypto.Keccak256([]byte(sig))[:4])
+		base := new(big.Int).Lsh(sel, 4)
+		for i, w := range words {
+			storage[common.BigToHash(new(big.Int).Add(base, big.NewInt(int64(i))))] = common.BigToHash(w)
+		}
+	}
+	return types.Account{Code: selectorStubCode(), Storage: storage, Balance: big.NewInt(0)}
+}
+
+func newChainReadRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	vault := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	env := newSimRouter(t, simRouterOptions{
+		alloc: types.GenesisAlloc{
+			common.HexToAddress(APPLEtokenAddr): stubAccount(map[string][]*big.Int{
+				"decimals()":                 {big.NewInt(6)},
+				"balanceOf(address)":         {big.NewInt(2_500_000)},
+				"allowance(address,address)": {big.NewInt(1_000_000)},
+			}),
+			common.HexToAddress(SCOStokenAddr): stubAccount(map[string][]*big.Int{
+				"decimals()":         {big.NewInt(18)},
+				"balanceOf(address)": {new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17))},
+			}),
+			vault: stubAccount(map[string][]*big.Int{
+				"getStakeInfo(address,address)": {big.NewInt(100_000_000), new(big.Int).Mul(big.NewInt(70), big.NewInt(1e18)), big.NewInt(1700000000), big.NewInt(1)},
+				"supportedTokens(address)":      {big.NewInt(1)},
+			}),
+		},
+		chain: config.ChainInfo{VaultAddress: vault.Hex(), SCOSAddress: SCOStokenAddr},
+		routes: func(r *gin.Engine, db *gorm.DB, bc *blockchain.BlockchainClient) {
+			timeouts := config.TimeoutConfig{Read: 5 * time.Second}
+			chain := handlers.NewChainHandler(db, bc, timeouts)
+			stock := handlers.NewStockHandler(db, bc, timeouts)
+			r.GET("/api/chain/:chain/position/:user/:token", chain.GetPosition)
+			r.GET("/api/chain/:chain/balance/:token/:user", chain.GetBalance)
+			r.GET("/api/user/:address/scos", stock.GetUserSCOSBalance)
+		},
+	})
+	return env.r, env.db
+}
+
+func TestChainRead_PositionAndBalance(t *testing.T) {
+	r, db := newChainReadRouter(t)
+	require.NoError(t, db.Create(&models.StakeRecord{
+		UserAddress: userAddr, TokenAddress: APPLEtokenAddr, Chain: "sim",
+		Amount: money.NewFromInt(100), SCOSBorrowed: money.NewFromInt(70), Status: "active", Staker: userAddr,
+	}).Error)
+
+	code, resp := doJSON(r, http.MethodGet, fmt.Sprintf("/api/chain/sim/position/%s/%s", userAddr, APPLEtokenAddr), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, true, resp["supported"])
+	onChain := resp["on_chain"].(map[string]interface{})
+	assert.Equal(t, "100", onChain["amount"])
+	assert.Equal(t, "70", onChain["scos_borrowed"])
+	assert.Equal(t, true, onChain["active"])
+	assert.Len(t, resp["db"], 1)
+
+	code, resp = doJSON(r, http.MethodGet, fmt.Sprintf("/api/chain/sim/balance/%s/%s", APPLEtokenAddr, userAddr), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "2.5", resp["balance"])
+	assert.Equal(t, "1", resp["allowance_to_vault"])
+	assert.EqualValues(t, 6, resp["decimals"])
+
+	code, resp = doJSON(r, http.MethodGet, fmt.Sprintf("/api/user/%s/scos", userAddr), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "1.5", resp["scos_balance"])
+	assert.Equal(t, "70", resp["db_scos_borrowed"])
+
+	code, resp = doJSON(r, http.MethodGet, fmt.Sprintf("/api/chain/Reddoi/balance/%s/%s", APPLEtokenAddr, userAddr), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "unknown_chain", resp["code"])
+
+	code, _ = doJSON(r, http.MethodGet, "/api/chain/sim/balance/nope/"+userAddr, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/config"
+)
+
+const testConfigYAML = `
+port: "9000"
+signer:
+  type: keystore
+  path: /run/secrets/operator.json
+  password_env: OPERATOR_PASSWORD
+db:
+  path: test.db
+risk:
+  collateral_ratio: 1.5
+  monitor_interval: 30s
+chains:
+  Reddio:
+    rpc: https://reddio-dev.reddio.com/
+    chain_id: "50341"
+    scos_address: "0xeB5e9Af4b798ec27A0f24DA22C7A7b3b657D05d9"
+    vault_address: "0x0124e835BdE149aD885b765Bb8BF6f63735Fc4db"
+`
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv("SCOS_CONFIG", path)
+}
+
+func TestLoadConfig_FileAndEnv(t *testing.T) {
+	writeConfig(t, testConfigYAML)
+	t.Setenv("PORT", "9100")
+	t.Setenv("SCOS_REDDIO_RPC", "http://127.0.0.1:8545")
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "9100", cfg.Port)
+	assert.Equal(t, "keystore", cfg.Signer.Type)
+	assert.Equal(t, "test.db", cfg.DB.Path)
+	assert.Equal(t, 1.5, cfg.Risk.CollateralRatio)
+	assert.Equal(t, 0.25, cfg.Risk.LiquidationThreshold) // 未配置时使用默认值
+	assert.Equal(t, 30*time.Second, cfg.Risk.MonitorInterval)
+	assert.Equal(t, "http://127.0.0.1:8545", cfg.Chains["Reddio"].RPC)
+}
+
+func TestLoadConfig_ReportsAllErrors(t *testing.T) {
+	writeConfig(t, testConfigYAML+`
+  Scroll:
+    rpc: https://sepolia-rpc.scroll.io
+    chain_id: "534351"
+    scos_address: "0x1234"
+    signer: scroll-operator
+    scos_signer: scroll-scos-owner
+`)
+	t.Setenv("SCOS_REDDIO_VAULT_ADDRESS", "0x0124E835BdE149aD885b765Bb8BF6f63735Fc4db")
+	t.Setenv("SCOS_SIGNER_TYPE", "remote")
+
+	_, err := config.LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chain Reddio: vault_address")
+	assert.Contains(t, err.Error(), "invalid checksum")
+	assert.Contains(t, err.Error(), "chain Scroll: vault_address is missing")
+	assert.Contains(t, err.Error(), `chain Scroll: scos_address "0x1234" is not a valid address`)
+	assert.Contains(t, err.Error(), "signer: remote signer requires url")
+	assert.Contains(t, err.Error(), `chain Scroll: signer "scroll-operator" is not defined`)
+	assert.Contains(t, err.Error(), `chain Scroll: scos_signer "scroll-scos-owner" is not defined`)
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/handlers"
+	"scos/models"
+	"scos/money"
+)
+
+var dryRunVault = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+func newDryRunRouter(t *testing.T, vaultCode []byte) (*gin.Engine, *gorm.DB, simulated.Client) {
+	env := newSimRouter(t, simRouterOptions{
+		alloc: types.GenesisAlloc{
+			dryRunVault:                         {Code: vaultCode, Balance: big.NewInt(0)},
+			common.HexToAddress(APPLEtokenAddr): stubAccount(map[string][]*big.Int{"decimals()": {big.NewInt(6)}}),
+			common.HexToAddress(SCOStokenAddr): stubAccount(map[string][]*big.Int{
+				"decimals()":         {big.NewInt(6)},
+				"balanceOf(address)": {big.NewInt(6_000_000_000)},
+			}),
+		},
+		chain: config.ChainInfo{VaultAddress: dryRunVault.Hex(), SCOSAddress: SCOStokenAddr, Fees: config.FeeConfig{Mode: "legacy"}},
+		routes: func(r *gin.Engine, db *gorm.DB, bc *blockchain.BlockchainClient) {
+			timeouts := config.TimeoutConfig{Read: 5 * time.Second, Write: 5 * time.Second}
+			staking := handlers.NewStakingHandler(db, bc, config.RiskConfig{CollateralRatio: 1.5}, timeouts)
+			trading := handlers.NewTradingHandler(db, bc)
+			r.POST("/api/stake", staking.StakeStock)
+			r.POST("/api/buy", trading.BuyStock)
+		},
+	})
+	require.NoError(t, env.db.Create(&models.TokenPrice{Symbol: "APPLE", Price: money.NewFromInt(3000), UpdatedAt: time.Now()}).Error)
+	return env.r, env.db, env.sim.Client()
+}
+
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	var n int64
+	require.NoError(t, db.Model(model).Count(&n).Error)
+	return n
+}
+
+func TestDryRun_StakeBuildsWithoutSideEffects(t *testing.T) {
+	// STOP：任何调用都成功
+	r, db, client := newDryRunRouter(t, []byte{0x00})
+
+	// 质押只构造交易，由用户签名，本身不写库也不广播
+	body := stakeBody("sim")
+	body["amount"] = "3"
+	body["dry_run"] = true
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, true, resp["dry_run"])
+	assert.Equal(t, "6000", resp["scos_borrowed"])
+	assert.Equal(t, "3000000", resp["amount_wei"])
+	assert.Equal(t, "6000000000", resp["scos_amount_wei"])
+	built := resp["tx"].(map[string]interface{})
+	assert.Greater(t, built["gas"], float64(21000))
+	assert.EqualValues(t, 0, built["type"])
+	assert.NotEmpty(t, built["gas_price"])
+
+	code, _ = doJSON(r, http.MethodPost, "/api/buy", signBody(t, "buy", gin.H{
+		"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim", "amount": "1", "dry_run": true,
+	}, dryRunVault))
+	require.Equal(t, http.StatusOK, code)
+
+	// 没有写库，也没有广播
+	assert.EqualValues(t, 0, countRows(t, db, &models.StakeRecord{}))
+	assert.EqualValues(t, 0, countRows(t, db, &models.Transaction{}))
+	assert.EqualValues(t, 0, countRows(t, db, &models.IntentNonce{}))
+	pending, err := client.PendingTransactionCount(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, pending)
+}
+
+func TestDryRun_ReportsRevert(t *testing.T) {
+	// PUSH1 0 PUSH1 0 REVERT
+	r, db, _ := newDryRunRouter(t, []byte{0x60, 0x00, 0x60, 0x00, 0xfd})
+
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", stakeBody("sim"))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "execution_reverted", resp["code"])
+	assert.EqualValues(t, 0, countRows(t, db, &models.StakeRecord{}))
+}
@@ -2,33 +2,22 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/stretchr/testify/assert"
-	"io/ioutil"
-	"log"
+	"io"
 	"math/big"
 	"net/http"
-	"os"
-	"strings"
 	"testing"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-)
-
-const (
-	ethRPC     = "https://reddio-dev.reddio.com/"
-	privateKey = "01c7939dc6827ee10bb7d26f420618c4af88c0029aa70be202f1ca7f29fe5bb4"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
-	StockSellerAddr = "0xf30e1edec3c1633d3b4b67b9c37a597a95d808ff"
+	"scos/models"
+	"scos/server"
 )
 
-var ReddioChainID = big.NewInt(50341)
-
 // ============ 通用请求工具 ============
 func doRequest(t *testing.T, method, url string, body interface{}) ([]byte, int) {
 	t.Helper()
@@ -45,6 +34,7 @@ func doRequest(t *testing.T, method, url string, body interface{}) ([]byte, int)
 	if err != nil {
 		t.Fatalf("创建请求失败: %v", err)
 	}
+	req.Header.Set("X-Admin-Token", "secret")
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -53,157 +43,192 @@ func doRequest(t *testing.T, method, url string, body interface{}) ([]byte, int)
 	}
 	defer resp.Body.Close()
 
-	data, _ := ioutil.ReadAll(resp.Body)
+	data, _ := io.ReadAll(resp.Body)
 	return data, resp.StatusCode
 }
 
-// ============ ERC20 Mint ============
-func mintAppleToken(t *testing.T, to string, amount int64) {
-	client, err := ethclient.Dial(ethRPC)
-	if err != nil {
-		t.Fatalf("连接以太坊失败: %v", err)
-	}
-
-	erc20ABI := `[{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"mint","outputs":[],"type":"function"}]`
-
-	parsedABI, err := abi.JSON(strings.NewReader(erc20ABI))
-	assert.NoError(t, err)
-
-	contract := bind.NewBoundContract(
-		common.HexToAddress(APPLEtokenAddr),
-		parsedABI,
-		client, client, client,
-	)
-
-	pk, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
-	assert.NoError(t, err)
-
-	// 调用 mint
-	auth, _ := bind.NewKeyedTransactorWithChainID(pk, ReddioChainID)
-	tx, err := contract.Transact(auth, "mint", common.HexToAddress(to), big.NewInt(amount))
-	assert.NoError(t, err)
-
-	log.Printf("Mint交易已发送: %s 金额: %d", tx.Hash().Hex(), amount)
-}
-
-func transferApple(t *testing.T, to string, amount int64) {
-	client, err := ethclient.Dial(ethRPC)
-	if err != nil {
-		t.Fatalf("连接以太坊失败: %v", err)
+// stake 用户自己签名质押 amount 个 APPLE，打包后由索引器写入质押记录并铸造 SCOS，返回质押交易哈希
+func (h *harness) stake(amount string) string {
+	h.t.Helper()
+	body := gin.H{
+		"user_address":  userAddr,
+		"token_address": h.apple.Hex(),
+		"chain":         harnessChain,
+		"amount":        amount,
+		"stock_symbol":  symbol,
 	}
-
-	erc20ABI := `[{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"transfer","outputs":[],"type":"function"}]`
-
-	parsedABI, err := abi.JSON(strings.NewReader(erc20ABI))
-	assert.NoError(t, err)
-
-	contract := bind.NewBoundContract(
-		common.HexToAddress(SCOStokenAddr),
-		parsedABI,
-		client, client, client,
-	)
-	pk, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
-	assert.NoError(t, err)
-
-	// 调用 transfer
-	auth, _ := bind.NewKeyedTransactorWithChainID(pk, ReddioChainID)
-	tx, err := contract.Transact(auth, "transfer", common.HexToAddress(to), big.NewInt(amount))
-	assert.NoError(t, err)
-
-	log.Printf("Transfer交易已发送: %s", tx.Hash().Hex())
+	code, resp := h.do(http.MethodPost, "/api/stake", body)
+	require.Equal(h.t, http.StatusOK, code, resp)
+	txHash := h.submit(resp)
+	h.mine()
+	h.index()
+	return txHash
 }
 
-func cleanDB(t *testing.T) {
-	assert.NoError(t, os.RemoveAll("*/**/scos.db"))
+func (h *harness) stakeRecord() models.StakeRecord {
+	h.t.Helper()
+	var stake models.StakeRecord
+	require.NoError(h.t, h.db.Where("user_address = ?", userAddr).First(&stake).Error)
+	return stake
 }
 
 // ============ 场景测试 ============
-func TestScenario_FullFlow(t *testing.T) {
-
-	t.Log("========== 功能测试 ===========")
-	cleanDB(t)
-
-	stockAmount := "20"
-	var (
-		appleAmount     int64 = 10_000000
-		sellAppleAmount       = "5_000000"
-	)
-
-	// 1. 给 user mint 10000000 APPLE token
-	mintAppleToken(t, userAddr, appleAmount)
-	mintAppleToken(t, StockSellerAddr, appleAmount)
-
-	// 2. 质押 APPLE 20 个
-	stakeBody := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-		"stock_symbol":  symbol,
-		"amount":        stockAmount,
-	}
-	data, code := doRequest(t, "POST", fmt.Sprintf("%s/api/stake", baseURL), stakeBody)
-	if code != http.StatusOK {
-		t.Fatalf("质押失败: %s", string(data))
-	}
-	t.Logf("质押成功: %s", string(data))
-
-	// 3. 查询 SCOS 余额
-	data, code = doRequest(t, "GET", fmt.Sprintf("%s/api/user/%s/scos", baseURL, userAddr), nil)
-	if code != http.StatusOK {
-		t.Fatalf("查询SCOS失败: %s", string(data))
-	}
-	t.Logf("SCOS余额: %s", string(data))
+func TestE2E_StakeAndRedeem(t *testing.T) {
+	h := newHarness(t)
+
+	// 质押 20 APPLE：20 / 1.5 x 3000 = 40000 SCOS
+	txHash := h.stake("20")
+	assert.Equal(t, "confirmed", h.txStatus(txHash))
+
+	// 仓位记在用户名下，抵押品来自用户
+	info := h.stakeInfo(h.apple)
+	assert.True(t, info.Active)
+	assert.Equal(t, big.NewInt(20_000000), info.Amount)
+	assert.Equal(t, big.NewInt(40000_000000), info.BorrowedSCOS)
+	assert.Equal(t, big.NewInt(20_000000), h.appleBalance(h.vault))
+	assert.Equal(t, big.NewInt(userApple-20_000000), h.appleBalance(h.user))
+
+	// 索引到质押后把借出的 SCOS 铸造给用户
+	assert.Equal(t, big.NewInt(40000_000000), h.scosBalance(h.user))
+	stake := h.stakeRecord()
+	assert.Equal(t, "active", stake.Status)
+	assert.Equal(t, symbol, stake.StockSymbol)
+	var mint models.Transaction
+	require.NoError(t, h.db.Where("type = ?", "mint").First(&mint).Error)
+	assert.Equal(t, "confirmed", h.txStatus(mint.TxHash))
+	assert.Equal(t, stake.ID, mint.StakeID)
+
+	code, resp := h.do(http.MethodGet, fmt.Sprintf("/api/chain/%s/position/%s/%s", harnessChain, h.user.Hex(), h.apple.Hex()), nil)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, true, resp["on_chain"].(map[string]interface{})["active"])
+
+	// 未授权金库转走 SCOS 时不能赎回
+	body := gin.H{"user_address": userAddr, "token_address": h.apple.Hex(), "chain": harnessChain}
+	code, resp = h.do(http.MethodPost, "/api/redeem", body)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "insufficient_allowance", resp["code"])
+
+	code, resp = h.do(http.MethodPost, "/api/tx/build/approve", gin.H{
+		"user_address": userAddr, "token_address": h.scos.Hex(), "chain": harnessChain, "amount": "40000",
+	})
+	require.Equal(t, http.StatusOK, code, resp)
+	h.submit(resp)
+	h.mine()
+
+	// 赎回时金库销毁用户偿还的 SCOS，抵押品回到用户
+	assert.Equal(t, big.NewInt(40000_000000), h.scosSupply())
+	code, resp = h.do(http.MethodPost, "/api/redeem", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "40000", resp["scos_repay"])
+	redeemHash := h.submit(resp)
+	h.mine()
+	assert.Equal(t, "confirmed", h.txStatus(redeemHash))
+	h.index()
+
+	assert.False(t, h.stakeInfo(h.apple).Active)
+	assert.Zero(t, h.scosBalance(h.user).Sign())
+	assert.Zero(t, h.scosSupply().Sign())
+	assert.Zero(t, h.scosBalance(h.treasury).Sign())
+	assert.Equal(t, big.NewInt(userApple), h.appleBalance(h.user))
+	assert.Equal(t, "redeemed", h.stakeRecord().Status)
+}
 
-	// 用全数 SCOS 买 APPLE
-	buyBody := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-		"amount":        sellAppleAmount,
-	}
-	data, code = doRequest(t, "POST", fmt.Sprintf("%s/api/buy", baseURL), buyBody)
-	if code != http.StatusOK {
-		t.Fatalf("买入失败: %s", string(data))
-	}
-	t.Logf("买入成功: %s", string(data))
+func TestE2E_RepayAndWithdraw(t *testing.T) {
+	h := newHarness(t)
+
+	h.stake("20")
+
+	code, resp := h.do(http.MethodPost, "/api/tx/build/approve", gin.H{
+		"user_address": userAddr, "token_address": h.scos.Hex(), "chain": harnessChain, "amount": "10000",
+	})
+	require.Equal(t, http.StatusOK, code, resp)
+	h.submit(resp)
+	h.mine()
+
+	// 还款 10000 SCOS 后剩余债务 30000，可取回 5 APPLE：15 x 3000 / 1.5 = 30000。还款的 SCOS 在同一笔交易中销毁
+	body := gin.H{"user_address": userAddr, "token_address": h.apple.Hex(), "chain": harnessChain, "amount": "10000"}
+	code, resp = h.do(http.MethodPost, "/api/repay", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	repayHash := h.submit(resp)
+	h.mine()
+	assert.Equal(t, "confirmed", h.txStatus(repayHash))
+	assert.Equal(t, big.NewInt(30000_000000), h.scosBalance(h.user))
+	assert.Equal(t, big.NewInt(30000_000000), h.scosSupply())
+	assert.Zero(t, h.scosBalance(h.treasury).Sign())
+
+	// 取回的抵押品回到用户
+	body["amount"] = "5"
+	code, resp = h.do(http.MethodPost, "/api/withdraw", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	withdrawHash := h.submit(resp)
+	h.mine()
+	assert.Equal(t, "confirmed", h.txStatus(withdrawHash))
+	h.index()
+
+	info := h.stakeInfo(h.apple)
+	assert.True(t, info.Active)
+	assert.Equal(t, big.NewInt(15_000000), info.Amount)
+	assert.Equal(t, big.NewInt(30000_000000), info.BorrowedSCOS)
+	assert.Equal(t, big.NewInt(15_000000), h.appleBalance(h.vault))
+	assert.Equal(t, big.NewInt(userApple-15_000000), h.appleBalance(h.user))
+
+	stake := h.stakeRecord()
+	assert.Equal(t, "15", stake.Amount.String())
+	assert.Equal(t, "30000", stake.SCOSBorrowed.String())
+
+	// 再取回任何数量都会低于抵押率
+	body["amount"] = "0.000001"
+	code, resp = h.do(http.MethodPost, "/api/withdraw", body)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "collateral_ratio", resp["code"])
+}
 
-	// 卖出 APPLE
-	sellBody := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-		"amount":        sellAppleAmount,
-	}
-	data, code = doRequest(t, "POST", fmt.Sprintf("%s/api/sell", baseURL), sellBody)
-	if code != http.StatusOK {
-		t.Fatalf("卖出失败: %s", string(data))
-	}
-	t.Logf("卖出成功: %s", string(data))
+func TestE2E_Liquidation(t *testing.T) {
+	h := newHarness(t)
+
+	h.stake("20")
+
+	// 价格从 3000 跌到 2000，超过 20% 的清算阈值
+	code, resp := h.do(http.MethodPost, "/api/admin/stock/"+symbol+"/price", gin.H{"price": 2000.0})
+	require.Equal(t, http.StatusOK, code, resp)
+	h.mine()
+	server.CheckLiquidations(context.Background(), h.db, h.bc, h.cfg.Risk, h.cfg.Timeouts)
+	h.mine()
+
+	assert.False(t, h.stakeInfo(h.apple).Active)
+	assert.Equal(t, big.NewInt(20_000000), h.appleBalance(h.treasury))
+	// 仓位状态由索引器根据 Liquidated 事件更新
+	assert.Equal(t, "active", h.stakeRecord().Status)
+	h.index()
+	assert.Equal(t, "liquidated", h.stakeRecord().Status)
+
+	var tx models.Transaction
+	require.NoError(t, h.db.Where("type = ?", "liquidate").First(&tx).Error)
+	assert.Equal(t, "confirmed", h.txStatus(tx.TxHash))
+
+	// 已清算的仓位不会再次发送交易
+	server.CheckLiquidations(context.Background(), h.db, h.bc, h.cfg.Risk, h.cfg.Timeouts)
+	var liquidations int64
+	require.NoError(t, h.db.Model(&models.Transaction{}).Where("type = ?", "liquidate").Count(&liquidations).Error)
+	assert.EqualValues(t, 1, liquidations)
+}
 
-	// 4. 赎回 APPLE
-	redeemBody := map[string]interface{}{
-		"user_address":  userAddr,
-		"token_address": SCOStokenAddr,
-		"chain":         chainName,
-	}
-	data, code = doRequest(t, "POST", fmt.Sprintf("%s/api/redeem", baseURL), redeemBody)
-	if code != http.StatusOK {
-		t.Fatalf("赎回失败: %s", string(data))
-	}
-	t.Logf("赎回成功: %s", string(data))
+func TestE2E_BuyAndSell(t *testing.T) {
+	h := newHarness(t)
 
-	// 5. 修改 APPLE 价格，下跌30%
-	updateBody := map[string]interface{}{"price": 70.0} // 假设初始100
-	data, code = doRequest(t, "POST", fmt.Sprintf("%s/api/stock/%s/price", baseURL, symbol), updateBody)
-	if code != http.StatusOK {
-		t.Fatalf("修改价格失败: %s", string(data))
+	for _, action := range []string{"buy", "sell"} {
+		body := gin.H{"user_address": userAddr, "token_address": h.apple.Hex(), "chain": harnessChain, "amount": "5"}
+		code, resp := h.do(http.MethodPost, "/api/"+action, signBody(t, action, body, h.vault))
+		require.Equal(t, http.StatusOK, code, resp)
+		assert.Equal(t, "success", resp["status"])
 	}
-	t.Logf("价格更新成功: %s", string(data))
 
-	// 6. 查看质押是否被清算
-	data, code = doRequest(t, "GET", fmt.Sprintf("%s/api/stake/%s", baseURL, userAddr), nil)
-	if code != http.StatusOK {
-		t.Fatalf("查询质押失败: %s", string(data))
+	data, code := doRequest(t, http.MethodGet, fmt.Sprintf("%s/api/transactions/%s", h.url, userAddr), nil)
+	require.Equal(t, http.StatusOK, code, string(data))
+	var txs []models.Transaction
+	require.NoError(t, json.Unmarshal(data, &txs))
+	require.Len(t, txs, 2)
+	for _, tx := range txs {
+		assert.Equal(t, "offchain", tx.Status)
 	}
-	t.Logf("质押状态: %s", string(data))
 }
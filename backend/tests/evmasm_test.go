@@ -0,0 +1,335 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// evmAsm 测试用的最小 EVM 汇编器：标签统一用 PUSH2 引用，assemble 时回填。
+// 内存布局：0x00-0x3f 计算 mapping 槽位，0x80 起存放调用参数、事件数据与返回值，locals 从 0x400 开始
+type evmAsm struct {
+	code   []byte
+	labels map[string]int
+	refs   map[int]string // PUSH2 操作数的位置 => 标签
+	next   int
+}
+
+// expr 把一个值压入栈顶
+type expr func(a *evmAsm)
+
+const (
+	memArgs   = 0x80
+	memLocals = 0x400
+)
+
+func newEVMAsm() *evmAsm {
+	return &evmAsm{labels: make(map[string]int), refs: make(map[int]string)}
+}
+
+func (a *evmAsm) op(ops ...vm.OpCode) {
+	for _, o := range ops {
+		a.code = append(a.code, byte(o))
+	}
+}
+
+func (a *evmAsm) push(v *big.Int) {
+	if v.Sign() == 0 {
+		a.op(vm.PUSH0)
+		return
+	}
+	b := v.Bytes()
+	a.op(vm.PUSH1 + vm.OpCode(len(b)-1))
+	a.code = append(a.code, b...)
+}
+
+func (a *evmAsm) pushInt(v uint64) { a.push(new(big.Int).SetUint64(v)) }
+
+func (a *evmAsm) pushLabel(label string) {
+	a.op(vm.PUSH2)
+	a.refs[len(a.code)] = label
+	a.code = append(a.code, 0, 0)
+}
+
+// fresh 生成唯一的标签名
+func (a *evmAsm) fresh(prefix string) string {
+	a.next++
+	return fmt.Sprintf("%s_%d", prefix, a.next)
+}
+
+// mark 在当前位置放置 JUMPDEST
+func (a *evmAsm) mark(label string) {
+	a.labels[label] = len(a.code)
+	a.op(vm.JUMPDEST)
+}
+
+// data 在当前位置追加原始字节，标签指向其起始位置
+func (a *evmAsm) data(label string, b []byte) {
+	a.labels[label] = len(a.code)
+	a.code = append(a.code, b...)
+}
+
+func (a *evmAsm) jumpi(cond expr, label string) {
+	cond(a)
+	a.pushLabel(label)
+	a.op(vm.JUMPI)
+}
+
+func (a *evmAsm) assemble() []byte {
+	out := append([]byte(nil), a.code...)
+	for pos, label := range a.refs {
+		dest, ok := a.labels[label]
+		if !ok {
+			panic("evmasm: undefined label " + label)
+		}
+		out[pos], out[pos+1] = byte(dest>>8), byte(dest)
+	}
+	return out
+}
+
+// ---- 表达式 ----
+
+func num(v uint64) expr { return func(a *evmAsm) { a.pushInt(v) } }
+
+func word(v *big.Int) expr { return func(a *evmAsm) { a.push(v) } }
+
+// arg 第 i 个静态 ABI 参数
+func arg(i int) expr {
+	return func(a *evmAsm) { a.pushInt(uint64(4 + 32*i)); a.op(vm.CALLDATALOAD) }
+}
+
+func opExpr(o vm.OpCode) expr { return func(a *evmAsm) { a.op(o) } }
+
+var (
+	caller    = opExpr(vm.CALLER)
+	self      = opExpr(vm.ADDRESS)
+	timestamp = opExpr(vm.TIMESTAMP)
+)
+
+func mload(offset uint64) expr {
+	return func(a *evmAsm) { a.pushInt(offset); a.op(vm.MLOAD) }
+}
+
+func local(i int) expr { return mload(uint64(memLocals + 32*i)) }
+
+func sload(slot expr) expr {
+	return func(a *evmAsm) { slot(a); a.op(vm.SLOAD) }
+}
+
+// mapping 与 Solidity 相同：keccak256(key . slot)，多个 key 依次嵌套
+func mapping(slot expr, keys ...expr) expr {
+	return func(a *evmAsm) {
+		slot(a)
+		for _, key := range keys {
+			a.pushInt(0x20)
+			a.op(vm.MSTORE)
+			key(a)
+			a.op(vm.PUSH0, vm.MSTORE)
+			a.pushInt(0x40)
+			a.op(vm.PUSH0, vm.KECCAK256)
+		}
+	}
+}
+
+// binary 计算 x op y：EVM 以栈顶为第一个操作数
+func binary(o vm.OpCode, x, y expr) expr {
+	return func(a *evmAsm) { y(a); x(a); a.op(o) }
+}
+
+func add(x, y expr) expr { return binary(vm.ADD, x, y) }
+func sub(x, y expr) expr { return binary(vm.SUB, x, y) }
+func mul(x, y expr) expr { return binary(vm.MUL, x, y) }
+func div(x, y expr) expr { return binary(vm.DIV, x, y) }
+func lt(x, y expr) expr  { return binary(vm.LT, x, y) }
+func gt(x, y expr) expr  { return binary(vm.GT, x, y) }
+func eq(x, y expr) expr  { return binary(vm.EQ, x, y) }
+
+func iszero(x expr) expr { return func(a *evmAsm) { x(a); a.op(vm.ISZERO) } }
+
+// field 结构体第 i 个成员的槽位
+func field(base expr, i uint64) expr { return add(base, num(i)) }
+
+// ---- 语句 ----
+
+func (a *evmAsm) sstore(slot, value expr) {
+	value(a)
+	slot(a)
+	a.op(vm.SSTORE)
+}
+
+func (a *evmAsm) mstore(offset uint64, value expr) {
+	value(a)
+	a.pushInt(offset)
+	a.op(vm.MSTORE)
+}
+
+func (a *evmAsm) setLocal(i int, value expr) { a.mstore(uint64(memLocals+32*i), value) }
+
+// when cond 非零时执行 body
+func (a *evmAsm) when(cond expr, body func()) {
+	end := a.fresh("endif")
+	a.jumpi(iszero(cond), end)
+	body()
+	a.mark(end)
+}
+
+// require 与 Solidity 相同，条件不成立时以 Error(string) revert
+func (a *evmAsm) require(cond expr, reason string) {
+	ok := a.fresh("ok")
+	a.jumpi(cond, ok)
+	a.revert(reason)
+	a.mark(ok)
+}
+
+func (a *evmAsm) revert(reason string) {
+	if reason == "" {
+		a.op(vm.PUSH0, vm.PUSH0, vm.REVERT)
+		return
+	}
+	a.mstore(memArgs, word(new(big.Int).Lsh(big.NewInt(0x08c379a0), 224)))
+	a.mstore(memArgs+4, num(0x20))
+	a.mstore(memArgs+0x24, num(uint64(len(reason))))
+	size := a.storeString(memArgs+0x44, reason)
+	a.pushInt(0x44 + size)
+	a.pushInt(memArgs)
+	a.op(vm.REVERT)
+}
+
+// storeString 把字符串按 32 字节右补零写入内存，返回写入的长度
+func (a *evmAsm) storeString(offset uint64, s string) uint64 {
+	var size uint64
+	for i := 0; i < len(s); i += 32 {
+		chunk := make([]byte, 32)
+		copy(chunk, s[i:])
+		a.mstore(offset+size, word(new(big.Int).SetBytes(chunk)))
+		size += 32
+	}
+	return size
+}
+
+func (a *evmAsm) stop() { a.op(vm.STOP) }
+
+// ret 返回若干个静态字
+func (a *evmAsm) ret(values ...expr) {
+	for i, v := range values {
+		a.mstore(memArgs+uint64(32*i), v)
+	}
+	a.pushInt(uint64(32 * len(values)))
+	a.pushInt(memArgs)
+	a.op(vm.RETURN)
+}
+
+func (a *evmAsm) retString(s string) {
+	a.mstore(memArgs, num(0x20))
+	a.mstore(memArgs+0x20, num(uint64(len(s))))
+	size := a.storeString(memArgs+0x40, s)
+	a.pushInt(0x40 + size)
+	a.pushInt(memArgs)
+	a.op(vm.RETURN)
+}
+
+// emit 发出事件，topics 为 indexed 参数，data 为其余参数
+func (a *evmAsm) emit(signature string, topics []expr, data ...expr) {
+	for i, v := range data {
+		a.mstore(memArgs+uint64(32*i), v)
+	}
+	for i := len(topics) - 1; i >= 0; i-- {
+		topics[i](a)
+	}
+	a.push(new(big.Int).SetBytes(crypto.Keccak256([]byte(signature))))
+	a.pushInt(uint64(32 * len(data)))
+	a.pushInt(memArgs)
+	a.op(vm.LOG1 + vm.OpCode(len(topics)))
+}
+
+// call 与 Solidity 的外部调用相同：目标没有代码时 revert，失败时原样抛出 revert 数据；
+// returnsBool 时要求返回至少一个字（调用方忽略其值时 Solidity 仍会解码）
+func (a *evmAsm) call(target expr, signature string, returnsBool bool, args ...expr) {
+	a.when(iszero(func(a *evmAsm) { target(a); a.op(vm.EXTCODESIZE) }), func() { a.revert("") })
+
+	a.mstore(memArgs, word(new(big.Int).Lsh(new(big.Int).SetBytes(crypto.Keccak256([]byte(signature))[:4]), 224)))
+	for i, v := range args {
+		a.mstore(memArgs+4+uint64(32*i), v)
+	}
+	a.pushInt(32)
+	a.pushInt(memArgs)
+	a.pushInt(uint64(4 + 32*len(args)))
+	a.pushInt(memArgs)
+	a.op(vm.PUSH0)
+	target(a)
+	a.op(vm.GAS, vm.CALL)
+
+	ok := a.fresh("called")
+	a.pushLabel(ok)
+	a.op(vm.JUMPI)
+	a.op(vm.RETURNDATASIZE, vm.PUSH0, vm.PUSH0, vm.RETURNDATACOPY)
+	a.op(vm.RETURNDATASIZE, vm.PUSH0, vm.REVERT)
+	a.mark(ok)
+	if returnsBool {
+		a.when(lt(opExpr(vm.RETURNDATASIZE), num(32)), func() { a.revert("") })
+	}
+}
+
+// ---- 合约 ----
+
+// evmFunc 合约的一个外部函数，body 结束后自动 STOP
+type evmFunc struct {
+	signature string
+	args      int
+	body      func(a *evmAsm)
+}
+
+// runtimeCode 按选择器分派，所有函数都不接受转账，参数不足时 revert
+func runtimeCode(funcs []evmFunc) []byte {
+	a := newEVMAsm()
+	a.when(opExpr(vm.CALLVALUE), func() { a.revert("") })
+	a.when(lt(opExpr(vm.CALLDATASIZE), num(4)), func() { a.revert("") })
+	a.op(vm.PUSH0, vm.CALLDATALOAD)
+	a.pushInt(224)
+	a.op(vm.SHR)
+	for i, f := range funcs {
+		a.op(vm.DUP1)
+		a.push(new(big.Int).SetBytes(crypto.Keccak256([]byte(f.signature))[:4]))
+		a.op(vm.EQ)
+		a.pushLabel(fmt.Sprintf("fn_%d", i))
+		a.op(vm.JUMPI)
+	}
+	a.revert("")
+	for i, f := range funcs {
+		a.mark(fmt.Sprintf("fn_%d", i))
+		a.when(lt(opExpr(vm.CALLDATASIZE), num(uint64(4+32*f.args))), func() { a.revert("") })
+		f.body(a)
+		a.stop()
+	}
+	return a.assemble()
+}
+
+// deployCode 构造函数参数位于部署代码末尾，先复制到 locals，ctorArg(i) 即 local(i)
+func deployCode(ctorArgs int, ctor func(a *evmAsm), runtime []byte) []byte {
+	a := newEVMAsm()
+	if ctorArgs > 0 {
+		size := uint64(32 * ctorArgs)
+		a.pushInt(size)
+		a.pushInt(size)
+		a.op(vm.CODESIZE, vm.SUB)
+		a.pushInt(memLocals)
+		a.op(vm.CODECOPY)
+	}
+	a.when(opExpr(vm.CALLVALUE), func() { a.revert("") })
+	ctor(a)
+	a.pushInt(uint64(len(runtime)))
+	a.op(vm.DUP1)
+	a.pushLabel("runtime")
+	a.op(vm.PUSH0, vm.CODECOPY, vm.PUSH0, vm.RETURN)
+	a.data("runtime", runtime)
+	return a.assemble()
+}
+
+var zeroAddr = num(0)
+
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+func addrWord(addr common.Address) expr { return word(new(big.Int).SetBytes(addr.Bytes())) }
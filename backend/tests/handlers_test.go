@@ -0,0 +1,456 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/blockchain/fake"
+	"scos/config"
+	"scos/contracts"
+	"scos/handlers"
+	"scos/models"
+	"scos/money"
+	"scos/server"
+)
+
+var (
+	fakeOperator = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	fakeVault    = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// newFakeRouter 不连接节点，金库操作由内存中的 fake.Vault 处理
+func newFakeRouter(t *testing.T) (*gin.Engine, *gorm.DB, *fake.Vault) {
+	vault := fake.NewVault(fakeOperator)
+	vault.AddChain("sim", 1337, fakeVault)
+	vault.AddSupportedToken("sim", common.HexToAddress(APPLEtokenAddr))
+	vault.SetPrice("sim", common.HexToAddress(APPLEtokenAddr), vaultPrice(3000))
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.StakeRecord{}, &models.TokenPrice{}, &models.Transaction{}, &models.IntentNonce{}))
+	require.NoError(t, db.Create(&models.TokenPrice{Symbol: "APPLE", Price: money.NewFromInt(3000), UpdatedAt: time.Now()}).Error)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	timeouts := config.TimeoutConfig{Read: 5 * time.Second, Write: 5 * time.Second}
+	staking := handlers.NewStakingHandler(db, vault, config.RiskConfig{CollateralRatio: 1.5}, timeouts)
+	trading := handlers.NewTradingHandler(db, vault)
+	r.POST("/api/stake", staking.StakeStock)
+	r.POST("/api/redeem", staking.RedeemStock)
+	r.POST("/api/repay", staking.RepayDebt)
+	r.POST("/api/withdraw", staking.WithdrawStock)
+	r.POST("/api/buy", trading.BuyStock)
+	r.POST("/api/sell", trading.SellStock)
+	return r, db, vault
+}
+
+// vaultPrice 金库的价格格式：代币与 SCOS 同为 6 位精度时即 价格 x 1e18
+func vaultPrice(price int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(price), big.NewInt(1e18))
+}
+
+// executeBuilt 相当于用户签名接口返回的交易并上链
+func executeBuilt(t *testing.T, vault *fake.Vault, resp map[string]interface{}) string {
+	built, ok := resp["tx"].(map[string]interface{})
+	require.True(t, ok, resp)
+	hash, err := vault.Execute("sim", &blockchain.UnsignedTx{
+		From: common.HexToAddress(built["from"].(string)),
+		To:   common.HexToAddress(built["to"].(string)),
+		Data: hexutil.MustDecode(built["data"].(string)),
+	})
+	require.NoError(t, err)
+	return hash
+}
+
+func redeemBody() gin.H {
+	return gin.H{"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim"}
+}
+
+func TestHandlers_StakeAndRedeem(t *testing.T) {
+	r, db, vault := newFakeRouter(t)
+	user := common.HexToAddress(userAddr)
+
+	body := stakeBody("sim")
+	body["amount"] = "3"
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "6000", resp["scos_borrowed"])
+	assert.Equal(t, "3000000", resp["amount_wei"])
+	assert.Equal(t, "6000000000", resp["scos_amount_wei"])
+	built := resp["tx"].(map[string]interface{})
+	assert.Equal(t, user.Hex(), built["from"])
+	assert.Equal(t, fakeVault.Hex(), built["to"])
+	executeBuilt(t, vault, resp)
+
+	// 仓位属于用户本人；质押记录与 mint 由索引器根据链上事件完成，接口不写数据库
+	info, err := vault.GetStakeInfo(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	require.NoError(t, err)
+	assert.True(t, info.Active)
+	assert.Equal(t, "3000000", info.Amount.String())
+	assert.Equal(t, "6000000000", info.BorrowedSCOS.String())
+	info, err = vault.GetStakeInfo(context.Background(), "sim", fakeOperator.Hex(), APPLEtokenAddr)
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	assert.EqualValues(t, 0, countRows(t, db, &models.StakeRecord{}))
+	assert.EqualValues(t, 0, countRows(t, db, &models.Transaction{}))
+
+	// 已有仓位时不能再次质押
+	code, resp = doJSON(r, http.MethodPost, "/api/stake", body)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "Already staked", resp["revert_reason"])
+
+	vault.SetSCOSBalance("sim", user, big.NewInt(6_000_000_000))
+	vault.SetSCOSAllowance("sim", user, big.NewInt(6_000_000_000))
+	dry := redeemBody()
+	dry["dry_run"] = true
+	code, resp = doJSON(r, http.MethodPost, "/api/redeem", dry)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, true, resp["dry_run"])
+	assert.Equal(t, "6000", resp["scos_repay"])
+	code, resp = doJSON(r, http.MethodPost, "/api/redeem", redeemBody())
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.NotContains(t, resp, "dry_run")
+	assert.Equal(t, "6000", resp["scos_repay"])
+	assert.Equal(t, "3000000", resp["amount_wei"])
+	executeBuilt(t, vault, resp)
+
+	info, err = vault.GetStakeInfo(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	balance, err := vault.SCOSBalance(context.Background(), "sim", userAddr)
+	require.NoError(t, err)
+	assert.Zero(t, balance.Sign())
+	txs := vault.Txs()
+	require.Len(t, txs, 2)
+	assert.Equal(t, "stakeStock", txs[0].Method)
+	assert.Equal(t, "unstakeStock", txs[1].Method)
+	assert.Equal(t, user, txs[1].From)
+	assert.Equal(t, big.NewInt(6_000_000_000), txs[1].SCOSAmount)
+
+	code, _ = doJSON(r, http.MethodPost, "/api/redeem", redeemBody())
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestHandlers_StakeReverts(t *testing.T) {
+	r, _, vault := newFakeRouter(t)
+
+	body := stakeBody("sim")
+	body["token_address"] = SCOStokenAddr
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "Token not supported", resp["revert_reason"])
+
+	for _, amount := range []string{"0", "-1", "abc"} {
+		body = stakeBody("sim")
+		body["amount"] = amount
+		code, resp = doJSON(r, http.MethodPost, "/api/stake", body)
+		assert.Equal(t, http.StatusBadRequest, code, amount)
+		assert.Equal(t, fmt.Sprintf("invalid amount %q", amount), resp["error"])
+	}
+
+	body = stakeBody("sim")
+	body["stock_symbol"] = "MISSING"
+	code, resp = doJSON(r, http.MethodPost, "/api/stake", body)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "Stock price not found", resp["error"])
+
+	// 借出数量受金库链上价格限制，数据库价格高于链上时报价会被拒绝
+	vault.SetPrice("sim", common.HexToAddress(APPLEtokenAddr), vaultPrice(2000))
+	code, resp = doJSON(r, http.MethodPost, "/api/stake", stakeBody("sim"))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "Exceeds collateral ratio", resp["revert_reason"])
+
+	assert.Empty(t, vault.Txs())
+}
+
+func TestHandlers_RedeemRequiresSCOS(t *testing.T) {
+	r, _, vault := newFakeRouter(t)
+	user := common.HexToAddress(userAddr)
+
+	body := stakeBody("sim")
+	body["amount"] = "3"
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	executeBuilt(t, vault, resp)
+
+	// 用户转走了部分 SCOS，不足以偿还
+	vault.SetSCOSBalance("sim", user, big.NewInt(5_999_999_999))
+	code, resp = doJSON(r, http.MethodPost, "/api/redeem", redeemBody())
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "insufficient_scos", resp["code"])
+	assert.Equal(t, "6000", resp["scos_required"])
+	assert.Equal(t, "5999.999999", resp["scos_balance"])
+
+	// 余额足够但尚未授权金库
+	vault.SetSCOSBalance("sim", user, big.NewInt(6_000_000_000))
+	vault.SetSCOSAllowance("sim", user, big.NewInt(1_000_000_000))
+	code, resp = doJSON(r, http.MethodPost, "/api/redeem", redeemBody())
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "insufficient_allowance", resp["code"])
+	assert.Equal(t, fakeVault.Hex(), resp["spender"])
+	assert.Equal(t, "1000", resp["scos_allowance"])
+
+	info, err := vault.GetStakeInfo(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	require.NoError(t, err)
+	assert.True(t, info.Active)
+	assert.Len(t, vault.Txs(), 1)
+}
+
+func adjustBody(amount string) gin.H {
+	return gin.H{"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim", "amount": amount}
+}
+
+func TestHandlers_RepayAndWithdraw(t *testing.T) {
+	r, db, vault := newFakeRouter(t)
+	user := common.HexToAddress(userAddr)
+
+	// 3 x 3000 / 1.5 = 6000 SCOS，已借满
+	body := stakeBody("sim")
+	body["amount"] = "3"
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	executeBuilt(t, vault, resp)
+	require.NoError(t, db.Create(&models.StakeRecord{
+		UserAddress: userAddr, Staker: userAddr, TokenAddress: APPLEtokenAddr, Chain: "sim", StockSymbol: "APPLE",
+		Amount: money.NewFromInt(3), SCOSBorrowed: money.NewFromInt(6000), Status: "active",
+	}).Error)
+
+	code, resp = doJSON(r, http.MethodPost, "/api/withdraw", adjustBody("1"))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "collateral_ratio", resp["code"])
+	assert.Equal(t, "0", resp["max_withdrawable"])
+
+	// 还款需要持有并授权金库转走 SCOS
+	code, resp = doJSON(r, http.MethodPost, "/api/repay", adjustBody("2000"))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "insufficient_scos", resp["code"])
+	vault.SetSCOSBalance("sim", user, big.NewInt(6_000_000_000))
+	vault.SetSCOSAllowance("sim", user, big.NewInt(2_000_000_000))
+
+	// 还款 2000 后剩余债务 4000，抵押品可取回 1
+	code, resp = doJSON(r, http.MethodPost, "/api/repay", adjustBody("2000"))
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "4000", resp["scos_borrowed"])
+	assert.Equal(t, "2000000000", resp["scos_repay_wei"])
+	built := resp["tx"].(map[string]interface{})
+	assert.Equal(t, user.Hex(), built["from"])
+	assert.Equal(t, fakeVault.Hex(), built["to"])
+	executeBuilt(t, vault, resp)
+	balance, err := vault.SCOSBalance(context.Background(), "sim", userAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "4000000000", balance.String())
+
+	code, resp = doJSON(r, http.MethodPost, "/api/withdraw", adjustBody("1.5"))
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "1", resp["max_withdrawable"])
+	code, resp = doJSON(r, http.MethodPost, "/api/withdraw", adjustBody("1"))
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "2", resp["amount"])
+	assert.Equal(t, "1000000", resp["amount_wei"])
+	executeBuilt(t, vault, resp)
+
+	// 数据库记录由索引器根据链上事件更新
+	info, err := vault.GetStakeInfo(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "2000000", info.Amount.String())
+	assert.Equal(t, "4000000000", info.BorrowedSCOS.String())
+
+	// 全部取回与超额还款不在这两个接口处理
+	code, _ = doJSON(r, http.MethodPost, "/api/withdraw", adjustBody("2"))
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doJSON(r, http.MethodPost, "/api/repay", adjustBody("4000.000001"))
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 还款只会改善仓位，价格下跌后部分还款仍然允许
+	require.NoError(t, db.Model(&models.TokenPrice{}).Where("symbol = ?", "APPLE").Update("price", money.NewFromInt(1500)).Error)
+	vault.SetSCOSAllowance("sim", user, big.NewInt(1_000_000_000))
+	code, resp = doJSON(r, http.MethodPost, "/api/repay", adjustBody("1000"))
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "3000", resp["scos_borrowed"])
+
+	// 金库按链上价格再次检查取回后的抵押率
+	vault.SetPrice("sim", common.HexToAddress(APPLEtokenAddr), vaultPrice(1500))
+	_, err = vault.Execute("sim", &blockchain.UnsignedTx{From: user, To: fakeVault, Data: packVault(t, "withdrawStock", common.HexToAddress(APPLEtokenAddr), big.NewInt(1_000_000))})
+	assert.EqualError(t, err, "execution reverted: Exceeds collateral ratio")
+
+	txs := vault.Txs()
+	require.Len(t, txs, 3)
+	assert.Equal(t, []string{"stakeStock", "repayDebt", "withdrawStock"}, []string{txs[0].Method, txs[1].Method, txs[2].Method})
+	for _, tx := range txs {
+		assert.Equal(t, user, tx.From)
+	}
+}
+
+func TestHandlers_AdjustMatchesAddressCase(t *testing.T) {
+	r, db, vault := newFakeRouter(t)
+
+	body := stakeBody("sim")
+	body["amount"] = "3"
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	executeBuilt(t, vault, resp)
+	require.NoError(t, db.Create(&models.StakeRecord{
+		UserAddress: userAddr, Staker: userAddr, TokenAddress: APPLEtokenAddr, Chain: "sim", StockSymbol: "APPLE",
+		Amount: money.NewFromInt(3), SCOSBorrowed: money.NewFromInt(6000), Status: "active",
+	}).Error)
+
+	// 请求中的地址全部小写，仍能找到按校验和格式保存的质押记录
+	lower := gin.H{"user_address": strings.ToLower(userAddr), "token_address": strings.ToLower(APPLEtokenAddr), "chain": "sim", "amount": "1"}
+	code, resp = doJSON(r, http.MethodPost, "/api/withdraw", lower)
+	assert.Equal(t, http.StatusUnprocessableEntity, code, resp)
+	assert.Equal(t, "collateral_ratio", resp["code"])
+}
+
+// packVault 编码金库调用，模拟用户绕过接口直接发送的交易
+func packVault(t *testing.T, method string, args ...interface{}) []byte {
+	parsed, err := contracts.StockVaultMetaData.GetAbi()
+	require.NoError(t, err)
+	data, err := parsed.Pack(method, args...)
+	require.NoError(t, err)
+	return data
+}
+
+func TestHandlers_RedeemWithoutOnChainStake(t *testing.T) {
+	r, db, vault := newFakeRouter(t)
+
+	// 数据库记录存在但链上没有仓位
+	require.NoError(t, db.Create(&models.StakeRecord{
+		UserAddress: userAddr, TokenAddress: APPLEtokenAddr, Chain: "sim", Amount: money.NewFromInt(1), Status: "active",
+	}).Error)
+	code, resp := doJSON(r, http.MethodPost, "/api/redeem", redeemBody())
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "No active stake found", resp["error"])
+
+	var stake models.StakeRecord
+	require.NoError(t, db.First(&stake).Error)
+	assert.Equal(t, "active", stake.Status)
+	assert.Empty(t, vault.Txs())
+}
+
+func TestHandlers_ChainErrors(t *testing.T) {
+	r, db, vault := newFakeRouter(t)
+
+	vault.FailNext(context.DeadlineExceeded)
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", stakeBody("sim"))
+	assert.Equal(t, http.StatusGatewayTimeout, code)
+	assert.Equal(t, "timeout", resp["code"])
+
+	vault.FailNext(errors.New("nonce too low"))
+	code, _ = doJSON(r, http.MethodPost, "/api/stake", stakeBody("sim"))
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	vault.SetChainEnabled("sim", false)
+	code, resp = doJSON(r, http.MethodPost, "/api/stake", stakeBody("sim"))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "chain_unavailable", resp["code"])
+
+	code, resp = doJSON(r, http.MethodPost, "/api/sell", gin.H{
+		"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "Reddoi", "amount": "1",
+	})
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "unknown_chain", resp["code"])
+
+	assert.EqualValues(t, 0, countRows(t, db, &models.StakeRecord{}))
+	assert.Empty(t, vault.Txs())
+}
+
+func TestHandlers_BuyAndSell(t *testing.T) {
+	r, db, vault := newFakeRouter(t)
+
+	body := gin.H{"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim", "amount": "2"}
+	code, resp := doJSON(r, http.MethodPost, "/api/buy", signBody(t, "buy", body, fakeVault))
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "Buy order processed", resp["message"])
+
+	body = gin.H{"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim", "amount": "2"}
+	code, resp = doJSON(r, http.MethodPost, "/api/sell", signBody(t, "sell", body, fakeVault))
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "Sell order processed", resp["message"])
+
+	var trades []models.Transaction
+	require.NoError(t, db.Find(&trades).Error)
+	require.Len(t, trades, 2)
+	for _, tx := range trades {
+		assert.Equal(t, "offchain", tx.Status)
+		assert.Empty(t, tx.TxHash)
+	}
+
+	// 数量无效时在校验签名前拒绝，不写库
+	for _, action := range []string{"buy", "sell"} {
+		for _, amount := range []string{"0", "-2", "1e3"} {
+			body = gin.H{"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim", "amount": amount}
+			code, resp = doJSON(r, http.MethodPost, "/api/"+action, signBody(t, action, body, fakeVault))
+			assert.Equal(t, http.StatusBadRequest, code, action+" "+amount)
+			assert.Equal(t, fmt.Sprintf("invalid amount %q", amount), resp["error"])
+		}
+	}
+	assert.EqualValues(t, 2, countRows(t, db, &models.Transaction{}))
+	assert.Empty(t, vault.Txs())
+}
+
+func TestMigrate_MarksLegacyTradesOffchain(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Transaction{}))
+	require.NoError(t, db.Create(&[]models.Transaction{
+		{Type: "buy", Status: "pending"},
+		{Type: "sell", Status: "pending"},
+		{Type: "stake", Status: "pending", TxHash: "0x01"},
+		{Type: "buy", Status: "pending", TxHash: "0x02"},
+	}).Error)
+
+	require.NoError(t, server.Migrate(db))
+	var txs []models.Transaction
+	require.NoError(t, db.Order("id").Find(&txs).Error)
+	assert.Equal(t, []string{"offchain", "offchain", "pending", "pending"},
+		[]string{txs[0].Status, txs[1].Status, txs[2].Status, txs[3].Status})
+}
+
+func TestFakeVault_Liquidate(t *testing.T) {
+	vault := fake.NewVault(fakeOperator)
+	vault.AddChain("sim", 1337, fakeVault)
+	token := common.HexToAddress(APPLEtokenAddr)
+	ctx := context.Background()
+
+	_, err := vault.Liquidate(ctx, "sim", userAddr, APPLEtokenAddr)
+	assert.EqualError(t, err, "execution reverted: No active stake")
+
+	vault.AddSupportedToken("sim", token)
+	vault.SetPrice("sim", token, vaultPrice(3))
+	_, err = vault.BuildStakeTx(ctx, "sim", userAddr, APPLEtokenAddr, big.NewInt(5), big.NewInt(11))
+	assert.EqualError(t, err, "execution reverted: Exceeds collateral ratio")
+	tx, err := vault.BuildStakeTx(ctx, "sim", userAddr, APPLEtokenAddr, big.NewInt(5), big.NewInt(10))
+	require.NoError(t, err)
+	_, err = vault.Execute("sim", tx)
+	require.NoError(t, err)
+	_, err = vault.Execute("sim", tx)
+	assert.EqualError(t, err, "execution reverted: Already staked")
+
+	_, err = vault.Liquidate(ctx, "sim", userAddr, APPLEtokenAddr)
+	require.NoError(t, err)
+	txs := vault.Txs()
+	require.Len(t, txs, 2)
+	assert.Equal(t, "liquidate", txs[1].Method)
+	assert.Equal(t, fakeOperator, txs[1].From)
+	assert.Equal(t, common.HexToAddress(userAddr), txs[1].User)
+	assert.Equal(t, "5", txs[1].Amount.String())
+
+	_, err = vault.BuildUnstakeTx(ctx, "sim", userAddr, APPLEtokenAddr)
+	assert.EqualError(t, err, "execution reverted: No active stake")
+}
@@ -0,0 +1,337 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/contracts"
+	"scos/models"
+	"scos/money"
+	"scos/server"
+)
+
+// 部署用字节码：优先使用 contracts/gen.sh 嵌入 Go 绑定的 Bin，其次读取 contracts/build/*.bin，再次用 solc 编译仓库根目录的 contracts/*.sol，
+// 都没有时使用 refcontracts_test.go 中手写的参考字节码
+const (
+	buildDir  = "../contracts/build"
+	solSrcDir = "../../contracts"
+)
+
+var (
+	artifactsOnce sync.Once
+	artifacts     map[string][]byte
+	artifactsErr  error
+)
+
+// loadArtifacts 返回 StockVault、SCOS、StockToken 的部署字节码
+func loadArtifacts(t *testing.T) map[string][]byte {
+	t.Helper()
+	artifactsOnce.Do(func() {
+		artifacts, artifactsErr = embeddedArtifacts()
+		if artifactsErr != nil {
+			artifacts, artifactsErr = readArtifacts(buildDir)
+		}
+		if artifactsErr != nil {
+			artifacts, artifactsErr = compileArtifacts()
+		}
+		if artifactsErr != nil {
+			artifacts, artifactsErr = referenceArtifacts(), nil
+		}
+	})
+	return artifacts
+}
+
+// embeddedArtifacts gen.sh 在 build/*.bin 存在时把字节码写入绑定的 MetaData.Bin
+func embeddedArtifacts() (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for name, meta := range map[string]*bind.MetaData{
+		"StockVault": contracts.StockVaultMetaData,
+		"SCOS":       contracts.SCOSMetaData,
+		"StockToken": contracts.StockTokenMetaData,
+	} {
+		if meta.Bin == "" {
+			return nil, fmt.Errorf("%s binding has no bytecode", name)
+		}
+		bin, err := hexutil.Decode("0x" + strings.TrimPrefix(meta.Bin, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("%s binding: %w", name, err)
+		}
+		out[name] = bin
+	}
+	return out, nil
+}
+
+func readArtifacts(dir string) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for _, name := range []string{"StockVault", "SCOS", "StockToken"} {
+		data, err := os.ReadFile(filepath.Join(dir, name+".bin"))
+		if err != nil {
+			return nil, err
+		}
+		bin, err := hexutil.Decode("0x" + strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("%s.bin: %w", name, err)
+		}
+		out[name] = bin
+	}
+	return out, nil
+}
+
+// compileArtifacts 与 gen.sh 使用相同的 solc 参数，股票代币使用 Apple 合约
+func compileArtifacts() (map[string][]byte, error) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "scos-solc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command(solc, "--bin", "--optimize", "--overwrite", "-o", dir,
+		"--base-path", filepath.Join(solSrcDir, ".."), "--include-path", filepath.Join(solSrcDir, "..", "node_modules"),
+		filepath.Join(solSrcDir, "StockVault.sol"), filepath.Join(solSrcDir, "SCOS.sol"), filepath.Join(solSrcDir, "AppleStock.sol"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, out)
+	}
+	for src, dst := range map[string]string{"Apple": "StockToken"} {
+		if err := os.Rename(filepath.Join(dir, src+".bin"), filepath.Join(dir, dst+".bin")); err != nil {
+			return nil, err
+		}
+	}
+	return readArtifacts(dir)
+}
+
+// harness 在模拟链上部署 SCOS、Apple 与 StockVault，并通过 httptest 启动完整的 API 服务
+type harness struct {
+	t   *testing.T
+	sim *simulated.Backend
+	url string
+
+	db      *gorm.DB
+	bc      *blockchain.BlockchainClient
+	cfg     *config.Config
+	watcher *blockchain.ReceiptWatcher
+	indexer *blockchain.Indexer
+
+	operator common.Address // 部署合约并发送运营交易的账户，也是合约 owner
+	user     common.Address // userKey 对应的账户，自己签名质押与赎回
+	treasury common.Address
+	scos     common.Address
+	apple    common.Address
+	vault    common.Address
+}
+
+const (
+	harnessChain = "sim"
+	// 用户初始持有的 Apple（6 位小数）
+	userApple = 1_000 * 1_000_000
+	// 金库中 Apple 的价格：Apple 与 SCOS 同为 6 位小数，每个 Apple 最小单位值 3000 个 SCOS 最小单位，放大 1e18
+	harnessPrice = 3000
+)
+
+func harnessVaultPrice(price int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(price), big.NewInt(params.Ether))
+}
+
+func newHarness(t *testing.T) *harness {
+	bins := loadArtifacts(t)
+
+	key, _ := crypto.GenerateKey()
+	operator := crypto.PubkeyToAddress(key.PublicKey)
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		operator:                      {Balance: funds},
+		common.HexToAddress(userAddr): {Balance: funds},
+	})
+	t.Cleanup(func() { sim.Close() })
+
+	h := &harness{
+		t:        t,
+		sim:      sim,
+		operator: operator,
+		user:     common.HexToAddress(userAddr),
+		treasury: common.HexToAddress("0x00000000000000000000000000000000000000ee"),
+	}
+	h.deploy(key, bins)
+
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return sim.Client(), nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	h.cfg = &config.Config{
+		AdminToken: "secret",
+		Risk:       config.RiskConfig{CollateralRatio: 1.5, LiquidationThreshold: 0.2, MonitorInterval: time.Minute},
+		Timeouts:   config.TimeoutConfig{Read: 10 * time.Second, Write: 10 * time.Second, Shutdown: 10 * time.Second},
+		Watcher:    config.WatcherConfig{Interval: time.Second, DropTimeout: time.Minute},
+		Chains: map[string]config.ChainInfo{harnessChain: {
+			RPC:          "http://sim",
+			ChainID:      "1337",
+			VaultAddress: h.vault.Hex(),
+			SCOSAddress:  h.scos.Hex(),
+			Tokens:       map[string]string{symbol: h.apple.Hex()},
+		}},
+	}
+	bc, err := blockchain.NewBlockchainClient(h.cfg.Chains, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+	h.bc = bc
+
+	h.db, err = gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, server.Migrate(h.db))
+	require.NoError(t, h.db.Create(&models.TokenPrice{Symbol: "APPLE", Price: money.NewFromInt(3000), UpdatedAt: time.Now()}).Error)
+	h.watcher = blockchain.NewReceiptWatcher(h.db, bc, h.cfg.Watcher)
+	h.indexer = blockchain.NewIndexer(h.db, bc, config.IndexerConfig{BatchSize: 100})
+
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(server.New(h.db, bc, h.cfg))
+	t.Cleanup(srv.Close)
+	h.url = srv.URL
+	return h
+}
+
+// deploy 部署合约，把 Apple 加入金库支持列表并设置价格，给用户铸造 Apple 并由用户授权金库
+func (h *harness) deploy(key *ecdsa.PrivateKey, bins map[string][]byte) {
+	t := h.t
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+	userOpts, err := bind.NewKeyedTransactorWithChainID(userKey, big.NewInt(1337))
+	require.NoError(t, err)
+	client := h.sim.Client()
+
+	deploy := func(meta *bind.MetaData, bin []byte, args ...interface{}) (common.Address, *bind.BoundContract) {
+		parsed, err := meta.GetAbi()
+		require.NoError(t, err)
+		addr, _, contract, err := bind.DeployContract(opts, *parsed, bin, client, args...)
+		require.NoError(t, err)
+		return addr, contract
+	}
+	var apple, vault *bind.BoundContract
+	h.scos, _ = deploy(contracts.SCOSMetaData, bins["SCOS"])
+	h.apple, apple = deploy(contracts.StockTokenMetaData, bins["StockToken"])
+	h.vault, vault = deploy(contracts.StockVaultMetaData, bins["StockVault"], h.scos, h.treasury, big.NewInt(15000))
+	h.commit()
+
+	var txs []*types.Transaction
+	send := func(opts *bind.TransactOpts, contract *bind.BoundContract, method string, args ...interface{}) {
+		tx, err := contract.Transact(opts, method, args...)
+		require.NoError(t, err, method)
+		txs = append(txs, tx)
+	}
+	send(opts, vault, "addSupportedToken", h.apple)
+	send(opts, vault, "setPrice", h.apple, harnessVaultPrice(harnessPrice))
+	send(opts, apple, "mint", h.user, big.NewInt(userApple))
+	send(userOpts, apple, "approve", h.vault, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)))
+	h.commit()
+	for _, tx := range txs {
+		receipt, err := client.TransactionReceipt(context.Background(), tx.Hash())
+		require.NoError(t, err)
+		require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status, "setup transaction %s failed", tx.Hash())
+	}
+}
+
+func (h *harness) commit() {
+	h.sim.Commit()
+}
+
+// mine 打包待处理的交易，并让回执监控更新交易状态
+func (h *harness) mine() {
+	h.commit()
+	h.watcher.Poll(context.Background())
+}
+
+// index 索引已打包的金库事件，质押后由索引器铸造 SCOS，再打包 mint
+func (h *harness) index() {
+	h.t.Helper()
+	require.NoError(h.t, h.indexer.Sync(context.Background(), harnessChain))
+	h.mine()
+}
+
+// submit 用 userKey 签名接口返回的交易并经 /api/tx/submit 广播，返回交易哈希
+func (h *harness) submit(resp map[string]interface{}) string {
+	h.t.Helper()
+	built, ok := resp["tx"].(map[string]interface{})
+	require.True(h.t, ok, resp)
+	code, resp := h.do(http.MethodPost, "/api/tx/submit", map[string]interface{}{"chain": harnessChain, "raw_tx": signBuilt(h.t, userKey, built)})
+	require.Equal(h.t, http.StatusOK, code, resp)
+	return resp["tx_hash"].(string)
+}
+
+// do 调用 API，返回状态码与解析后的 JSON
+func (h *harness) do(method, path string, body interface{}) (int, map[string]interface{}) {
+	h.t.Helper()
+	data, code := doRequest(h.t, method, h.url+path, body)
+	var resp map[string]interface{}
+	json.Unmarshal(data, &resp)
+	return code, resp
+}
+
+func (h *harness) appleBalance(owner common.Address) *big.Int {
+	h.t.Helper()
+	token, err := contracts.NewStockTokenCaller(h.apple, h.sim.Client())
+	require.NoError(h.t, err)
+	balance, err := token.BalanceOf(nil, owner)
+	require.NoError(h.t, err)
+	return balance
+}
+
+func (h *harness) scosBalance(owner common.Address) *big.Int {
+	h.t.Helper()
+	token, err := contracts.NewSCOSCaller(h.scos, h.sim.Client())
+	require.NoError(h.t, err)
+	balance, err := token.BalanceOf(nil, owner)
+	require.NoError(h.t, err)
+	return balance
+}
+
+func (h *harness) scosSupply() *big.Int {
+	h.t.Helper()
+	token, err := contracts.NewSCOSCaller(h.scos, h.sim.Client())
+	require.NoError(h.t, err)
+	supply, err := token.TotalSupply(nil)
+	require.NoError(h.t, err)
+	return supply
+}
+
+func (h *harness) stakeInfo(token common.Address) contracts.StockVaultStakeInfo {
+	h.t.Helper()
+	info, err := h.bc.GetStakeInfo(context.Background(), harnessChain, h.user.Hex(), token.Hex())
+	require.NoError(h.t, err)
+	return info
+}
+
+// txStatus 查询交易记录的状态
+func (h *harness) txStatus(hash string) string {
+	h.t.Helper()
+	code, resp := h.do(http.MethodGet, "/api/tx/"+hash, nil)
+	require.Equal(h.t, http.StatusOK, code, resp)
+	status, _ := resp["status"].(string)
+	return status
+}
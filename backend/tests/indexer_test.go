@@ -0,0 +1,417 @@
+package tests
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/contracts"
+	"scos/models"
+)
+
+// eventEmitterCode 部署后对任意调用发出 LOG3(topic0, msg.sender, token, word1, word2)，
+// calldata 依次为 topic0、token 与两个 32 字节的数据字，用来模拟金库的各种事件
+func eventEmitterCode() []byte {
+	runtime := []byte{
+		0x60, 0x40, 0x60, 0x40, 0x60, 0x00, 0x37, // CALLDATACOPY(0, 64, 64)
+		0x60, 0x20, 0x35, // token
+		0x33,             // msg.sender
+		0x60, 0x00, 0x35, // topic0
+		0x60, 0x40, 0x60, 0x00, 0xa3, 0x00, // LOG3(0, 64, ...) STOP
+	}
+	initCode := []byte{0x60, byte(len(runtime)), 0x80, 0x60, 0x0b, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(initCode, runtime...)
+}
+
+type indexerEnv struct {
+	sim     *simulated.Backend
+	client  simulated.Client
+	vault   common.Address
+	staker  common.Address
+	db      *gorm.DB
+	bc      *blockchain.BlockchainClient
+	indexer *blockchain.Indexer
+	send    func(to *common.Address, data []byte, gasPrice int64) common.Hash
+}
+
+func newIndexerEnv(t *testing.T, confirmations uint64) (*indexerEnv, func(amount, gasPrice int64) common.Hash) {
+	return newIndexerEnvWithOperator(t, confirmations, big.NewInt(params.Ether))
+}
+
+// newIndexerEnvWithOperator operatorBalance 为发送 mint 的运营账户的初始余额
+func newIndexerEnvWithOperator(t *testing.T, confirmations uint64, operatorBalance *big.Int) (*indexerEnv, func(amount, gasPrice int64) common.Hash) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	// 运营账户发送 mint，与质押人分开以免 nonce 冲突
+	operatorKey, _ := crypto.GenerateKey()
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		from: {Balance: big.NewInt(params.Ether)},
+		crypto.PubkeyToAddress(operatorKey.PublicKey): {Balance: operatorBalance},
+		common.HexToAddress(APPLEtokenAddr):           stubAccount(map[string][]*big.Int{"decimals()": {big.NewInt(6)}}),
+		common.HexToAddress(SCOStokenAddr):            stubAccount(map[string][]*big.Int{"decimals()": {big.NewInt(6)}}),
+	})
+	t.Cleanup(func() { sim.Close() })
+	client := sim.Client()
+	ctx := context.Background()
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(chainID)
+
+	send := func(to *common.Address, data []byte, gasPrice int64) common.Hash {
+		nonce, err := client.NonceAt(ctx, from, nil)
+		require.NoError(t, err)
+		tx := types.MustSignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       to,
+			Gas:      200000,
+			GasPrice: big.NewInt(gasPrice * params.GWei),
+			Data:     data,
+		})
+		require.NoError(t, client.SendTransaction(ctx, tx))
+		sim.Commit()
+		return tx.Hash()
+	}
+
+	send(nil, eventEmitterCode(), 10)
+	vault := crypto.CreateAddress(from, 0)
+
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return client, nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: "simulated", ChainID: chainID.String(), VaultAddress: vault.Hex(), SCOSAddress: SCOStokenAddr, Confirmations: confirmations},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(operatorKey)})
+	require.NoError(t, err)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.StakeRecord{}, &models.Transaction{},
+		&models.IndexCheckpoint{}, &models.VaultEvent{}, &models.IndexedBlock{}, &models.StakeChange{}))
+
+	env := &indexerEnv{
+		sim:     sim,
+		client:  client,
+		vault:   vault,
+		staker:  from,
+		db:      db,
+		bc:      bc,
+		indexer: blockchain.NewIndexer(db, bc, config.IndexerConfig{BatchSize: 100}),
+		send:    send,
+	}
+	stake := func(amount, gasPrice int64) common.Hash {
+		return env.emitAt("StockStaked", gasPrice, amount*1_000_000, amount*700_000)
+	}
+	return env, stake
+}
+
+// emit 以质押人身份让金库发出事件，word1、word2 为事件的两个数值参数（最小单位）
+func (e *indexerEnv) emit(event string, word1, word2 int64) common.Hash {
+	return e.emitAt(event, 10, word1, word2)
+}
+
+func (e *indexerEnv) emitAt(event string, gasPrice, word1, word2 int64) common.Hash {
+	parsed, _ := contracts.StockVaultMetaData.GetAbi()
+	data := parsed.Events[event].ID.Bytes()
+	data = append(data, common.LeftPadBytes(common.HexToAddress(APPLEtokenAddr).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(word1).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(word2).Bytes(), 32)...)
+	return e.send(&e.vault, data, gasPrice)
+}
+
+func (e *indexerEnv) head(t *testing.T) *types.Header {
+	h, err := e.client.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	return h
+}
+
+func TestIndexer_RollsBackOnReorg(t *testing.T) {
+	env, stake := newIndexerEnv(t, 1)
+	ctx := context.Background()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	forkPoint := env.head(t).Hash()
+	oldHash := stake(100, 10)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	var records []models.StakeRecord
+	require.NoError(t, env.db.Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.Equal(t, "100", records[0].Amount.String())
+	assert.Equal(t, "70", records[0].SCOSBorrowed.String())
+	assert.Equal(t, oldHash.Hex(), records[0].TxHash)
+
+	// 分叉链上用同一 nonce 发出另一笔质押，原交易随之失效
+	env.sim.Commit()
+	require.NoError(t, env.sim.Fork(forkPoint))
+	newHash := stake(50, 20)
+	env.sim.Commit()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	require.NoError(t, env.db.Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.Equal(t, "50", records[0].Amount.String())
+	assert.Equal(t, newHash.Hex(), records[0].TxHash)
+
+	var events []models.VaultEvent
+	require.NoError(t, env.db.Find(&events).Error)
+	require.Len(t, events, 1)
+	assert.Equal(t, newHash.Hex(), events[0].TxHash)
+
+	var txs []models.Transaction
+	require.NoError(t, env.db.Where("type = ?", "stake").Find(&txs).Error)
+	require.Len(t, txs, 1)
+	assert.Equal(t, newHash.Hex(), txs[0].TxHash)
+
+	var cp models.IndexCheckpoint
+	require.NoError(t, env.db.First(&cp, "chain = ?", "sim").Error)
+	assert.Equal(t, env.head(t).Number.Uint64(), cp.Block)
+}
+
+func TestIndexer_WaitsForConfirmations(t *testing.T) {
+	env, stake := newIndexerEnv(t, 3)
+	ctx := context.Background()
+
+	stake(100, 10)
+	env.sim.Commit()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	var count int64
+	env.db.Model(&models.StakeRecord{}).Count(&count)
+	assert.Zero(t, count)
+
+	env.sim.Commit()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	env.db.Model(&models.StakeRecord{}).Count(&count)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestIndexer_MintsBorrowedSCOS(t *testing.T) {
+	env, stake := newIndexerEnv(t, 1)
+	ctx := context.Background()
+
+	stakeHash := stake(100, 10)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	// 质押达到确认数后给质押人铸造事件中的借出数量，mint 与 stake 互相关联
+	var stakeTx, mintTx models.Transaction
+	require.NoError(t, env.db.Where("tx_hash = ?", stakeHash.Hex()).First(&stakeTx).Error)
+	require.NoError(t, env.db.Where("type = ?", "mint").First(&mintTx).Error)
+	assert.Equal(t, "pending", mintTx.Status)
+	assert.Equal(t, env.staker.Hex(), mintTx.UserAddress)
+	assert.Equal(t, stakeTx.ID, mintTx.LinkedTxID)
+	assert.Equal(t, mintTx.ID, stakeTx.LinkedTxID)
+	assert.Equal(t, stakeTx.StakeID, mintTx.StakeID)
+	assert.NotZero(t, mintTx.StakeID)
+
+	tx, _, err := env.client.TransactionByHash(ctx, common.HexToHash(mintTx.TxHash))
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(SCOStokenAddr), *tx.To())
+	parsed, err := contracts.StockTokenMetaData.GetAbi()
+	require.NoError(t, err)
+	args, err := parsed.Methods["mint"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	assert.Equal(t, env.staker, args[0])
+	assert.Equal(t, "70000000", args[1].(*big.Int).String())
+
+	// 再次同步不会重复铸造
+	env.sim.Commit()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	var mints int64
+	env.db.Model(&models.Transaction{}).Where("type = ?", "mint").Count(&mints)
+	assert.EqualValues(t, 1, mints)
+}
+
+func TestIndexer_ReorgDropsQueuedMint(t *testing.T) {
+	// 运营账户没有 gas 费，mint 排队后发送失败，留在队列中等待重试
+	env, stake := newIndexerEnvWithOperator(t, 1, big.NewInt(0))
+	ctx := context.Background()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	forkPoint := env.head(t).Hash()
+	stake(100, 10)
+	assert.Error(t, env.indexer.Sync(ctx, "sim"))
+	var mints []models.Transaction
+	require.NoError(t, env.db.Where("type = ?", "mint").Find(&mints).Error)
+	require.Len(t, mints, 1)
+	assert.Equal(t, "queued", mints[0].Status)
+	assert.Empty(t, mints[0].TxHash)
+
+	// 质押被重组掉后，排队中的 mint 随之删除，只为分叉链上的质押排队
+	require.NoError(t, env.sim.Fork(forkPoint))
+	newHash := stake(50, 20)
+	env.sim.Commit()
+	assert.Error(t, env.indexer.Sync(ctx, "sim"))
+	var stakeTx models.Transaction
+	require.NoError(t, env.db.Where("tx_hash = ?", newHash.Hex()).First(&stakeTx).Error)
+	require.NoError(t, env.db.Where("type = ?", "mint").Find(&mints).Error)
+	require.Len(t, mints, 1)
+	assert.Equal(t, "queued", mints[0].Status)
+	assert.Equal(t, stakeTx.ID, mints[0].LinkedTxID)
+}
+
+func TestIndexer_ReorgKeepsSentMint(t *testing.T) {
+	env, stake := newIndexerEnv(t, 1)
+	ctx := context.Background()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	forkPoint := env.head(t).Hash()
+	stakeHash := stake(100, 10)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	var sent models.Transaction
+	require.NoError(t, env.db.Where("type = ?", "mint").First(&sent).Error)
+	require.Equal(t, "pending", sent.Status)
+	env.sim.Commit()
+
+	// 分叉后交易池重新打包同一笔质押：质押记录被回滚后重建，已发送的 mint 不能再铸造一次
+	require.NoError(t, env.sim.Fork(forkPoint))
+	env.sim.Commit()
+	env.sim.Commit()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	var mints []models.Transaction
+	require.NoError(t, env.db.Where("type = ?", "mint").Find(&mints).Error)
+	require.Len(t, mints, 1)
+	assert.Equal(t, sent.ID, mints[0].ID)
+	assert.Equal(t, sent.TxHash, mints[0].TxHash)
+
+	var stakeTx models.Transaction
+	var record models.StakeRecord
+	require.NoError(t, env.db.Where("tx_hash = ?", stakeHash.Hex()).First(&stakeTx).Error)
+	require.NoError(t, env.db.Where("tx_hash = ?", stakeHash.Hex()).First(&record).Error)
+	assert.Equal(t, stakeTx.ID, mints[0].LinkedTxID)
+	assert.Equal(t, mints[0].ID, stakeTx.LinkedTxID)
+	assert.Equal(t, record.ID, mints[0].StakeID)
+}
+
+func TestIndexer_AppliesStakeLifecycle(t *testing.T) {
+	env, stake := newIndexerEnv(t, 1)
+	ctx := context.Background()
+
+	stakeHash := stake(100, 10)
+	repayHash := env.emit("DebtRepaid", 20_000000, 50_000000)
+	withdrawHash := env.emit("StockWithdrawn", 30_000000, 70_000000)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	// 还款与提取按事件中的剩余数量覆盖
+	var record models.StakeRecord
+	require.NoError(t, env.db.First(&record).Error)
+	assert.Equal(t, "active", record.Status)
+	assert.Equal(t, env.staker.Hex(), record.Staker)
+	assert.Equal(t, "70", record.Amount.String())
+	assert.Equal(t, "50", record.SCOSBorrowed.String())
+	assert.Equal(t, stakeHash.Hex(), record.TxHash)
+
+	unstakeHash := env.emit("StockUnstaked", 70_000000, 0)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	require.NoError(t, env.db.First(&record, record.ID).Error)
+	assert.Equal(t, "redeemed", record.Status)
+
+	// 赎回后的新质押另起一条记录，随后被清算
+	secondHash := stake(10, 10)
+	liquidateHash := env.emit("Liquidated", 10_000000, 0)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	var records []models.StakeRecord
+	require.NoError(t, env.db.Order("id").Find(&records).Error)
+	require.Len(t, records, 2)
+	assert.Equal(t, "redeemed", records[0].Status)
+	assert.Equal(t, "liquidated", records[1].Status)
+	assert.Equal(t, "10", records[1].Amount.String())
+	assert.Equal(t, secondHash.Hex(), records[1].TxHash)
+
+	// 直接调用合约的交易都补录为已确认
+	for hash, kind := range map[common.Hash]string{
+		stakeHash: "stake", repayHash: "repay", withdrawHash: "withdraw",
+		unstakeHash: "unstake", secondHash: "stake", liquidateHash: "liquidate",
+	} {
+		var tx models.Transaction
+		require.NoError(t, env.db.Where("tx_hash = ?", hash.Hex()).First(&tx).Error, kind)
+		assert.Equal(t, kind, tx.Type)
+		assert.Equal(t, "confirmed", tx.Status)
+		assert.Equal(t, "indexer", tx.Source)
+		assert.Equal(t, env.staker.Hex(), tx.UserAddress)
+	}
+	assert.EqualValues(t, 2, countRows(t, env.db.Where("type = ?", "mint"), &models.Transaction{}))
+}
+
+func TestIndexer_IgnoresEventsWithoutActiveStake(t *testing.T) {
+	env, _ := newIndexerEnv(t, 1)
+	ctx := context.Background()
+
+	hash := env.emit("StockWithdrawn", 1_000000, 0)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	assert.EqualValues(t, 0, countRows(t, env.db, &models.StakeRecord{}))
+	var tx models.Transaction
+	require.NoError(t, env.db.Where("tx_hash = ?", hash.Hex()).First(&tx).Error)
+	assert.Equal(t, "withdraw", tx.Type)
+}
+
+func TestIndexer_ResumesFromCheckpoint(t *testing.T) {
+	env, stake := newIndexerEnv(t, 1)
+	ctx := context.Background()
+
+	stake(100, 10)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	var cp models.IndexCheckpoint
+	require.NoError(t, env.db.First(&cp, "chain = ?", "sim").Error)
+	assert.Equal(t, env.head(t).Number.Uint64(), cp.Block)
+
+	// 重启后按检查点继续，分多批拉取，已处理的日志不重复处理
+	for i := 0; i < 5; i++ {
+		env.sim.Commit()
+	}
+	env.emit("DebtRepaid", 70_000000, 0)
+	restarted := blockchain.NewIndexer(env.db, env.bc, config.IndexerConfig{BatchSize: 2})
+	require.NoError(t, restarted.Sync(ctx, "sim"))
+	require.NoError(t, restarted.Sync(ctx, "sim"))
+
+	assert.EqualValues(t, 2, countRows(t, env.db, &models.VaultEvent{}))
+	assert.EqualValues(t, 1, countRows(t, env.db.Where("type = ?", "mint"), &models.Transaction{}))
+	var record models.StakeRecord
+	require.NoError(t, env.db.First(&record).Error)
+	assert.Equal(t, "0", record.SCOSBorrowed.String())
+	require.NoError(t, env.db.First(&cp, "chain = ?", "sim").Error)
+	assert.Equal(t, env.head(t).Number.Uint64(), cp.Block)
+}
+
+func TestIndexer_ReorgRestoresPreviousStakeState(t *testing.T) {
+	env, stake := newIndexerEnv(t, 1)
+	ctx := context.Background()
+
+	stake(100, 10)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	forkPoint := env.head(t).Hash()
+
+	env.emit("DebtRepaid", 20_000000, 50_000000)
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+	var record models.StakeRecord
+	require.NoError(t, env.db.First(&record).Error)
+	assert.Equal(t, "50", record.SCOSBorrowed.String())
+
+	// 还款所在区块被重组掉（分叉链上同一 nonce 换成普通转账），质押恢复为还款前的状态
+	env.sim.Commit()
+	require.NoError(t, env.sim.Fork(forkPoint))
+	env.send(&env.staker, nil, 20)
+	env.sim.Commit()
+	require.NoError(t, env.indexer.Sync(ctx, "sim"))
+
+	require.NoError(t, env.db.First(&record).Error)
+	assert.Equal(t, "70", record.SCOSBorrowed.String())
+	assert.Equal(t, "active", record.Status)
+	assert.EqualValues(t, 0, countRows(t, env.db.Where("type = ?", "repay"), &models.Transaction{}))
+	assert.EqualValues(t, 1, countRows(t, env.db, &models.VaultEvent{}))
+}
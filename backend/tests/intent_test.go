@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/handlers"
+	"scos/models"
+)
+
+// 测试用户的私钥，userAddr 由它导出
+var (
+	userKey, _ = crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	userAddr   = crypto.PubkeyToAddress(userKey.PublicKey).Hex()
+)
+
+// signIntent 按 EIP-712 签名用户操作，返回请求中需要附带的字段
+func signIntent(t *testing.T, key *ecdsa.PrivateKey, in handlers.Intent, chainID *big.Int, vault common.Address) gin.H {
+	t.Helper()
+	if in.Nonce == "" {
+		in.Nonce = fmt.Sprint(rand.Uint64())
+	}
+	if in.Expiry == 0 {
+		in.Expiry = time.Now().Add(10 * time.Minute).Unix()
+	}
+	hash, _, err := apitypes.TypedDataAndHash(in.TypedData(chainID, vault))
+	require.NoError(t, err)
+	sig, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27
+	return gin.H{"nonce": in.Nonce, "expiry": in.Expiry, "signature": hexutil.Encode(sig)}
+}
+
+func withIntent(body, auth gin.H) gin.H {
+	for k, v := range auth {
+		body[k] = v
+	}
+	return body
+}
+
+// signBody 用 userKey 为 chain_id 1337 上的请求体签名
+func signBody(t *testing.T, action string, body gin.H, vault common.Address) gin.H {
+	t.Helper()
+	amount, _ := body["amount"].(string)
+	in := handlers.Intent{Action: action, User: userAddr, Token: body["token_address"].(string), Amount: amount, Chain: body["chain"].(string)}
+	return withIntent(body, signIntent(t, userKey, in, big.NewInt(1337), vault))
+}
+
+func buyRequest(auth gin.H) gin.H {
+	return withIntent(gin.H{"user_address": userAddr, "token_address": APPLEtokenAddr, "chain": "sim", "amount": "1"}, auth)
+}
+
+func TestIntent_RejectsImpersonationAndReplay(t *testing.T) {
+	r, db, _ := newDryRunRouter(t, []byte{0x00})
+	chainID, vault := big.NewInt(1337), dryRunVault
+	intent := handlers.Intent{Action: "buy", User: userAddr, Token: APPLEtokenAddr, Amount: "1", Chain: "sim"}
+
+	code, resp := doJSON(r, http.MethodPost, "/api/buy", buyRequest(gin.H{}))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "invalid_signature", resp["code"])
+
+	// 其他人的签名
+	other, _ := crypto.GenerateKey()
+	code, resp = doJSON(r, http.MethodPost, "/api/buy", buyRequest(signIntent(t, other, intent, chainID, vault)))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "invalid_signature", resp["code"])
+
+	// 签名内容与请求不一致
+	tampered := intent
+	tampered.Amount = "1000"
+	code, _ = doJSON(r, http.MethodPost, "/api/buy", buyRequest(signIntent(t, userKey, tampered, chainID, vault)))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// 过期
+	expired := intent
+	expired.Expiry = time.Now().Add(-time.Minute).Unix()
+	code, resp = doJSON(r, http.MethodPost, "/api/buy", buyRequest(signIntent(t, userKey, expired, chainID, vault)))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "intent_expired", resp["code"])
+
+	// dry_run 不消耗 nonce，真实请求只能使用一次
+	auth := signIntent(t, userKey, intent, chainID, vault)
+	dry := buyRequest(auth)
+	dry["dry_run"] = true
+	code, _ = doJSON(r, http.MethodPost, "/api/buy", dry)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = doJSON(r, http.MethodPost, "/api/buy", buyRequest(auth))
+	require.Equal(t, http.StatusOK, code)
+	code, resp = doJSON(r, http.MethodPost, "/api/buy", buyRequest(auth))
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "nonce_used", resp["code"])
+
+	assert.EqualValues(t, 1, countRows(t, db, &models.IntentNonce{}))
+	assert.EqualValues(t, 1, countRows(t, db, &models.Transaction{}))
+}
+
+// 数值相同但写法不同的 nonce 签名相同，必须在验签前拒绝，不能绕过重放检查
+func TestIntent_RejectsNonCanonicalNonce(t *testing.T) {
+	r, db, _ := newDryRunRouter(t, []byte{0x00})
+	chainID, vault := big.NewInt(1337), dryRunVault
+	intent := handlers.Intent{Action: "buy", User: userAddr, Token: APPLEtokenAddr, Amount: "1", Chain: "sim", Nonce: "7"}
+
+	code, resp := doJSON(r, http.MethodPost, "/api/buy", buyRequest(signIntent(t, userKey, intent, chainID, vault)))
+	require.Equal(t, http.StatusOK, code, resp)
+
+	for _, nonce := range []string{"07", "+7"} {
+		replay := intent
+		replay.Nonce = nonce
+		code, _ = doJSON(r, http.MethodPost, "/api/buy", buyRequest(signIntent(t, userKey, replay, chainID, vault)))
+		assert.Equal(t, http.StatusBadRequest, code, nonce)
+	}
+
+	assert.EqualValues(t, 1, countRows(t, db, &models.IntentNonce{}))
+	assert.EqualValues(t, 1, countRows(t, db, &models.Transaction{}))
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/models"
+	"scos/money"
+	"scos/server"
+)
+
+func TestCheckLiquidations_UsesPositionHealth(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	vault := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	// 链上仓位均为活跃，liquidate 等其他调用都成功
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)},
+		vault: stubAccount(map[string][]*big.Int{
+			"getStakeInfo(address,address)": {big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1)},
+		}),
+	})
+	t.Cleanup(func() { sim.Close() })
+
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return sim.Client(), nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: "http://sim", ChainID: "1337", VaultAddress: vault.Hex()},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.StakeRecord{}, &models.TokenPrice{}, &models.Transaction{}))
+	require.NoError(t, db.Create(&models.TokenPrice{Symbol: "APPLE", Price: money.NewFromInt(2000), UpdatedAt: time.Now()}).Error)
+
+	// 价格都从 3000 跌到 2000，只有借满的仓位健康度低于 1 - 0.2：
+	// 20 x 2000 / (40000 x 1.5) = 0.67；部分还款后 20 x 2000 / (20000 x 1.5) = 1.33；没有债务的仓位不清算
+	stakes := map[string]int64{
+		"0x0000000000000000000000000000000000000001": 40000,
+		"0x0000000000000000000000000000000000000002": 20000,
+		"0x0000000000000000000000000000000000000003": 0,
+	}
+	for user, debt := range stakes {
+		require.NoError(t, db.Create(&models.StakeRecord{
+			UserAddress: user, Staker: user, TokenAddress: APPLEtokenAddr, Chain: "sim", StockSymbol: "APPLE",
+			Amount: money.NewFromInt(20), SCOSBorrowed: money.NewFromInt(debt), StakePrice: money.NewFromInt(3000), Status: "active",
+		}).Error)
+	}
+
+	risk := config.RiskConfig{CollateralRatio: 1.5, LiquidationThreshold: 0.2}
+	timeouts := config.TimeoutConfig{Read: 5 * time.Second, Write: 5 * time.Second}
+	server.CheckLiquidations(context.Background(), db, bc, risk, timeouts)
+
+	var records []models.StakeRecord
+	require.NoError(t, db.Order("id").Find(&records).Error)
+	status := make(map[string]string)
+	ids := make(map[string]uint)
+	for _, r := range records {
+		status[r.UserAddress] = r.Status
+		ids[r.UserAddress] = r.ID
+	}
+	// 仓位状态等索引器处理 Liquidated 事件后再更新
+	assert.Equal(t, map[string]string{
+		"0x0000000000000000000000000000000000000001": "active",
+		"0x0000000000000000000000000000000000000002": "active",
+		"0x0000000000000000000000000000000000000003": "active",
+	}, status)
+	var tx models.Transaction
+	require.NoError(t, db.Where("type = ?", "liquidate").First(&tx).Error)
+	assert.Equal(t, "pending", tx.Status)
+	assert.Equal(t, ids["0x0000000000000000000000000000000000000001"], tx.StakeID)
+
+	// 清算交易尚未上链时不重复发送
+	server.CheckLiquidations(context.Background(), db, bc, risk, timeouts)
+	assert.EqualValues(t, 1, countRows(t, db, &models.Transaction{}))
+}
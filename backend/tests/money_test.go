@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/money"
+)
+
+func TestMoney_ParseAndFormat(t *testing.T) {
+	for _, s := range []string{"", "1e5", "1/3", ".5", "1.", "0x10", " 1"} {
+		_, err := money.Parse(s)
+		assert.Error(t, err, s)
+	}
+
+	assert.Equal(t, "1.5", money.MustParse("1.500").String())
+	assert.Equal(t, "-2", money.MustParse("-2.0").String())
+	assert.Equal(t, "0.333333333333333333", money.NewFromInt(1).Div(money.NewFromInt(3)).String())
+	assert.Equal(t, "1.4", money.FromFloat(1.4).String())
+	assert.Equal(t, "0.3", money.MustParse("0.1").Add(money.MustParse("0.2")).String())
+	assert.Equal(t, "2.50", money.MustParse("2.5").StringFixed(2, money.HalfEven))
+
+	var d money.Decimal
+	require.NoError(t, json.Unmarshal([]byte(`120.25`), &d))
+	assert.Equal(t, "120.25", d.String())
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, `"120.25"`, string(data))
+}
+
+func TestMoney_Units(t *testing.T) {
+	units, err := money.MustParse("1.5").Units(6)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1_500_000), units)
+
+	_, err = money.MustParse("0.0000001").Units(6)
+	assert.Error(t, err)
+
+	assert.Equal(t, "1.5", money.FromUnits(big.NewInt(1_500_000), 6).String())
+
+	third := money.NewFromInt(2).Div(money.NewFromInt(3))
+	assert.Equal(t, big.NewInt(666_666), third.ToUnits(6, money.Down))
+	assert.Equal(t, big.NewInt(666_667), third.ToUnits(6, money.Up))
+	assert.Equal(t, big.NewInt(666_667), third.ToUnits(6, money.HalfEven))
+	assert.Equal(t, big.NewInt(2), money.MustParse("2.5").ToUnits(0, money.HalfEven))
+	assert.Equal(t, big.NewInt(4), money.MustParse("3.5").ToUnits(0, money.HalfEven))
+	assert.Equal(t, big.NewInt(-1), money.MustParse("-1.5").ToUnits(0, money.Down))
+}
+
+func TestMoney_StakeFractionalAmount(t *testing.T) {
+	r, _, vault := newFakeRouter(t)
+
+	// 1.5 x 3000 / 1.5 = 3000 SCOS，不受浮点误差影响
+	body := stakeBody("sim")
+	body["amount"] = "1.5"
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", body)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "3000", resp["scos_borrowed"])
+	assert.Equal(t, "1500000", resp["amount_wei"])
+	assert.Equal(t, "3000000000", resp["scos_amount_wei"])
+	executeBuilt(t, vault, resp)
+	txs := vault.Txs()
+	require.Len(t, txs, 1)
+	assert.Equal(t, big.NewInt(1_500_000), txs[0].Amount)
+	assert.Equal(t, big.NewInt(3_000_000_000), txs[0].SCOSAmount)
+
+	// 超过代币精度的数量被拒绝
+	body = stakeBody("sim")
+	body["amount"] = "0.0000001"
+	code, _ = doJSON(r, http.MethodPost, "/api/stake", body)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Len(t, vault.Txs(), 1)
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/contracts"
+)
+
+var (
+	multicall3Addr = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+	revertingAddr  = common.HexToAddress("0x00000000000000000000000000000000000000bd")
+)
+
+// countingNode 统计 eth_call 次数；multicall 为 true 时在标准地址模拟 Multicall3 的 aggregate3
+type countingNode struct {
+	simulated.Client
+	multicall bool
+	calls     *atomic.Int32
+}
+
+func (n countingNode) CodeAt(ctx context.Context, addr common.Address, block *big.Int) ([]byte, error) {
+	if n.multicall && addr == multicall3Addr {
+		return []byte{0x01}, nil
+	}
+	return n.Client.CodeAt(ctx, addr, block)
+}
+
+func (n countingNode) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	n.calls.Add(1)
+	if !n.multicall || msg.To == nil || *msg.To != multicall3Addr {
+		return n.Client.CallContract(ctx, msg, block)
+	}
+
+	parsed, _ := contracts.Multicall3MetaData.GetAbi()
+	args, err := parsed.Methods["aggregate3"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]contracts.Multicall3Call3)).(*[]contracts.Multicall3Call3)
+	results := make([]contracts.Multicall3Result, len(calls))
+	for i, call := range calls {
+		target := call.Target
+		data, err := n.Client.CallContract(ctx, ethereum.CallMsg{To: &target, Data: call.CallData}, block)
+		results[i] = contracts.Multicall3Result{Success: err == nil, ReturnData: data}
+	}
+	return parsed.Methods["aggregate3"].Outputs.Pack(results)
+}
+
+func newBatchClient(t *testing.T, multicall bool) (*blockchain.BlockchainClient, *atomic.Int32) {
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		common.HexToAddress(APPLEtokenAddr): stubAccount(map[string][]*big.Int{
+			"balanceOf(address)": {big.NewInt(2_500_000)},
+		}),
+		// PUSH1 0 PUSH1 0 REVERT
+		revertingAddr: {Code: []byte{0x60, 0x00, 0x60, 0x00, 0xfd}, Balance: big.NewInt(0)},
+	})
+	t.Cleanup(func() { sim.Close() })
+	sim.Commit()
+
+	calls := new(atomic.Int32)
+	node := countingNode{Client: sim.Client(), multicall: multicall, calls: calls}
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return node, nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	key, _ := crypto.GenerateKey()
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: "http://sim", ChainID: "1337", VaultAddress: APPLEtokenAddr},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+	return bc, calls
+}
+
+func balanceKeys(n int) []blockchain.BalanceKey {
+	keys := make([]blockchain.BalanceKey, n)
+	for i := range keys {
+		keys[i] = blockchain.BalanceKey{Token: common.HexToAddress(APPLEtokenAddr), Owner: common.BigToAddress(big.NewInt(int64(i + 1)))}
+	}
+	keys[n-1].Token = revertingAddr
+	return keys
+}
+
+func TestMulticall_BatchesCallsIntoAggregate3(t *testing.T) {
+	bc, calls := newBatchClient(t, true)
+
+	results, err := bc.TokenBalances(context.Background(), "sim", balanceKeys(250))
+	require.NoError(t, err)
+	require.Len(t, results, 250)
+
+	// 250 个调用按 200 一批合并为两次 eth_call
+	assert.EqualValues(t, 2, calls.Load())
+	assert.NoError(t, results[0].Err)
+	assert.EqualValues(t, 2_500_000, results[0].Value.Int64())
+	assert.EqualValues(t, 2_500_000, results[248].Value.Int64())
+	var revert *blockchain.RevertError
+	assert.ErrorAs(t, results[249].Err, &revert)
+}
+
+func TestMulticall_FallsBackToParallelCalls(t *testing.T) {
+	bc, calls := newBatchClient(t, false)
+
+	results, err := bc.TokenBalances(context.Background(), "sim", balanceKeys(20))
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 20, calls.Load())
+	assert.EqualValues(t, 2_500_000, results[0].Value.Int64())
+	var revert *blockchain.RevertError
+	assert.ErrorAs(t, results[19].Err, &revert)
+
+	_, err = bc.TokenBalances(context.Background(), "Reddoi", balanceKeys(1))
+	assert.ErrorIs(t, err, blockchain.ErrUnknownChain)
+}
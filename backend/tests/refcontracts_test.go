@@ -0,0 +1,319 @@
+package tests
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// 参考实现：在没有 solc 与 OpenZeppelin 的环境中，用 evmAsm 手写与 contracts/*.sol 语义一致的字节码，
+// 仅作为 loadArtifacts 的最后一级回退，不是编译器输出。存储布局、事件与 revert 信息与 OpenZeppelin v4 相同；
+// 算术溢出检查只保留合约中显式的 require。修改 .sol 时需要同步这里
+
+// ERC20 + Ownable 的存储槽位
+const (
+	erc20Balances  = 0
+	erc20Allowance = 1
+	erc20Supply    = 2
+	erc20Owner     = 5
+)
+
+// StockVault 的存储槽位
+const (
+	vaultOwner      = 0
+	vaultReentrancy = 1
+	vaultStakes     = 2
+	vaultSupported  = 3
+	vaultSCOS       = 4
+	vaultTreasury   = 5
+	vaultPrices     = 6
+	vaultRatio      = 7
+)
+
+// referenceArtifacts 返回 StockVault、SCOS、StockToken（Apple）的参考部署字节码
+func referenceArtifacts() map[string][]byte {
+	return map[string][]byte{
+		"SCOS":       erc20Code("SCOS Stablecoin", "SCOS", true),
+		"StockToken": erc20Code("Apple Stock", "APPLE", false),
+		"StockVault": vaultCode(),
+	}
+}
+
+// ---- Ownable ----
+
+func ownableCtor(a *evmAsm, slot uint64) {
+	a.sstore(num(slot), caller)
+	a.emit("OwnershipTransferred(address,address)", []expr{zeroAddr, caller})
+}
+
+func onlyOwner(a *evmAsm, slot uint64) {
+	a.require(eq(sload(num(slot)), caller), "Ownable: caller is not the owner")
+}
+
+func transferOwnership(a *evmAsm, slot uint64, newOwner expr) {
+	a.emit("OwnershipTransferred(address,address)", []expr{sload(num(slot)), newOwner})
+	a.sstore(num(slot), newOwner)
+}
+
+func ownableFuncs(slot uint64) []evmFunc {
+	return []evmFunc{
+		{"owner()", 0, func(a *evmAsm) { a.ret(sload(num(slot))) }},
+		{"renounceOwnership()", 0, func(a *evmAsm) {
+			onlyOwner(a, slot)
+			transferOwnership(a, slot, zeroAddr)
+		}},
+		{"transferOwnership(address)", 1, func(a *evmAsm) {
+			onlyOwner(a, slot)
+			a.require(iszero(iszero(arg(0))), "Ownable: new owner is the zero address")
+			transferOwnership(a, slot, arg(0))
+		}},
+	}
+}
+
+// ---- ERC20 ----
+
+var (
+	balanceOf = func(account expr) expr { return mapping(num(erc20Balances), account) }
+	allowance = func(owner, spender expr) expr { return mapping(num(erc20Allowance), owner, spender) }
+	notZero   = func(x expr) expr { return iszero(iszero(x)) }
+	trueWord  = num(1)
+)
+
+// erc20Transfer 使用 local(0)
+func erc20Transfer(a *evmAsm, from, to, amount expr) {
+	a.require(notZero(from), "ERC20: transfer from the zero address")
+	a.require(notZero(to), "ERC20: transfer to the zero address")
+	a.setLocal(0, sload(balanceOf(from)))
+	a.require(iszero(lt(local(0), amount)), "ERC20: transfer amount exceeds balance")
+	a.sstore(balanceOf(from), sub(local(0), amount))
+	a.sstore(balanceOf(to), add(sload(balanceOf(to)), amount))
+	a.emit("Transfer(address,address,uint256)", []expr{from, to}, amount)
+}
+
+func erc20Approve(a *evmAsm, owner, spender, amount expr) {
+	a.require(notZero(owner), "ERC20: approve from the zero address")
+	a.require(notZero(spender), "ERC20: approve to the zero address")
+	a.sstore(allowance(owner, spender), amount)
+	a.emit("Approval(address,address,uint256)", []expr{owner, spender}, amount)
+}
+
+// erc20SpendAllowance 使用 local(1)，无限授权不扣减
+func erc20SpendAllowance(a *evmAsm, owner, spender, amount expr) {
+	a.setLocal(1, sload(allowance(owner, spender)))
+	a.when(iszero(eq(local(1), word(maxUint256))), func() {
+		a.require(iszero(lt(local(1), amount)), "ERC20: insufficient allowance")
+		erc20Approve(a, owner, spender, sub(local(1), amount))
+	})
+}
+
+func erc20Mint(a *evmAsm, to, amount expr) {
+	a.require(notZero(to), "ERC20: mint to the zero address")
+	a.sstore(num(erc20Supply), add(sload(num(erc20Supply)), amount))
+	a.sstore(balanceOf(to), add(sload(balanceOf(to)), amount))
+	a.emit("Transfer(address,address,uint256)", []expr{zeroAddr, to}, amount)
+}
+
+// erc20Burn 使用 local(2)
+func erc20Burn(a *evmAsm, from, amount expr) {
+	a.require(notZero(from), "ERC20: burn from the zero address")
+	a.setLocal(2, sload(balanceOf(from)))
+	a.require(iszero(lt(local(2), amount)), "ERC20: burn amount exceeds balance")
+	a.sstore(balanceOf(from), sub(local(2), amount))
+	a.sstore(num(erc20Supply), sub(sload(num(erc20Supply)), amount))
+	a.emit("Transfer(address,address,uint256)", []expr{from, zeroAddr}, amount)
+}
+
+// erc20Code 对应 SCOS.sol 与 AppleStock.sol：OpenZeppelin ERC20 + Ownable，6 位小数，owner 可 mint/burn；
+// withBurnFrom 时加入 SCOS 的 burnFrom
+func erc20Code(name, symbol string, withBurnFrom bool) []byte {
+	funcs := []evmFunc{
+		{"name()", 0, func(a *evmAsm) { a.retString(name) }},
+		{"symbol()", 0, func(a *evmAsm) { a.retString(symbol) }},
+		{"decimals()", 0, func(a *evmAsm) { a.ret(num(6)) }},
+		{"totalSupply()", 0, func(a *evmAsm) { a.ret(sload(num(erc20Supply))) }},
+		{"balanceOf(address)", 1, func(a *evmAsm) { a.ret(sload(balanceOf(arg(0)))) }},
+		{"allowance(address,address)", 2, func(a *evmAsm) { a.ret(sload(allowance(arg(0), arg(1)))) }},
+		{"transfer(address,uint256)", 2, func(a *evmAsm) {
+			erc20Transfer(a, caller, arg(0), arg(1))
+			a.ret(trueWord)
+		}},
+		{"approve(address,uint256)", 2, func(a *evmAsm) {
+			erc20Approve(a, caller, arg(0), arg(1))
+			a.ret(trueWord)
+		}},
+		{"transferFrom(address,address,uint256)", 3, func(a *evmAsm) {
+			erc20SpendAllowance(a, arg(0), caller, arg(2))
+			erc20Transfer(a, arg(0), arg(1), arg(2))
+			a.ret(trueWord)
+		}},
+		{"increaseAllowance(address,uint256)", 2, func(a *evmAsm) {
+			erc20Approve(a, caller, arg(0), add(sload(allowance(caller, arg(0))), arg(1)))
+			a.ret(trueWord)
+		}},
+		{"decreaseAllowance(address,uint256)", 2, func(a *evmAsm) {
+			a.setLocal(1, sload(allowance(caller, arg(0))))
+			a.require(iszero(lt(local(1), arg(1))), "ERC20: decreased allowance below zero")
+			erc20Approve(a, caller, arg(0), sub(local(1), arg(1)))
+			a.ret(trueWord)
+		}},
+		{"mint(address,uint256)", 2, func(a *evmAsm) {
+			onlyOwner(a, erc20Owner)
+			erc20Mint(a, arg(0), arg(1))
+		}},
+		{"burn(address,uint256)", 2, func(a *evmAsm) {
+			onlyOwner(a, erc20Owner)
+			erc20Burn(a, arg(0), arg(1))
+		}},
+	}
+	if withBurnFrom {
+		funcs = append(funcs, evmFunc{"burnFrom(address,uint256)", 2, func(a *evmAsm) {
+			erc20SpendAllowance(a, arg(0), caller, arg(1))
+			erc20Burn(a, arg(0), arg(1))
+		}})
+	}
+	funcs = append(funcs, ownableFuncs(erc20Owner)...)
+	return deployCode(0, func(a *evmAsm) { ownableCtor(a, erc20Owner) }, runtimeCode(funcs))
+}
+
+// ---- StockVault ----
+
+func stakeSlot(user, token expr) expr { return mapping(num(vaultStakes), user, token) }
+
+// StakeInfo 的成员
+const (
+	stakeAmount   = 0
+	stakeBorrowed = 1
+	stakeTime     = 2
+	stakeActive   = 3
+)
+
+func maxBorrow(token, amount expr) expr {
+	return div(
+		mul(mul(amount, sload(mapping(num(vaultPrices), token))), num(10000)),
+		mul(word(big.NewInt(params.Ether)), sload(num(vaultRatio))),
+	)
+}
+
+func nonReentrant(a *evmAsm, body func()) {
+	a.require(iszero(eq(sload(num(vaultReentrancy)), num(2))), "ReentrancyGuard: reentrant call")
+	a.sstore(num(vaultReentrancy), num(2))
+	body()
+	a.sstore(num(vaultReentrancy), num(1))
+}
+
+func requireActive(a *evmAsm, stake expr) {
+	a.require(sload(field(stake, stakeActive)), "No active stake")
+}
+
+// closeStake 清空质押并把原数量保存到 local(3)
+func closeStake(a *evmAsm, stake expr) {
+	a.setLocal(3, sload(field(stake, stakeAmount)))
+	a.sstore(field(stake, stakeActive), num(0))
+	a.sstore(field(stake, stakeAmount), num(0))
+	a.sstore(field(stake, stakeBorrowed), num(0))
+}
+
+func stakeFields(stake expr) []expr {
+	return []expr{
+		sload(field(stake, stakeAmount)),
+		sload(field(stake, stakeBorrowed)),
+		sload(field(stake, stakeTime)),
+		sload(field(stake, stakeActive)),
+	}
+}
+
+// vaultCode 对应 StockVault.sol，构造参数为 (scosToken, treasury, collateralRatio)
+func vaultCode() []byte {
+	scos := sload(num(vaultSCOS))
+	mine := func(token expr) expr { return stakeSlot(caller, token) }
+
+	funcs := []evmFunc{
+		{"addSupportedToken(address)", 1, func(a *evmAsm) {
+			onlyOwner(a, vaultOwner)
+			a.sstore(mapping(num(vaultSupported), arg(0)), num(1))
+		}},
+		{"setPrice(address,uint256)", 2, func(a *evmAsm) {
+			onlyOwner(a, vaultOwner)
+			a.sstore(mapping(num(vaultPrices), arg(0)), arg(1))
+			a.emit("PriceUpdated(address,uint256)", []expr{arg(0)}, arg(1))
+		}},
+		{"maxBorrow(address,uint256)", 2, func(a *evmAsm) { a.ret(maxBorrow(arg(0), arg(1))) }},
+		{"stakeStock(address,uint256,uint256)", 3, func(a *evmAsm) {
+			nonReentrant(a, func() {
+				token, amount, scosAmount, stake := arg(0), arg(1), arg(2), mine(arg(0))
+				a.require(sload(mapping(num(vaultSupported), token)), "Token not supported")
+				a.require(notZero(amount), "Amount must be greater than 0")
+				a.require(iszero(sload(field(stake, stakeActive))), "Already staked")
+				a.require(iszero(gt(scosAmount, maxBorrow(token, amount))), "Exceeds collateral ratio")
+				a.call(token, "transferFrom(address,address,uint256)", true, caller, self, amount)
+				a.sstore(field(stake, stakeAmount), amount)
+				a.sstore(field(stake, stakeBorrowed), scosAmount)
+				a.sstore(field(stake, stakeTime), timestamp)
+				a.sstore(field(stake, stakeActive), num(1))
+				a.emit("StockStaked(address,address,uint256,uint256)", []expr{caller, token}, amount, scosAmount)
+			})
+		}},
+		{"unstakeStock(address)", 1, func(a *evmAsm) {
+			nonReentrant(a, func() {
+				token, stake := arg(0), mine(arg(0))
+				requireActive(a, stake)
+				a.setLocal(4, sload(field(stake, stakeBorrowed)))
+				closeStake(a, stake)
+				a.when(local(4), func() {
+					a.call(scos, "burnFrom(address,uint256)", false, caller, local(4))
+				})
+				a.call(token, "transfer(address,uint256)", true, caller, local(3))
+				a.emit("StockUnstaked(address,address,uint256)", []expr{caller, token}, local(3))
+			})
+		}},
+		{"withdrawStock(address,uint256)", 2, func(a *evmAsm) {
+			nonReentrant(a, func() {
+				token, amount, stake := arg(0), arg(1), mine(arg(0))
+				requireActive(a, stake)
+				a.require(notZero(amount), "Amount must be greater than 0")
+				a.require(lt(amount, sload(field(stake, stakeAmount))), "Use unstakeStock to withdraw everything")
+				a.require(iszero(gt(sload(field(stake, stakeBorrowed)), maxBorrow(token, sub(sload(field(stake, stakeAmount)), amount)))), "Exceeds collateral ratio")
+				a.sstore(field(stake, stakeAmount), sub(sload(field(stake, stakeAmount)), amount))
+				a.call(token, "transfer(address,uint256)", true, caller, amount)
+				a.emit("StockWithdrawn(address,address,uint256,uint256)", []expr{caller, token}, amount, sload(field(stake, stakeAmount)))
+			})
+		}},
+		{"repayDebt(address,uint256)", 2, func(a *evmAsm) {
+			nonReentrant(a, func() {
+				token, scosAmount, stake := arg(0), arg(1), mine(arg(0))
+				requireActive(a, stake)
+				a.require(notZero(scosAmount), "Amount must be greater than 0")
+				a.require(iszero(gt(scosAmount, sload(field(stake, stakeBorrowed)))), "Repay exceeds debt")
+				a.sstore(field(stake, stakeBorrowed), sub(sload(field(stake, stakeBorrowed)), scosAmount))
+				a.call(scos, "burnFrom(address,uint256)", false, caller, scosAmount)
+				a.emit("DebtRepaid(address,address,uint256,uint256)", []expr{caller, token}, scosAmount, sload(field(stake, stakeBorrowed)))
+			})
+		}},
+		{"liquidate(address,address)", 2, func(a *evmAsm) {
+			onlyOwner(a, vaultOwner)
+			user, token, stake := arg(0), arg(1), stakeSlot(arg(0), arg(1))
+			requireActive(a, stake)
+			closeStake(a, stake)
+			a.call(token, "transfer(address,uint256)", true, sload(num(vaultTreasury)), local(3))
+			a.emit("Liquidated(address,address,uint256)", []expr{user, token}, local(3))
+		}},
+		{"getStakeInfo(address,address)", 2, func(a *evmAsm) { a.ret(stakeFields(stakeSlot(arg(0), arg(1)))...) }},
+		{"userStakes(address,address)", 2, func(a *evmAsm) { a.ret(stakeFields(stakeSlot(arg(0), arg(1)))...) }},
+		{"supportedTokens(address)", 1, func(a *evmAsm) { a.ret(sload(mapping(num(vaultSupported), arg(0)))) }},
+		{"scosToken()", 0, func(a *evmAsm) { a.ret(scos) }},
+		{"treasury()", 0, func(a *evmAsm) { a.ret(sload(num(vaultTreasury))) }},
+		{"prices(address)", 1, func(a *evmAsm) { a.ret(sload(mapping(num(vaultPrices), arg(0)))) }},
+		{"collateralRatio()", 0, func(a *evmAsm) { a.ret(sload(num(vaultRatio))) }},
+	}
+	funcs = append(funcs, ownableFuncs(vaultOwner)...)
+
+	ctor := func(a *evmAsm) {
+		ownableCtor(a, vaultOwner)
+		a.sstore(num(vaultReentrancy), num(1))
+		a.require(iszero(lt(local(2), num(10000))), "Ratio below 100%")
+		a.sstore(num(vaultSCOS), local(0))
+		a.sstore(num(vaultTreasury), local(1))
+		a.sstore(num(vaultRatio), local(2))
+	}
+	return deployCode(3, ctor, runtimeCode(funcs))
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/handlers"
+)
+
+func newRegistryRouter(t *testing.T) *gin.Engine {
+	cfg := &config.Config{
+		AdminToken: "secret",
+		Signer:     config.SignerConfig{Type: "env", Env: "SCOS_PRIVATE_KEY"},
+		Chains: map[string]config.ChainInfo{
+			"sim": {RPC: "http://sim", ChainID: "1337", VaultAddress: APPLEtokenAddr, SCOSAddress: SCOStokenAddr},
+		},
+	}
+	env := newSimRouter(t, simRouterOptions{
+		chain: cfg.Chains["sim"],
+		routes: func(r *gin.Engine, db *gorm.DB, bc *blockchain.BlockchainClient) {
+			staking := handlers.NewStakingHandler(db, bc, config.RiskConfig{CollateralRatio: 1.4}, config.TimeoutConfig{Write: 5 * time.Second})
+			admin := handlers.NewAdminHandler(db, bc, cfg)
+			r.POST("/api/stake", staking.StakeStock)
+			group := r.Group("/api/admin", handlers.AdminAuth(cfg.AdminToken))
+			group.POST("/chains/:chain", admin.AddChain)
+			group.POST("/chains/:chain/disable", admin.DisableChain)
+			group.POST("/chains/:chain/enable", admin.EnableChain)
+			group.DELETE("/chains/:chain", admin.RemoveChain)
+		},
+	})
+	return env.r
+}
+
+func doJSON(r http.Handler, method, path string, body interface{}) (int, map[string]interface{}) {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Admin-Token", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func stakeBody(chain string) gin.H {
+	return gin.H{
+		"user_address":  userAddr,
+		"token_address": APPLEtokenAddr,
+		"chain":         chain,
+		"amount":        "1",
+		"stock_symbol":  "APPLE",
+	}
+}
+
+func TestRegistry_UnknownAndDisabledChains(t *testing.T) {
+	r := newRegistryRouter(t)
+
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", stakeBody("Reddoi"))
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "unknown_chain", resp["code"])
+
+	code, _ = doJSON(r, http.MethodPost, "/api/admin/chains/sim/disable", nil)
+	require.Equal(t, http.StatusOK, code)
+	code, resp = doJSON(r, http.MethodPost, "/api/stake", stakeBody("sim"))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "chain_unavailable", resp["code"])
+
+	// 重新启用后进入正常流程（价格不存在返回 404）
+	code, _ = doJSON(r, http.MethodPost, "/api/admin/chains/sim/enable", nil)
+	require.Equal(t, http.StatusOK, code)
+	body := signBody(t, "stake", stakeBody("sim"), common.HexToAddress(APPLEtokenAddr))
+	code, resp = doJSON(r, http.MethodPost, "/api/stake", body)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "Stock price not found", resp["error"])
+}
+
+func TestRegistry_AddAndRemoveChain(t *testing.T) {
+	r := newRegistryRouter(t)
+
+	chain := gin.H{
+		"rpc":           "http://sim",
+		"chain_id":      "1337",
+		"vault_address": APPLEtokenAddr,
+		"scos_address":  SCOStokenAddr,
+		"fees":          gin.H{"mode": "legacy", "max_fee_gwei": 50, "stuck_timeout": "5m"},
+	}
+	code, resp := doJSON(r, http.MethodPost, "/api/admin/chains/Scroll", chain)
+	require.Equal(t, http.StatusCreated, code, resp)
+
+	code, _ = doJSON(r, http.MethodPost, "/api/admin/chains/Scroll", chain)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, resp = doJSON(r, http.MethodPost, "/api/admin/chains/Bad", gin.H{"rpc": "http://sim", "chain_id": "x"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, resp["error"], "chain_id")
+
+	code, _ = doJSON(r, http.MethodDelete, "/api/admin/chains/Scroll", nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, resp = doJSON(r, http.MethodDelete, "/api/admin/chains/Scroll", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "unknown_chain", resp["code"])
+}
@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/blockchain"
+	"scos/config"
+)
+
+// downClient 模拟连接失败的节点
+type downClient struct {
+	simulated.Client
+}
+
+func (downClient) BlockNumber(context.Context) (uint64, error) {
+	return 0, errors.New("dial tcp: connection refused")
+}
+
+// sendDownClient 广播时连接中断的节点，记录广播次数
+type sendDownClient struct {
+	simulated.Client
+	sends *atomic.Int32
+}
+
+func (c sendDownClient) SendTransaction(context.Context, *types.Transaction) error {
+	c.sends.Add(1)
+	return errors.New("write tcp: connection reset by peer")
+}
+
+// countingClient 记录广播次数
+type countingClient struct {
+	simulated.Client
+	sends *atomic.Int32
+}
+
+func (c countingClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.sends.Add(1)
+	return c.Client.SendTransaction(ctx, tx)
+}
+
+func newSimChain(t *testing.T, blocks int) simulated.Client {
+	sim := simulated.NewBackend(types.GenesisAlloc{})
+	t.Cleanup(func() { sim.Close() })
+	for i := 0; i < blocks; i++ {
+		sim.Commit()
+	}
+	return sim.Client()
+}
+
+func newPoolClient(t *testing.T, nodes map[string]blockchain.ChainClient, urls ...string) *blockchain.BlockchainClient {
+	orig := blockchain.Dial
+	blockchain.Dial = func(url string) (blockchain.ChainClient, error) { return nodes[url], nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	key, _ := crypto.GenerateKey()
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: urls[0], RPCs: urls[1:], ChainID: "1337", VaultAddress: APPLEtokenAddr},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+	return bc
+}
+
+func mustClient(t *testing.T, bc *blockchain.BlockchainClient, chain string) blockchain.ChainClient {
+	t.Helper()
+	client, err := bc.GetClient(chain)
+	require.NoError(t, err)
+	return client
+}
+
+func TestRPCPool_SwitchesAwayFromLaggingNode(t *testing.T) {
+	fresh := newSimChain(t, 10)
+	bc := newPoolClient(t, map[string]blockchain.ChainClient{
+		"http://lagging": newSimChain(t, 1),
+		"http://fresh":   fresh,
+	}, "http://lagging", "http://fresh")
+
+	assert.Equal(t, "http://lagging", bc.Status()[0].ActiveRPC)
+
+	bc.ProbeRPCs(context.Background(), config.HealthConfig{MaxBlockLag: 5, MaxLatency: time.Second})
+
+	status := bc.Status()[0]
+	assert.Equal(t, "http://fresh", status.ActiveRPC)
+	assert.False(t, status.Endpoints[0].Healthy)
+	assert.EqualValues(t, 9, status.Endpoints[0].Lag)
+	assert.True(t, status.Endpoints[1].Healthy)
+
+	head, err := mustClient(t, bc, "sim").BlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, head)
+}
+
+func TestRPCPool_FailsOverOnConnectionError(t *testing.T) {
+	fresh := newSimChain(t, 3)
+	bc := newPoolClient(t, map[string]blockchain.ChainClient{
+		"http://down":  downClient{fresh},
+		"http://fresh": fresh,
+	}, "http://down", "http://fresh")
+
+	head, err := mustClient(t, bc, "sim").BlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, head)
+
+	status := bc.Status()[0]
+	assert.Equal(t, "http://fresh", status.ActiveRPC)
+	assert.Contains(t, status.Endpoints[0].Error, "connection refused")
+}
+
+func TestRPCPool_StartsUnavailableAndReconnects(t *testing.T) {
+	node := newSimChain(t, 2)
+	up := false
+	dials := 0
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) {
+		dials++
+		if !up {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		return node, nil
+	}
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	key, _ := crypto.GenerateKey()
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: "http://flaky", ChainID: "1337", VaultAddress: APPLEtokenAddr},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+
+	assert.False(t, bc.Available("sim"))
+	_, err = mustClient(t, bc, "sim").BlockNumber(context.Background())
+	assert.ErrorIs(t, err, blockchain.ErrChainUnavailable)
+
+	// 退避期间不会重复连接
+	cfg := config.HealthConfig{Interval: time.Minute, MaxBlockLag: 5, MaxLatency: time.Second,
+		ReconnectMin: 50 * time.Millisecond, ReconnectMax: time.Second}
+	bc.ProbeRPCs(context.Background(), cfg)
+	bc.ProbeRPCs(context.Background(), cfg)
+	assert.Equal(t, 2, dials)
+
+	up = true
+	time.Sleep(60 * time.Millisecond)
+	bc.ProbeRPCs(context.Background(), cfg)
+	assert.True(t, bc.Available("sim"))
+	head, err := mustClient(t, bc, "sim").BlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, head)
+}
+
+func TestRPCPool_DoesNotResendTransactionOnAnotherNode(t *testing.T) {
+	fresh := newSimChain(t, 1)
+	var failed, other atomic.Int32
+	bc := newPoolClient(t, map[string]blockchain.ChainClient{
+		"http://flaky": sendDownClient{fresh, &failed},
+		"http://fresh": countingClient{fresh, &other},
+	}, "http://flaky", "http://fresh")
+
+	key, _ := crypto.GenerateKey()
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1337)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(1337), Gas: 21000, GasFeeCap: big.NewInt(1e9), GasTipCap: big.NewInt(1),
+	})
+	require.NoError(t, err)
+
+	// 原节点可能已收下交易，换节点重发会被误报为失败，由调用方决定如何处理
+	err = mustClient(t, bc, "sim").SendTransaction(context.Background(), tx)
+	assert.ErrorContains(t, err, "connection reset")
+	assert.EqualValues(t, 1, failed.Load())
+	assert.EqualValues(t, 0, other.Load())
+
+	// 节点已切换，后续调用走新节点
+	assert.Equal(t, "http://fresh", bc.Status()[0].ActiveRPC)
+	_, err = mustClient(t, bc, "sim").BlockNumber(context.Background())
+	require.NoError(t, err)
+}
+
+func TestRPCPool_CallsDuringRemoveChain(t *testing.T) {
+	bc := newPoolClient(t, map[string]blockchain.ChainClient{"http://sim": newSimChain(t, 1)}, "http://sim")
+	client := mustClient(t, bc, "sim")
+	cfg := config.HealthConfig{Interval: time.Millisecond, MaxBlockLag: 5, MaxLatency: time.Second, ReconnectMin: time.Millisecond, ReconnectMax: time.Millisecond}
+
+	// 调用、探测与移除并发进行，不会使用被置空的客户端。移除时进行中的调用可能收到连接已关闭的错误
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := client.BlockNumber(context.Background()); err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			bc.ProbeRPCs(context.Background(), cfg)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, bc.RemoveChain("sim"))
+	wg.Wait()
+
+	// 移除后不再重新连接
+	_, err := client.BlockNumber(context.Background())
+	assert.ErrorIs(t, err, blockchain.ErrChainUnavailable)
+	bc.ProbeRPCs(context.Background(), cfg)
+	_, err = client.BlockNumber(context.Background())
+	assert.ErrorIs(t, err, blockchain.ErrChainUnavailable)
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/blockchain"
+	"scos/config"
+)
+
+var testChainID = big.NewInt(50341)
+
+func unsignedTx() *types.Transaction {
+	to := common.HexToAddress(APPLEtokenAddr)
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(3_000_000_000),
+		Gas:       100000,
+		To:        &to,
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func assertSignedBy(t *testing.T, signer blockchain.Signer, want common.Address) {
+	t.Helper()
+	assert.Equal(t, want, signer.Address())
+
+	signed, err := signer.SignTx(context.Background(), unsignedTx(), testChainID)
+	require.NoError(t, err)
+	from, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	require.NoError(t, err)
+	assert.Equal(t, want, from)
+}
+
+func TestSigner_FileKey(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	path := filepath.Join(t.TempDir(), "operator.key")
+	require.NoError(t, os.WriteFile(path, []byte(hexutil.Encode(crypto.FromECDSA(key))+"\n"), 0o600))
+
+	signer, err := blockchain.NewSigner(config.SignerConfig{Type: "file", Path: path})
+	require.NoError(t, err)
+	assertSignedBy(t, signer, crypto.PubkeyToAddress(key.PublicKey))
+}
+
+func TestSigner_Keystore(t *testing.T) {
+	dir := t.TempDir()
+	account, err := keystore.StoreKey(dir, "s3cret", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+	t.Setenv("OPERATOR_PASSWORD", "s3cret")
+
+	signer, err := blockchain.NewSigner(config.SignerConfig{
+		Type:        "keystore",
+		Path:        account.URL.Path,
+		PasswordEnv: "OPERATOR_PASSWORD",
+	})
+	require.NoError(t, err)
+	assertSignedBy(t, signer, account.Address)
+
+	t.Setenv("OPERATOR_PASSWORD", "wrong")
+	_, err = blockchain.NewSigner(config.SignerConfig{
+		Type:        "keystore",
+		Path:        account.URL.Path,
+		PasswordEnv: "OPERATOR_PASSWORD",
+	})
+	assert.Error(t, err)
+}
+
+// remoteSignerStub 模拟远程签名服务的 eth_signTransaction
+func remoteSignerStub(t *testing.T, signWith *ecdsa.PrivateKey) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []struct {
+				To                   *common.Address `json:"to"`
+				Gas                  hexutil.Uint64  `json:"gas"`
+				MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+				MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+				Value                *hexutil.Big    `json:"value"`
+				Input                hexutil.Bytes   `json:"input"`
+				Nonce                hexutil.Uint64  `json:"nonce"`
+				ChainID              *hexutil.Big    `json:"chainId"`
+			} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "eth_signTransaction", req.Method)
+
+		p := req.Params[0]
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   p.ChainID.ToInt(),
+			Nonce:     uint64(p.Nonce),
+			GasTipCap: p.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: p.MaxFeePerGas.ToInt(),
+			Gas:       uint64(p.Gas),
+			To:        p.To,
+			Value:     p.Value.ToInt(),
+			Data:      p.Input,
+		})
+		signed, err := types.SignTx(tx, types.LatestSignerForChainID(p.ChainID.ToInt()), signWith)
+		require.NoError(t, err)
+		raw, _ := signed.MarshalBinary()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  hexutil.Bytes(raw),
+		})
+	}))
+}
+
+func TestSigner_Remote(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	srv := remoteSignerStub(t, key)
+	defer srv.Close()
+
+	signer, err := blockchain.NewSigner(config.SignerConfig{Type: "remote", URL: srv.URL, Address: addr.Hex()})
+	require.NoError(t, err)
+	assertSignedBy(t, signer, addr)
+
+	// 远程服务用了别的账户签名时必须拒绝
+	other, _ := crypto.GenerateKey()
+	signer, err = blockchain.NewSigner(config.SignerConfig{
+		Type:    "remote",
+		URL:     srv.URL,
+		Address: crypto.PubkeyToAddress(other.PublicKey).Hex(),
+	})
+	require.NoError(t, err)
+	_, err = signer.SignTx(context.Background(), unsignedTx(), testChainID)
+	assert.ErrorContains(t, err, "signed by")
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/handlers"
+	"scos/models"
+)
+
+// hungNode 区块高度正常，但 eth_call 一直不返回，直到调用方取消
+type hungNode struct {
+	simulated.Client
+}
+
+func (hungNode) CallContract(ctx context.Context, _ ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeouts_HungNodeDoesNotBlockHandlers(t *testing.T) {
+	node := hungNode{newSimChain(t, 1)}
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return node, nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	key, _ := crypto.GenerateKey()
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: "http://hung", ChainID: "1337", VaultAddress: APPLEtokenAddr},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.StakeRecord{}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := handlers.NewChainHandler(db, bc, config.TimeoutConfig{Read: 100 * time.Millisecond})
+	r.GET("/api/chain/:chain/balance/:token/:user", h.GetBalance)
+	path := fmt.Sprintf("/api/chain/sim/balance/%s/%s", APPLEtokenAddr, userAddr)
+
+	start := time.Now()
+	code, resp := doJSON(r, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusGatewayTimeout, code)
+	assert.Equal(t, "timeout", resp["code"])
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// 客户端断开时立即中止
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start = time.Now()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// 超时与取消不算节点故障
+	assert.True(t, bc.Available("sim"))
+}
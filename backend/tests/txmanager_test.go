@@ -0,0 +1,545 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"scos/blockchain"
+	"scos/config"
+)
+
+// rpcError 节点返回的 JSON-RPC 错误，连接池不会因此切换节点
+type rpcError struct {
+	msg  string
+	data interface{}
+}
+
+func (e *rpcError) Error() string          { return e.msg }
+func (e *rpcError) ErrorCode() int         { return -32000 }
+func (e *rpcError) ErrorData() interface{} { return e.data }
+
+// fakeNode 可编程的单账户节点：记录广播的交易，按队列返回广播错误。
+// 内嵌的 ChainClient 为 nil，用到未实现的方法会直接 panic
+type fakeNode struct {
+	blockchain.ChainClient
+
+	mu       sync.Mutex
+	baseFee  *big.Int // nil 表示最新区块没有 baseFee（未启用 London）
+	gasPrice *big.Int
+	tip      *big.Int
+	latest   uint64 // 已上链的 nonce 数
+	pending  uint64 // 含交易池的下一个 nonce
+	callErr  error
+	sendErrs []error
+	sent     []*types.Transaction
+	pool     map[common.Hash]*types.Transaction
+	mined    map[common.Hash]bool
+}
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{
+		baseFee:  big.NewInt(10e9),
+		gasPrice: big.NewInt(20e9),
+		tip:      big.NewInt(1e9),
+		pool:     make(map[common.Hash]*types.Transaction),
+		mined:    make(map[common.Hash]bool),
+	}
+}
+
+func (n *fakeNode) ChainID(context.Context) (*big.Int, error) { return big.NewInt(1337), nil }
+
+func (n *fakeNode) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return &types.Header{Number: big.NewInt(1), BaseFee: n.baseFee}, nil
+}
+
+func (n *fakeNode) SuggestGasPrice(context.Context) (*big.Int, error) { return n.gasPrice, nil }
+
+func (n *fakeNode) SuggestGasTipCap(context.Context) (*big.Int, error) { return n.tip, nil }
+
+func (n *fakeNode) PendingCallContract(context.Context, ethereum.CallMsg) ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return nil, n.callErr
+}
+
+func (n *fakeNode) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return 50000, n.callErr
+}
+
+func (n *fakeNode) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.pending, nil
+}
+
+func (n *fakeNode) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.latest, nil
+}
+
+func (n *fakeNode) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.sendErrs) > 0 {
+		err := n.sendErrs[0]
+		n.sendErrs = n.sendErrs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	n.sent = append(n.sent, tx)
+	n.pool[tx.Hash()] = tx
+	if tx.Nonce() >= n.pending {
+		n.pending = tx.Nonce() + 1
+	}
+	return nil
+}
+
+func (n *fakeNode) TransactionByHash(_ context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	tx, ok := n.pool[hash]
+	switch {
+	case ok && n.mined[hash]:
+		return tx, false, nil
+	case ok && tx.Nonce() >= n.latest:
+		return tx, true, nil
+	}
+	// nonce 已被其他版本占用的交易会被节点丢弃
+	return nil, false, ethereum.NotFound
+}
+
+func (n *fakeNode) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.mined[hash] {
+		return nil, ethereum.NotFound
+	}
+	return &types.Receipt{TxHash: hash, Status: types.ReceiptStatusSuccessful}, nil
+}
+
+// mine 打包同一 nonce 的某个版本
+func (n *fakeNode) mine(hash common.Hash) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.mined[hash] = true
+	if nonce := n.pool[hash].Nonce(); nonce >= n.latest {
+		n.latest = nonce + 1
+	}
+}
+
+func (n *fakeNode) sentTxs() []*types.Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*types.Transaction(nil), n.sent...)
+}
+
+func newFakeNodeClient(t *testing.T, node *fakeNode, fees config.FeeConfig) (*blockchain.BlockchainClient, *ecdsa.PrivateKey) {
+	orig := blockchain.Dial
+	blockchain.Dial = func(string) (blockchain.ChainClient, error) { return node, nil }
+	t.Cleanup(func() { blockchain.Dial = orig })
+
+	key, _ := crypto.GenerateKey()
+	bc, err := blockchain.NewBlockchainClient(map[string]config.ChainInfo{
+		"sim": {RPC: "http://fake", ChainID: "1337", VaultAddress: APPLEtokenAddr, Fees: fees},
+	}, map[string]blockchain.Signer{config.DefaultSigner: blockchain.NewKeySigner(key)})
+	require.NoError(t, err)
+	return bc, key
+}
+
+// sendOperatorTx 以运营账户发送一笔金库交易
+func sendOperatorTx(t *testing.T, bc *blockchain.BlockchainClient) common.Hash {
+	t.Helper()
+	hash, err := bc.Liquidate(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	require.NoError(t, err)
+	return common.HexToHash(hash)
+}
+
+func TestTxManager_CancelKeepsHashHistory(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{StuckTimeout: time.Nanosecond, BumpPercent: 20})
+	ctx := context.Background()
+
+	original := sendOperatorTx(t, bc)
+	replaced := bc.ReplaceStuck(ctx)
+	require.Len(t, replaced, 1)
+	bumped := replaced[0].NewHash
+
+	cancelHash, err := bc.CancelTx(ctx, "sim", bumped.Hex())
+	require.NoError(t, err)
+	cancel := common.HexToHash(cancelHash)
+	sent := node.sentTxs()
+	require.Len(t, sent, 3)
+	assert.Equal(t, sent[0].Nonce(), sent[2].Nonce())
+	assert.Zero(t, sent[2].Value().Sign())
+	assert.Empty(t, sent[2].Data())
+
+	// 最终上链的是最初的版本：改写原操作的记录（停在加价后的哈希），而不是取消交易的记录
+	node.mine(original)
+	assert.Equal(t, []blockchain.Replacement{{Chain: "sim", OldHash: bumped, NewHash: original}}, bc.ReplaceStuck(ctx))
+	assert.NotEqual(t, cancel, original)
+
+	// 已清理，不会再次报告
+	assert.Empty(t, bc.ReplaceStuck(ctx))
+}
+
+func TestTxManager_CancelMinedReportsNothing(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{StuckTimeout: time.Hour})
+	ctx := context.Background()
+
+	original := sendOperatorTx(t, bc)
+	cancelHash, err := bc.CancelTx(ctx, "sim", original.Hex())
+	require.NoError(t, err)
+
+	// 取消交易上链：两条记录的哈希都不变，原交易由回执监控标记为 dropped
+	node.mine(common.HexToHash(cancelHash))
+	assert.Empty(t, bc.ReplaceStuck(ctx))
+
+	_, err = bc.CancelTx(ctx, "sim", original.Hex())
+	assert.ErrorIs(t, err, blockchain.ErrTxNotPending)
+}
+
+func TestTxManager_CancelAfterRestart(t *testing.T) {
+	node := newFakeNode()
+	bc, key := newFakeNodeClient(t, node, config.FeeConfig{StuckTimeout: time.Hour})
+	ctx := context.Background()
+
+	// 重启前发出的交易只在节点交易池中
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+	pending, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID: big.NewInt(1337), Nonce: 0, Gas: 21000, GasFeeCap: big.NewInt(21e9), GasTipCap: big.NewInt(1e9),
+		To: &common.Address{}, Value: big.NewInt(0),
+	})
+	require.NoError(t, err)
+	require.NoError(t, node.SendTransaction(ctx, pending))
+
+	other, _ := crypto.GenerateKey()
+	foreign, err := types.SignNewTx(other, signer, &types.DynamicFeeTx{
+		ChainID: big.NewInt(1337), Gas: 21000, GasFeeCap: big.NewInt(21e9), GasTipCap: big.NewInt(1e9), To: &common.Address{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, node.SendTransaction(ctx, foreign))
+	_, err = bc.CancelTx(ctx, "sim", foreign.Hash().Hex())
+	assert.ErrorIs(t, err, blockchain.ErrNotOperatorTx)
+
+	_, err = bc.CancelTx(ctx, "sim", pending.Hash().Hex())
+	require.NoError(t, err)
+
+	// 原交易仍然上链：哈希已记录，不需要改写
+	node.mine(pending.Hash())
+	assert.Empty(t, bc.ReplaceStuck(ctx))
+}
+
+func TestTxManager_ConcurrentSendsUseDistinctNonces(t *testing.T) {
+	node := newFakeNode()
+	node.pending = 5
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{})
+
+	const senders = 20
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := bc.Liquidate(context.Background(), "sim", userAddr, APPLEtokenAddr)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for _, tx := range node.sentTxs() {
+		assert.False(t, seen[tx.Nonce()], "nonce %d reused", tx.Nonce())
+		seen[tx.Nonce()] = true
+	}
+	require.Len(t, seen, senders)
+	for nonce := uint64(5); nonce < 5+senders; nonce++ {
+		assert.True(t, seen[nonce], "nonce %d skipped", nonce)
+	}
+}
+
+func TestTxManager_ResyncsNonceAfterNonceError(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{})
+
+	sendOperatorTx(t, bc)
+
+	// 其他进程用同一账户发了交易：本地分配的 nonce 1 被拒绝，同步到节点的 3 后重试
+	node.mu.Lock()
+	node.pending = 3
+	node.sendErrs = []error{&rpcError{msg: "nonce too low: next nonce 3, tx nonce 1"}}
+	node.mu.Unlock()
+	hash := sendOperatorTx(t, bc)
+	sent := node.sentTxs()
+	require.Len(t, sent, 2)
+	assert.Equal(t, hash, sent[1].Hash())
+	assert.EqualValues(t, 3, sent[1].Nonce())
+
+	// 其他错误不重试，下一次发送前重新同步
+	node.mu.Lock()
+	node.sendErrs = []error{&rpcError{msg: "insufficient funds for gas * price + value"}}
+	node.mu.Unlock()
+	_, err := bc.Liquidate(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	require.ErrorContains(t, err, "insufficient funds")
+	assert.Len(t, node.sentTxs(), 2)
+
+	node.mu.Lock()
+	node.pending = 7
+	node.mu.Unlock()
+	sendOperatorTx(t, bc)
+	sent = node.sentTxs()
+	require.Len(t, sent, 3)
+	assert.EqualValues(t, 7, sent[2].Nonce())
+}
+
+func TestTxManager_FeeModes(t *testing.T) {
+	gwei := func(v float64) *big.Int {
+		out, _ := new(big.Float).Mul(big.NewFloat(v), big.NewFloat(1e9)).Int(nil)
+		return out
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fees     config.FeeConfig
+		london   bool
+		dynamic  bool
+		feeCap   *big.Int // legacy 交易为 gasPrice
+		tipCap   *big.Int
+		errorMsg string
+	}{
+		{name: "auto on london", fees: config.FeeConfig{}, london: true, dynamic: true, feeCap: gwei(21), tipCap: gwei(1)},
+		{name: "auto before london", fees: config.FeeConfig{}, feeCap: gwei(20)},
+		{name: "legacy on london", fees: config.FeeConfig{Mode: "legacy"}, london: true, feeCap: gwei(20)},
+		{name: "dynamic before london", fees: config.FeeConfig{Mode: "dynamic"}, errorMsg: "EIP-1559"},
+		{name: "multiplier and caps", fees: config.FeeConfig{Multiplier: 1.5, MaxFeeGwei: 25, MaxTipGwei: 1.2}, london: true, dynamic: true, feeCap: gwei(25), tipCap: gwei(1.2)},
+		{name: "legacy cap", fees: config.FeeConfig{Multiplier: 2, MaxFeeGwei: 30}, feeCap: gwei(30)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			node := newFakeNode()
+			if !tc.london {
+				node.baseFee = nil
+			}
+			bc, _ := newFakeNodeClient(t, node, tc.fees)
+
+			_, err := bc.Liquidate(context.Background(), "sim", userAddr, APPLEtokenAddr)
+			if tc.errorMsg != "" {
+				require.ErrorContains(t, err, tc.errorMsg)
+				assert.Empty(t, node.sentTxs())
+				return
+			}
+			require.NoError(t, err)
+			sent := node.sentTxs()
+			require.Len(t, sent, 1)
+			if tc.dynamic {
+				assert.EqualValues(t, types.DynamicFeeTxType, sent[0].Type())
+				assert.Equal(t, tc.feeCap, sent[0].GasFeeCap())
+				assert.Equal(t, tc.tipCap, sent[0].GasTipCap())
+			} else {
+				assert.EqualValues(t, types.LegacyTxType, sent[0].Type())
+				assert.Equal(t, tc.feeCap, sent[0].GasPrice())
+			}
+			// 估算的 gas 上浮 20%
+			assert.EqualValues(t, 60000, sent[0].Gas())
+		})
+	}
+}
+
+func TestTxManager_DecodesReverts(t *testing.T) {
+	stringType, _ := abi.NewType("string", "", nil)
+	reason, err := abi.Arguments{{Type: stringType}}.Pack("No active stake")
+	require.NoError(t, err)
+	data := append(crypto.Keccak256([]byte("Error(string)"))[:4], reason...)
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{"revert data", &rpcError{msg: "execution reverted", data: hexutil.Encode(data)}, "No active stake"},
+		{"reason in message", &rpcError{msg: "execution reverted: Exceeds collateral ratio"}, "Exceeds collateral ratio"},
+		{"custom error", &rpcError{msg: "execution reverted", data: "0xdeadbeef"}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			node := newFakeNode()
+			node.callErr = tc.err
+			bc, _ := newFakeNodeClient(t, node, config.FeeConfig{})
+
+			_, err := bc.Liquidate(context.Background(), "sim", userAddr, APPLEtokenAddr)
+			var revert *blockchain.RevertError
+			require.ErrorAs(t, err, &revert)
+			assert.Equal(t, tc.reason, revert.Reason)
+			assert.Empty(t, node.sentTxs())
+		})
+	}
+
+	// 其他错误原样返回
+	node := newFakeNode()
+	node.callErr = &rpcError{msg: "header not found"}
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{})
+	_, err = bc.Liquidate(context.Background(), "sim", userAddr, APPLEtokenAddr)
+	var revert *blockchain.RevertError
+	assert.False(t, errors.As(err, &revert))
+	assert.ErrorContains(t, err, "header not found")
+}
+
+func TestTxManager_ReplaceStuckBumpsFees(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{StuckTimeout: time.Nanosecond, BumpPercent: 20, MaxFeeGwei: 100})
+	ctx := context.Background()
+
+	original := sendOperatorTx(t, bc)
+
+	// 按比例加价高于市场价
+	replaced := bc.ReplaceStuck(ctx)
+	require.Len(t, replaced, 1)
+	sent := node.sentTxs()
+	require.Len(t, sent, 2)
+	assert.Equal(t, blockchain.Replacement{Chain: "sim", OldHash: original, NewHash: sent[1].Hash()}, replaced[0])
+	assert.Equal(t, sent[0].Nonce(), sent[1].Nonce())
+	assert.Equal(t, sent[0].Data(), sent[1].Data())
+	assert.EqualValues(t, 25_200_000_000, sent[1].GasFeeCap().Int64())
+	assert.EqualValues(t, 1_200_000_000, sent[1].GasTipCap().Int64())
+
+	// 市场价上涨：取市场价，不超过 max_fee_gwei
+	node.mu.Lock()
+	node.baseFee = big.NewInt(60e9)
+	node.mu.Unlock()
+	replaced = bc.ReplaceStuck(ctx)
+	require.Len(t, replaced, 1)
+	sent = node.sentTxs()
+	require.Len(t, sent, 3)
+	assert.EqualValues(t, 100_000_000_000, sent[2].GasFeeCap().Int64())
+
+	// 已到上限，无法再加价 10%
+	assert.Empty(t, bc.ReplaceStuck(ctx))
+	assert.Len(t, node.sentTxs(), 3)
+
+	// 中间的版本上链：记录停在最新版本的哈希，改写为上链的版本
+	node.mine(sent[1].Hash())
+	assert.Equal(t, []blockchain.Replacement{{Chain: "sim", OldHash: sent[2].Hash(), NewHash: sent[1].Hash()}}, bc.ReplaceStuck(ctx))
+	assert.Empty(t, bc.ReplaceStuck(ctx))
+}
+
+func TestTxManager_ReplaceStuckLegacy(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{Mode: "legacy", StuckTimeout: time.Nanosecond, BumpPercent: 5})
+	ctx := context.Background()
+
+	sendOperatorTx(t, bc)
+	require.Len(t, bc.ReplaceStuck(ctx), 1)
+	sent := node.sentTxs()
+	require.Len(t, sent, 2)
+	assert.EqualValues(t, types.LegacyTxType, sent[1].Type())
+	// bump_percent 低于节点要求时按 10% 加价
+	assert.EqualValues(t, 22_000_000_000, sent[1].GasPrice().Int64())
+
+	// 唯一的版本上链，哈希不变
+	node.mine(sent[1].Hash())
+	assert.Empty(t, bc.ReplaceStuck(ctx))
+}
+
+func TestTxManager_ReplaceStuckDisabled(t *testing.T) {
+	node := newFakeNode()
+	bc, _ := newFakeNodeClient(t, node, config.FeeConfig{})
+
+	sendOperatorTx(t, bc)
+	assert.Empty(t, bc.ReplaceStuck(context.Background()))
+	assert.Len(t, node.sentTxs(), 1)
+}
+
+func TestTxManager_RotateReleasesLockWhileDraining(t *testing.T) {
+	node := newFakeNode()
+	bc, key := newFakeNodeClient(t, node, config.FeeConfig{StuckTimeout: time.Nanosecond, BumpPercent: 20})
+	ctx := context.Background()
+	oldAddr := crypto.PubkeyToAddress(key.PublicKey)
+	nextKey, _ := crypto.GenerateKey()
+	nextAddr := crypto.PubkeyToAddress(nextKey.PublicKey)
+
+	sendOperatorTx(t, bc)
+
+	type rotated struct {
+		old common.Address
+		err error
+	}
+	rotateDone := make(chan rotated, 1)
+	go func() {
+		old, err := bc.RotateSigner(ctx, "sim", blockchain.NewKeySigner(nextKey))
+		rotateDone <- rotated{old, err}
+	}()
+
+	// 已取消的 ctx 探测：轮换尚未开始时立即因等待超时失败，签名器不变
+	probe, cancel := context.WithCancel(ctx)
+	cancel()
+	require.Eventually(t, func() bool {
+		_, err := bc.RotateSigner(probe, "sim", blockchain.NewKeySigner(nextKey))
+		return errors.Is(err, blockchain.ErrRotationInProgress)
+	}, time.Second, 5*time.Millisecond)
+
+	// 等待期间卡住的交易仍可加价替换
+	replacedDone := make(chan []blockchain.Replacement, 1)
+	go func() { replacedDone <- bc.ReplaceStuck(ctx) }()
+	var replaced []blockchain.Replacement
+	select {
+	case replaced = <-replacedDone:
+	case <-time.After(time.Second):
+		t.Fatal("ReplaceStuck blocked by signer rotation")
+	}
+	require.Len(t, replaced, 1)
+
+	// 新的发送等待轮换完成
+	type sentTx struct {
+		hash string
+		err  error
+	}
+	sendDone := make(chan sentTx, 1)
+	go func() {
+		hash, err := bc.Liquidate(ctx, "sim", userAddr, APPLEtokenAddr)
+		sendDone <- sentTx{hash, err}
+	}()
+	select {
+	case <-sendDone:
+		t.Fatal("send went out during signer rotation")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	node.mine(replaced[0].NewHash)
+	select {
+	case r := <-rotateDone:
+		require.NoError(t, r.err)
+		assert.Equal(t, oldAddr, r.old)
+	case <-time.After(5 * time.Second):
+		t.Fatal("rotation did not finish after the pending transaction was mined")
+	}
+	signer, err := bc.SignerAddress("sim")
+	require.NoError(t, err)
+	assert.Equal(t, nextAddr, signer)
+
+	s := <-sendDone
+	require.NoError(t, s.err)
+	sent := node.sentTxs()
+	last := sent[len(sent)-1]
+	assert.Equal(t, common.HexToHash(s.hash), last.Hash())
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), last)
+	require.NoError(t, err)
+	assert.Equal(t, nextAddr, from)
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"scos/blockchain"
+	"scos/config"
+	"scos/contracts"
+	"scos/handlers"
+	"scos/models"
+	"scos/money"
+)
+
+var userTxVault = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+func newUserTxRouter(t *testing.T, user common.Address) (*gin.Engine, *gorm.DB, *simulated.Backend) {
+	env := newSimRouter(t, simRouterOptions{
+		alloc: types.GenesisAlloc{
+			user: {Balance: big.NewInt(1e18)},
+			// 链上仓位：100 APPLE，借出 70 SCOS
+			userTxVault: stubAccount(map[string][]*big.Int{
+				"getStakeInfo(address,address)": {big.NewInt(100_000_000), new(big.Int).Mul(big.NewInt(70), big.NewInt(1e18)), big.NewInt(1700000000), big.NewInt(1)},
+			}),
+			common.HexToAddress(APPLEtokenAddr): stubAccount(map[string][]*big.Int{"decimals()": {big.NewInt(6)}}),
+			common.HexToAddress(SCOStokenAddr): stubAccount(map[string][]*big.Int{
+				"decimals()":                 {big.NewInt(18)},
+				"balanceOf(address)":         {new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))},
+				"allowance(address,address)": {new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))},
+			}),
+		},
+		chain: config.ChainInfo{VaultAddress: userTxVault.Hex(), SCOSAddress: SCOStokenAddr},
+		routes: func(r *gin.Engine, db *gorm.DB, bc *blockchain.BlockchainClient) {
+			timeouts := config.TimeoutConfig{Read: 5 * time.Second, Write: 5 * time.Second}
+			staking := handlers.NewStakingHandler(db, bc, config.RiskConfig{CollateralRatio: 1.5}, timeouts)
+			h := handlers.NewUserTxHandler(db, bc, timeouts)
+			r.POST("/api/stake", staking.StakeStock)
+			r.POST("/api/repay", staking.RepayDebt)
+			r.POST("/api/withdraw", staking.WithdrawStock)
+			r.POST("/api/tx/build/approve", h.BuildApprove)
+			r.POST("/api/tx/submit", h.Submit)
+		},
+	})
+	require.NoError(t, env.db.Create(&models.TokenPrice{Symbol: "APPLE", Price: money.NewFromInt(3000), UpdatedAt: time.Now()}).Error)
+	return env.r, env.db, env.sim
+}
+
+// signBuilt 按接口返回的字段构造并签名交易
+func signBuilt(t *testing.T, key *ecdsa.PrivateKey, built map[string]interface{}) string {
+	chainID, _ := new(big.Int).SetString(built["chain_id"].(string), 10)
+	feeCap, _ := new(big.Int).SetString(built["max_fee_per_gas"].(string), 10)
+	tipCap, _ := new(big.Int).SetString(built["max_priority_fee_per_gas"].(string), 10)
+	to := common.HexToAddress(built["to"].(string))
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     uint64(built["nonce"].(float64)),
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       uint64(built["gas"].(float64)),
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      hexutil.MustDecode(built["data"].(string)),
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+	require.NoError(t, err)
+	raw, err := signed.MarshalBinary()
+	require.NoError(t, err)
+	return hexutil.Encode(raw)
+}
+
+func TestUserTx_BuildSignAndSubmitStake(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	user := crypto.PubkeyToAddress(key.PublicKey)
+	r, db, sim := newUserTxRouter(t, user)
+
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", gin.H{
+		"user_address": user.Hex(), "token_address": APPLEtokenAddr, "chain": "sim", "amount": "3", "stock_symbol": "APPLE",
+	})
+	require.Equal(t, http.StatusOK, code, resp)
+	built := resp["tx"].(map[string]interface{})
+	assert.Equal(t, user.Hex(), built["from"])
+	assert.Equal(t, userTxVault.Hex(), built["to"])
+	assert.EqualValues(t, 2, built["type"])
+	assert.Equal(t, "6000", resp["scos_borrowed"])
+
+	code, resp = doJSON(r, http.MethodPost, "/api/tx/submit", gin.H{"chain": "sim", "raw_tx": signBuilt(t, key, built)})
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "stake", resp["type"])
+	assert.Equal(t, user.Hex(), resp["from"])
+
+	sim.Commit()
+	receipt, err := sim.Client().TransactionReceipt(context.Background(), common.HexToHash(resp["tx_hash"].(string)))
+	require.NoError(t, err)
+	assert.EqualValues(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	var tx models.Transaction
+	require.NoError(t, db.First(&tx).Error)
+	assert.Equal(t, "user", tx.Source)
+	assert.Equal(t, "pending", tx.Status)
+	assert.EqualValues(t, 0, countRows(t, db, &models.StakeRecord{}))
+}
+
+func TestUserTx_SubmitReportsUnrecordedTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	user := crypto.PubkeyToAddress(key.PublicKey)
+	r, db, sim := newUserTxRouter(t, user)
+
+	code, resp := doJSON(r, http.MethodPost, "/api/stake", gin.H{
+		"user_address": user.Hex(), "token_address": APPLEtokenAddr, "chain": "sim", "amount": "3", "stock_symbol": "APPLE",
+	})
+	require.Equal(t, http.StatusOK, code, resp)
+	raw := signBuilt(t, key, resp["tx"].(map[string]interface{}))
+
+	// 交易已广播但记录失败：返回 500 并带上哈希
+	require.NoError(t, db.Migrator().DropTable(&models.Transaction{}))
+	code, resp = doJSON(r, http.MethodPost, "/api/tx/submit", gin.H{"chain": "sim", "raw_tx": raw})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "tx_not_recorded", resp["code"])
+
+	sim.Commit()
+	receipt, err := sim.Client().TransactionReceipt(context.Background(), common.HexToHash(resp["tx_hash"].(string)))
+	require.NoError(t, err)
+	assert.EqualValues(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
+
+func TestUserTx_RepayAndWithdrawTargetVault(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	user := crypto.PubkeyToAddress(key.PublicKey)
+	r, db, _ := newUserTxRouter(t, user)
+	require.NoError(t, db.Create(&models.StakeRecord{
+		UserAddress: user.Hex(), Staker: user.Hex(), TokenAddress: APPLEtokenAddr, Chain: "sim", StockSymbol: "APPLE",
+		Amount: money.NewFromInt(100), SCOSBorrowed: money.NewFromInt(70), Status: "active",
+	}).Error)
+	parsed, _ := contracts.StockVaultMetaData.GetAbi()
+
+	for _, tc := range []struct {
+		path, amount, method, kind, units string
+	}{
+		{"/api/repay", "1.5", "repayDebt", "repay", "1500000000000000000"},
+		{"/api/withdraw", "2.5", "withdrawStock", "withdraw", "2500000"},
+	} {
+		code, resp := doJSON(r, http.MethodPost, tc.path, gin.H{
+			"user_address": user.Hex(), "token_address": APPLEtokenAddr, "chain": "sim", "amount": tc.amount,
+		})
+		require.Equal(t, http.StatusOK, code, resp)
+		built := resp["tx"].(map[string]interface{})
+		assert.Equal(t, user.Hex(), built["from"])
+		assert.Equal(t, userTxVault.Hex(), built["to"])
+
+		data := hexutil.MustDecode(built["data"].(string))
+		method, err := parsed.MethodById(data[:4])
+		require.NoError(t, err)
+		assert.Equal(t, tc.method, method.Name)
+		args, err := method.Inputs.Unpack(data[4:])
+		require.NoError(t, err)
+		assert.Equal(t, common.HexToAddress(APPLEtokenAddr), args[0])
+		assert.Equal(t, tc.units, args[1].(*big.Int).String())
+
+		code, resp = doJSON(r, http.MethodPost, "/api/tx/submit", gin.H{"chain": "sim", "raw_tx": signBuilt(t, key, built)})
+		require.Equal(t, http.StatusOK, code, resp)
+		assert.Equal(t, tc.kind, resp["type"])
+	}
+	assert.EqualValues(t, 2, countRows(t, db, &models.Transaction{}))
+}
+
+func TestUserTx_RejectsUnsupportedTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	user := crypto.PubkeyToAddress(key.PublicKey)
+	r, db, _ := newUserTxRouter(t, user)
+
+	code, resp := doJSON(r, http.MethodPost, "/api/tx/build/approve", gin.H{
+		"user_address": user.Hex(), "token_address": SCOStokenAddr, "chain": "sim", "amount": "1",
+	})
+	require.Equal(t, http.StatusOK, code, resp)
+	built := resp["tx"].(map[string]interface{})
+
+	// 授权给金库以外的地址
+	other := built["data"].(string)
+	built["data"] = other[:10] + "000000000000000000000000" + "00000000000000000000000000000000000000ff" + other[74:]
+	code, _ = doJSON(r, http.MethodPost, "/api/tx/submit", gin.H{"chain": "sim", "raw_tx": signBuilt(t, key, built)})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 代币的 transfer
+	parsed, _ := contracts.StockTokenMetaData.GetAbi()
+	transfer, _ := parsed.Pack("transfer", userTxVault, big.NewInt(1))
+	built["data"] = hexutil.Encode(transfer)
+	code, _ = doJSON(r, http.MethodPost, "/api/tx/submit", gin.H{"chain": "sim", "raw_tx": signBuilt(t, key, built)})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 其他链的签名
+	built["data"] = other
+	built["chain_id"] = "1"
+	code, _ = doJSON(r, http.MethodPost, "/api/tx/submit", gin.H{"chain": "sim", "raw_tx": signBuilt(t, key, built)})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	assert.EqualValues(t, 0, countRows(t, db, &models.Transaction{}))
+}
@@ -15,6 +15,12 @@ contract SCOS is ERC20, Ownable {
         _burn(from, amount);
     }
 
+    // Burns SCOS the holder has approved to the caller; the vault uses it to take repaid debt out of supply
+    function burnFrom(address account, uint256 amount) external {
+        _spendAllowance(account, msg.sender, amount);
+        _burn(account, amount);
+    }
+
     function decimals() public view virtual override returns (uint8) {
         return 6; // USDC style decimals
     }
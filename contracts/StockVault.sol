@@ -5,6 +5,10 @@ import "@openzeppelin/contracts/token/ERC20/IERC20.sol";
 import "@openzeppelin/contracts/access/Ownable.sol";
 import "@openzeppelin/contracts/security/ReentrancyGuard.sol";
 
+interface ISCOS is IERC20 {
+    function burnFrom(address account, uint256 amount) external;
+}
+
 contract StockVault is Ownable, ReentrancyGuard {
     struct StakeInfo {
         uint256 amount;
@@ -16,25 +20,47 @@ contract StockVault is Ownable, ReentrancyGuard {
     mapping(address => mapping(address => StakeInfo)) public userStakes; // user => token => stake
     mapping(address => bool) public supportedTokens;
 
-    IERC20 public scosToken;
+    ISCOS public scosToken;
     address public treasury;
 
+    // SCOS base units per token base unit, scaled by 1e18; pushed by the owner from the price feed
+    mapping(address => uint256) public prices;
+    // Collateral ratio in basis points, 15000 = 150%
+    uint256 public collateralRatio;
+
     event StockStaked(address indexed user, address indexed token, uint256 amount, uint256 borrowedSCOS);
     event StockUnstaked(address indexed user, address indexed token, uint256 amount);
     event Liquidated(address indexed user, address indexed token, uint256 amount);
+    event StockWithdrawn(address indexed user, address indexed token, uint256 amount, uint256 remaining);
+    event DebtRepaid(address indexed user, address indexed token, uint256 scosAmount, uint256 remainingDebt);
+    event PriceUpdated(address indexed token, uint256 price);
 
-    constructor(address _scosToken, address _treasury) {
-        scosToken = IERC20(_scosToken);
+    constructor(address _scosToken, address _treasury, uint256 _collateralRatio) {
+        require(_collateralRatio >= 10000, "Ratio below 100%");
+        scosToken = ISCOS(_scosToken);
         treasury = _treasury;
+        collateralRatio = _collateralRatio;
     }
 
     function addSupportedToken(address token) external onlyOwner {
         supportedTokens[token] = true;
     }
 
+    function setPrice(address token, uint256 price) external onlyOwner {
+        prices[token] = price;
+        emit PriceUpdated(token, price);
+    }
+
+    // Most SCOS that `amount` of `token` can back at the current price and collateral ratio
+    function maxBorrow(address token, uint256 amount) public view returns (uint256) {
+        return amount * prices[token] * 10000 / (1e18 * collateralRatio);
+    }
+
     function stakeStock(address token, uint256 amount, uint256 scosAmount) external nonReentrant {
         require(supportedTokens[token], "Token not supported");
         require(amount > 0, "Amount must be greater than 0");
+        require(!userStakes[msg.sender][token].active, "Already staked");
+        require(scosAmount <= maxBorrow(token, amount), "Exceeds collateral ratio");
 
         IERC20(token).transferFrom(msg.sender, address(this), amount);
 
@@ -53,15 +79,49 @@ contract StockVault is Ownable, ReentrancyGuard {
         require(stake.active, "No active stake");
 
         uint256 amount = stake.amount;
+        uint256 debt = stake.borrowedSCOS;
         stake.active = false;
         stake.amount = 0;
         stake.borrowedSCOS = 0;
 
+        // The SCOS minted against the stake is burned; the caller approves it beforehand
+        if (debt > 0) {
+            scosToken.burnFrom(msg.sender, debt);
+        }
         IERC20(token).transfer(msg.sender, amount);
 
         emit StockUnstaked(msg.sender, token, amount);
     }
 
+    // Partial withdrawal back to the staker, limited by the collateral ratio at the vault's price
+    function withdrawStock(address token, uint256 amount) external nonReentrant {
+        StakeInfo storage stake = userStakes[msg.sender][token];
+        require(stake.active, "No active stake");
+        require(amount > 0, "Amount must be greater than 0");
+        require(amount < stake.amount, "Use unstakeStock to withdraw everything");
+        require(stake.borrowedSCOS <= maxBorrow(token, stake.amount - amount), "Exceeds collateral ratio");
+
+        stake.amount -= amount;
+
+        IERC20(token).transfer(msg.sender, amount);
+
+        emit StockWithdrawn(msg.sender, token, amount, stake.amount);
+    }
+
+    // Partial repayment; burns the repaid SCOS from the staker
+    function repayDebt(address token, uint256 scosAmount) external nonReentrant {
+        StakeInfo storage stake = userStakes[msg.sender][token];
+        require(stake.active, "No active stake");
+        require(scosAmount > 0, "Amount must be greater than 0");
+        require(scosAmount <= stake.borrowedSCOS, "Repay exceeds debt");
+
+        stake.borrowedSCOS -= scosAmount;
+        // The repaid SCOS leaves supply in the same call; the caller approves it beforehand
+        scosToken.burnFrom(msg.sender, scosAmount);
+
+        emit DebtRepaid(msg.sender, token, scosAmount, stake.borrowedSCOS);
+    }
+
     function liquidate(address user, address token) external onlyOwner {
         StakeInfo storage stake = userStakes[user][token];
         require(stake.active, "No active stake");
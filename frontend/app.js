@@ -71,7 +71,7 @@ class DeFiApp {
             if (response.ok) {
                 this.stockPrices = {};
                 data.stocks.forEach(stock => {
-                    this.stockPrices[stock.symbol] = stock.price;
+                    this.stockPrices[stock.symbol] = Number(stock.price);
                 });
                 this.updateStockSelectors();
             }
@@ -120,7 +120,7 @@ class DeFiApp {
             const data = await response.json();
 
             if (response.ok) {
-                document.getElementById('stockPrice').textContent = `${data.price.toFixed(2)}`;
+                document.getElementById('stockPrice').textContent = `${Number(data.price).toFixed(2)}`;
                 this.calculateBorrowableAmount();
             } else {
                 this.showMessage('获取价格失败', 'error');
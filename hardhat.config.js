@@ -1,17 +1,18 @@
 import "@nomicfoundation/hardhat-toolbox";
 
-const PRIVATE_KEY = "0x01c7939dc6827ee10bb7d26f420618c4af88c0029aa70be202f1ca7f29fe5bb4";
+// 部署账户的私钥从环境变量读取，不要写入仓库；未设置时只能使用本地 hardhat 网络
+const accounts = process.env.ADMIN_PRIVATE_KEY ? [process.env.ADMIN_PRIVATE_KEY] : [];
 
 const config = {
     solidity: "0.8.20",
     networks: {
         reddio: {
-            url: "https://reddio-dev.reddio.com/",
-            accounts: [PRIVATE_KEY],
+            url: process.env.RPC_PROVIDER || "https://reddio-dev.reddio.com/",
+            accounts,
         },
         scrollSepolia: {
             url: "https://sepolia-rpc.scroll.io",
-            accounts: [PRIVATE_KEY],
+            accounts,
         },
     },
 };
@@ -1,21 +1,57 @@
 // scripts/deploy.js
 import { ethers } from "hardhat";
 
+// 金库抵押率（基点），与 backend/config.yaml 的 risk.collateral_ratio 一致
+const COLLATERAL_RATIO_BPS = 14000;
+
+// 金库支持的股票代币及初始价格（以 SCOS 计），价格由环境变量给出，例如 APPLE_PRICE=190.5
+const STOCKS = [{ symbol: "APPLE", contract: "Apple", priceEnv: "APPLE_PRICE" }];
+
+// vaultPrice 与后端 SetTokenPrice 相同：每个代币最小单位对应的 SCOS 最小单位，放大 1e18
+function vaultPrice(price, tokenDecimals, scosDecimals) {
+    return ethers.parseUnits(price, 18) * 10n ** scosDecimals / 10n ** tokenDecimals;
+}
+
+async function deploy(name, ...args) {
+    const factory = await ethers.getContractFactory(name);
+    const contract = await factory.deploy(...args);
+    await contract.waitForDeployment();
+    console.log(`${name} deployed to: ${await contract.getAddress()}`);
+    return contract;
+}
+
 async function main() {
-    const AppleStock = await ethers.getContractFactory("AppleStock");
-    const appleStock = await AppleStock.deploy();
-    await appleStock.deployed();
-    console.log(`AppleStock deployed to: ${appleStock.address}`);
-
-    const SCOS = await ethers.getContractFactory("SCOS");
-    const scos = await SCOS.deploy();
-    await scos.deployed();
-    console.log(`SCOS deployed to: ${scos.address}`);
-
-    const StockVault = await ethers.getContractFactory("StockVault");
-    const stockVault = await StockVault.deploy();
-    await stockVault.deployed();
-    console.log(`StockVault deployed to: ${stockVault.address}`);
+    for (const stock of STOCKS) {
+        if (!process.env[stock.priceEnv]) {
+            throw new Error(`set ${stock.priceEnv} to the current ${stock.symbol} price in SCOS`);
+        }
+    }
+    const [deployer] = await ethers.getSigners();
+
+    // 部署账户是 SCOS 的 owner，后端索引到质押后用它 mint；不是运营账户时在链配置的 scos_signer 中指定
+    const scos = await deploy("SCOS");
+    // treasury 接收清算的抵押品；赎回与还款偿还的 SCOS 由金库销毁
+    const stockVault = await deploy("StockVault", await scos.getAddress(), deployer.address, COLLATERAL_RATIO_BPS);
+    const scosDecimals = await scos.decimals();
+
+    const tokens = {};
+    for (const stock of STOCKS) {
+        const token = await deploy(stock.contract);
+        const address = await token.getAddress();
+        await (await stockVault.addSupportedToken(address)).wait();
+        // 价格未设置时 maxBorrow 为 0，质押无法借出 SCOS
+        const price = vaultPrice(process.env[stock.priceEnv], await token.decimals(), scosDecimals);
+        await (await stockVault.setPrice(address, price)).wait();
+        tokens[stock.symbol] = address;
+    }
+
+    console.log("backend/config.yaml:");
+    console.log(`    scos_address: "${await scos.getAddress()}"`);
+    console.log(`    vault_address: "${await stockVault.getAddress()}"`);
+    console.log("    tokens:");
+    for (const [symbol, address] of Object.entries(tokens)) {
+        console.log(`      ${symbol}: "${address}"`);
+    }
 }
 
 main().catch((error) => {